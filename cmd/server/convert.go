@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/tchaudhry91/laqz/svc"
+	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
+)
+
+// quizFormat is a file format quizzes can be converted from and to
+type quizFormat struct {
+	parse func(r io.Reader, name string) (*models.QuizBundle, []string, error)
+	write func(w io.Writer, b *models.QuizBundle) error
+}
+
+var quizFormats = map[string]quizFormat{
+	"gift":    {models.ParseGIFT, models.WriteGIFT},
+	"opentdb": {models.ParseOpenTDB, models.WriteOpenTDB},
+}
+
+func lookupFormat(name string) (quizFormat, error) {
+	f, ok := quizFormats[strings.ToLower(name)]
+	if !ok {
+		return f, fmt.Errorf("Unknown format %q, expected gift or opentdb", name)
+	}
+	return f, nil
+}
+
+// runImport creates a private quiz owned by an existing user from a local file
+func runImport(s models.QuizStore, args []string) error {
+	if len(args) < 3 || len(args) > 4 {
+		return fmt.Errorf("usage: laqz import [flags] gift|opentdb <file> <owner email> [quiz name]")
+	}
+	format, err := lookupFormat(args[0])
+	if err != nil {
+		return err
+	}
+	name := strings.TrimSuffix(filepath.Base(args[1]), filepath.Ext(args[1]))
+	if len(args) == 4 {
+		name = args[3]
+	}
+	f, err := os.Open(args[1])
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	b, warnings, err := format.parse(f, name)
+	if err != nil {
+		return err
+	}
+	for _, w := range warnings {
+		fmt.Fprintln(os.Stderr, w)
+	}
+	if len(b.Questions) == 0 {
+		return fmt.Errorf("No questions found in %s", args[1])
+	}
+
+	hub := svc.NewQHub(s)
+	ctx := context.WithValue(context.Background(), hub.UserContextKey(), &models.User{Email: args[2]})
+	qz, err := hub.ImportQuiz(ctx, b)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return fmt.Errorf("There is no user %s, the owner must have logged in once", args[2])
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Imported %d questions into quiz %d %q\n", len(qz.Questions), qz.ID, qz.Name)
+	return nil
+}
+
+// runExport writes a quiz to a local file, or to stdout when no file is given
+func runExport(s models.QuizStore, args []string) (err error) {
+	if len(args) < 2 || len(args) > 3 {
+		return fmt.Errorf("usage: laqz export [flags] gift|opentdb <quiz id> [file]")
+	}
+	format, err := lookupFormat(args[0])
+	if err != nil {
+		return err
+	}
+	id, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("Bad ID supplied: %s", args[1])
+	}
+	qz, err := s.GetQuiz(uint(id))
+	if err != nil {
+		return err
+	}
+
+	var w io.Writer = os.Stdout
+	if len(args) == 3 {
+		f, err := os.Create(args[2])
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if cerr := f.Close(); err == nil {
+				err = cerr
+			}
+		}()
+		w = f
+	}
+	return format.write(w, models.NewQuizBundle(qz, qz.Questions))
+}
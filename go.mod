@@ -5,21 +5,17 @@ go 1.16
 require (
 	cloud.google.com/go/firestore v1.1.1 // indirect
 	firebase.google.com/go v3.13.0+incompatible
+	github.com/glebarez/sqlite v1.4.0
 	github.com/go-kit/kit v0.10.0
 	github.com/gorilla/handlers v1.5.1
 	github.com/gorilla/mux v1.8.0
 	github.com/gorilla/websocket v1.4.2
-	github.com/jackc/pgproto3/v2 v2.0.7 // indirect
-	github.com/jinzhu/now v1.1.2 // indirect
 	github.com/peterbourgon/ff v1.7.0
 	github.com/pkg/errors v0.9.1 // indirect
-	github.com/stretchr/testify v1.6.1 // indirect
-	golang.org/x/crypto v0.0.0-20210317152858-513c2a44f670 // indirect
 	golang.org/x/oauth2 v0.0.0-20200107190931-bf48bf16ab8d // indirect
-	golang.org/x/text v0.3.5 // indirect
 	golang.org/x/xerrors v0.0.0-20200804184101-5ec99f83aff1 // indirect
 	google.golang.org/api v0.17.0 // indirect
 	google.golang.org/grpc v1.29.1 // indirect
-	gorm.io/driver/postgres v1.0.8
-	gorm.io/gorm v1.21.4
+	gorm.io/driver/postgres v1.3.1
+	gorm.io/gorm v1.23.2
 )
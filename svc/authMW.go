@@ -8,7 +8,7 @@ import (
 	"github.com/tchaudhry91/laqz/svc/models"
 )
 
-func (s QServer) AuthMW(next http.Handler) http.Handler {
+func (s *QServer) AuthMW(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		idtoken, ok := req.Header["Token"]
 		if !ok {
@@ -33,7 +33,7 @@ func (s QServer) AuthMW(next http.Handler) http.Handler {
 	})
 }
 
-func (s QServer) OptionalAuthMW(next http.Handler) http.Handler {
+func (s *QServer) OptionalAuthMW(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
 		idtoken, ok := req.Header["Token"]
 		if !ok {
@@ -57,3 +57,14 @@ func (s QServer) OptionalAuthMW(next http.Handler) http.Handler {
 		next.ServeHTTP(w, req)
 	})
 }
+
+// WSAuthMW is OptionalAuthMW for websockets. Browsers can't set headers on them, so the token may
+// come in the token query parameter instead.
+func (s *QServer) WSAuthMW(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if token := req.URL.Query().Get("token"); token != "" && req.Header.Get("Token") == "" {
+			req.Header.Set("Token", token)
+		}
+		s.OptionalAuthMW(next).ServeHTTP(w, req)
+	})
+}
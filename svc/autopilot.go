@@ -0,0 +1,245 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/tchaudhry91/laqz/svc/models"
+)
+
+var AutopilotOffError = errors.New("The play session isn't on autopilot")
+var SessionOverError = errors.New("The play session is over")
+var NoQuestionsError = errors.New("The quiz has no questions to play")
+var UntimedQuestionsError = errors.New("The autopilot needs a timer for questions that have none")
+
+// AutopilotEvent is the autopilot of a play session changing step, being paused, resumed or handed
+// back to the quizmaster. Finished is set when it played the last question.
+type AutopilotEvent struct {
+	Code     uint
+	Engaged  bool
+	Paused   bool
+	Step     models.AutopilotStep
+	NextAt   *time.Time
+	Finished bool
+}
+
+// OnAutopilot sets what is told about the steps of autopilots. It is called from the goroutines
+// flying them as well.
+func (ps *PlaySessionSvc) OnAutopilot(notify func(e AutopilotEvent)) {
+	ps.notifyMx.Lock()
+	defer ps.notifyMx.Unlock()
+	ps.onAutopilot = notify
+}
+
+func (ps *PlaySessionSvc) notifyAutopilot(s *models.PlaySession) {
+	ps.notifyMx.Lock()
+	notify := ps.onAutopilot
+	ps.notifyMx.Unlock()
+	if notify == nil {
+		return
+	}
+	notify(AutopilotEvent{
+		Code:     s.Code,
+		Engaged:  s.Autopilot,
+		Paused:   s.AutopilotPaused,
+		Step:     s.AutopilotStep,
+		NextAt:   s.AutopilotNextAt,
+		Finished: s.State == models.StateFinished,
+	})
+}
+
+// StartPSAutopilot hands the play session to the autopilot, starting it if need be. The autopilot
+// picks up at the current question and plays the rest of the quiz with the pauses p.
+func (ps *PlaySessionSvc) StartPSAutopilot(ctx context.Context, code uint, p models.AutopilotPauses) (err error) {
+	if p.QuestionSeconds == 0 {
+		return UntimedQuestionsError
+	}
+	return ps.flyAutopilot(ctx, code, func(db models.QuizStore, s *models.PlaySession, now time.Time) error {
+		if s.State == models.StateFinished {
+			return SessionOverError
+		}
+		qqs, err := db.GetQuestionsByQuiz(s.Quiz.ID)
+		if err != nil {
+			return err
+		}
+		if s.CurrentQuestionIndex >= len(qqs) {
+			return NoQuestionsError
+		}
+		if s.State != models.StateInProgress {
+			s.SetInProgress()
+			err = goToQuestion(db, s, qqs, s.CurrentQuestionIndex)
+			if err != nil {
+				return err
+			}
+		}
+		q, r, err := currentQuestion(db, s)
+		if err != nil {
+			return err
+		}
+		s.UpdateQuestion(q)
+		s.EngageAutopilot(p, r.TimerFor(q), now)
+		return catchUpAutopilot(db, s, q, r, now)
+	})
+}
+
+// PausePSAutopilot freezes the autopilot, timer of the question included, until it is resumed
+func (ps *PlaySessionSvc) PausePSAutopilot(ctx context.Context, code uint) (err error) {
+	return ps.flyAutopilot(ctx, code, func(db models.QuizStore, s *models.PlaySession, now time.Time) error {
+		if !s.Autopilot || s.State != models.StateInProgress {
+			return AutopilotOffError
+		}
+		if !s.AutopilotPaused {
+			s.PauseAutopilot(now)
+		}
+		return db.UpdatePlaySession(s)
+	})
+}
+
+// ResumePSAutopilot lets a paused autopilot carry on with the time that was left of its step. An
+// autopilot that wasn't paused is set flying again where it is, in case it was lost with a restart.
+func (ps *PlaySessionSvc) ResumePSAutopilot(ctx context.Context, code uint) (err error) {
+	return ps.flyAutopilot(ctx, code, func(db models.QuizStore, s *models.PlaySession, now time.Time) error {
+		if !s.Autopilot || s.State != models.StateInProgress {
+			return AutopilotOffError
+		}
+		if s.AutopilotPaused {
+			s.ResumeAutopilot(now)
+		}
+		q, r, err := currentQuestion(db, s)
+		if err != nil {
+			return err
+		}
+		s.UpdateQuestion(q)
+		return catchUpAutopilot(db, s, q, r, now)
+	})
+}
+
+// TakeOverPS gives the play session back to the quizmaster, who carries on by hand from where the
+// autopilot was. Moving to another question or revealing the answer by hand takes over as well.
+func (ps *PlaySessionSvc) TakeOverPS(ctx context.Context, code uint) (err error) {
+	return ps.flyAutopilot(ctx, code, func(db models.QuizStore, s *models.PlaySession, now time.Time) error {
+		if !s.Autopilot {
+			return nil
+		}
+		s.TakeOver()
+		return db.UpdatePlaySession(s)
+	})
+}
+
+// flyAutopilot runs fn on the play session for the quizmaster, then lines up the timers of the
+// session with what fn made of it
+func (ps *PlaySessionSvc) flyAutopilot(ctx context.Context, code uint, fn func(db models.QuizStore, s *models.PlaySession, now time.Time) error) (err error) {
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
+	if err != nil {
+		return err
+	}
+	var s *models.PlaySession
+	err = ps.withRetry(ctx, func(db models.QuizStore) (err error) {
+		s, err = db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		return fn(db, s, time.Now())
+	})
+	if err != nil {
+		return err
+	}
+	ps.syncTimer(s)
+	ps.syncAutopilot(s)
+	ps.notifyAutopilot(s)
+	return nil
+}
+
+// catchUpAutopilot saves s, revealing the answer to q first if its time ran out while the autopilot
+// wasn't looking
+func catchUpAutopilot(db models.QuizStore, s *models.PlaySession, q *models.Question, r *models.Round, now time.Time) error {
+	if s.AutopilotRunning() && s.AutopilotStep == models.AutopilotQuestion && s.TimeUp(now) {
+		s.SetAutopilotStep(models.AutopilotAnswer, now)
+		return revealAnswer(db, s, q, r)
+	}
+	return db.UpdatePlaySession(s)
+}
+
+// syncAutopilot flies the autopilot of s in the background, in place of the one flying the session
+// before. The question step has no autopilot of its own, the timer of the question ends it.
+func (ps *PlaySessionSvc) syncAutopilot(s *models.PlaySession) {
+	if !s.AutopilotRunning() || s.AutopilotNextAt == nil {
+		ps.pilots.stop(s.Code)
+		return
+	}
+	stop := ps.pilots.replace(s.Code)
+	go ps.runAutopilot(s.Code, *s.AutopilotNextAt, stop)
+}
+
+func (ps *PlaySessionSvc) runAutopilot(code uint, nextAt time.Time, stop chan struct{}) {
+	next := time.NewTimer(time.Until(nextAt))
+	defer next.Stop()
+	select {
+	case <-stop:
+		return
+	case <-next.C:
+	}
+	ps.pilots.finish(code, stop)
+	s, err := ps.stepAutopilot(code)
+	if err != nil || s == nil {
+		return
+	}
+	ps.syncTimer(s)
+	ps.syncAutopilot(s)
+	ps.notifyAutopilot(s)
+}
+
+// stepAutopilot moves the autopilot on from the answer to the scores, and from the scores to the
+// next question. After the last question the session is over. The session is nil when the
+// autopilot was paused or taken over in the meantime, or its questions changed under it.
+func (ps *PlaySessionSvc) stepAutopilot(code uint) (s *models.PlaySession, err error) {
+	err = ps.withRetry(context.Background(), func(db models.QuizStore) error {
+		s = nil
+		current, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		now := time.Now()
+		// The time went through the database, which may have rounded it a little
+		if !current.AutopilotRunning() || current.AutopilotNextAt == nil || current.AutopilotNextAt.After(now.Add(time.Millisecond)) {
+			return nil
+		}
+		s = current
+		if s.AutopilotStep == models.AutopilotAnswer && s.AutopilotPauses.ScoresSeconds > 0 {
+			s.SetAutopilotStep(models.AutopilotScores, now)
+			return db.UpdatePlaySession(s)
+		}
+		qqs, err := db.GetQuestionsByQuiz(s.Quiz.ID)
+		if err != nil {
+			return err
+		}
+		if s.CurrentQuestionIndex >= len(qqs)-1 {
+			s.SetFinished()
+			s.StopTimer()
+			s.TakeOver()
+			return db.UpdatePlaySession(s)
+		}
+		err = goToQuestion(db, s, qqs, s.CurrentQuestionIndex+1)
+		if err != nil {
+			return err
+		}
+		q, r, err := currentQuestion(db, s)
+		if errors.Is(err, NoCurrentQuestionError) {
+			s = nil
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if s.QuestionEndsAt == nil {
+			s.StartTimer(s.AutopilotTimer(r.TimerFor(q)), now)
+		}
+		s.SetAutopilotStep(models.AutopilotQuestion, now)
+		return db.UpdatePlaySession(s)
+	})
+	return s, err
+}
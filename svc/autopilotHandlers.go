@@ -0,0 +1,125 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
+)
+
+// respondAutopilotErr maps the errors of the autopilot endpoints onto status codes
+func (s *QServer) respondAutopilotErr(w http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		s.respond(w, req, nil, http.StatusNotFound, nil)
+	case errors.Is(err, UntimedQuestionsError):
+		s.respond(w, req, nil, http.StatusBadRequest, err)
+	case errors.Is(err, NotPermittedError):
+		s.respond(w, req, nil, http.StatusForbidden, err)
+	case errors.Is(err, AutopilotOffError), errors.Is(err, SessionOverError), errors.Is(err, NoQuestionsError), errors.Is(err, NoCurrentQuestionError), errors.Is(err, models.ErrConflict):
+		s.respond(w, req, nil, http.StatusConflict, err)
+	default:
+		s.respond(w, req, nil, http.StatusInternalServerError, err)
+	}
+}
+
+// StartPSAutopilot hands the session to the autopilot. The pauses are optional, the body may be
+// left out altogether.
+func (s *QServer) StartPSAutopilot() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			QuestionSeconds *uint `json:"question_seconds,omitempty"`
+			AnswerSeconds   *uint `json:"answer_seconds,omitempty"`
+			ScoresSeconds   *uint `json:"scores_seconds,omitempty"`
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil && !errors.Is(err, io.EOF) {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		p := models.DefaultAutopilotPauses()
+		if r.QuestionSeconds != nil {
+			p.QuestionSeconds = *r.QuestionSeconds
+		}
+		if r.AnswerSeconds != nil {
+			p.AnswerSeconds = *r.AnswerSeconds
+		}
+		if r.ScoresSeconds != nil {
+			p.ScoresSeconds = *r.ScoresSeconds
+		}
+		s.respondAutopilot(w, req, s.hub.StartPSAutopilot(req.Context(), uint(id), p))
+	}
+}
+
+func (s *QServer) PausePSAutopilot() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		s.respondAutopilot(w, req, s.hub.PausePSAutopilot(req.Context(), uint(id)))
+	}
+}
+
+func (s *QServer) ResumePSAutopilot() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		s.respondAutopilot(w, req, s.hub.ResumePSAutopilot(req.Context(), uint(id)))
+	}
+}
+
+// TakeOverPS switches the autopilot off, the quizmaster carries on by hand
+func (s *QServer) TakeOverPS() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		s.respondAutopilot(w, req, s.hub.TakeOverPS(req.Context(), uint(id)))
+	}
+}
+
+// respondAutopilot answers a call to an autopilot endpoint. The players hear about the autopilot
+// from broadcastAutopilot.
+func (s *QServer) respondAutopilot(w http.ResponseWriter, req *http.Request, err error) {
+	if err != nil {
+		s.respondAutopilotErr(w, req, err)
+		return
+	}
+	s.respond(w, req, nil, http.StatusNoContent, nil)
+}
+
+// broadcastAutopilot relays what the autopilot of a session does to its players, who reload the
+// session to see the question, answer or scores it moved on to. Sessions nobody is connected to
+// are skipped.
+func (s *QServer) broadcastAutopilot(e AutopilotEvent) {
+	h := s.hubFor(e.Code, false)
+	if h == nil {
+		return
+	}
+	h.BroadcastAutopilot(e)
+	h.BroadcastReload()
+}
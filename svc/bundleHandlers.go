@@ -0,0 +1,264 @@
+package svc
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
+)
+
+// A bundle is a zip archive holding the quiz as quiz.json next to the uploaded files its questions
+// use under assets/. Links to files elsewhere are kept as they are.
+const (
+	bundleManifest = "quiz.json"
+	bundleAssetDir = "assets/"
+	// maxBundleSize caps the size of an uploaded bundle, maxBundleEntrySize every file inside it
+	maxBundleSize      = 200 << 20
+	maxBundleEntrySize = 20 << 20
+)
+
+// uploadedAsset returns the file name of a link served from the upload directory
+func (s *QServer) uploadedAsset(link string) (name string, ok bool) {
+	prefix := s.externalURL + "/uploads/"
+	if !strings.HasPrefix(link, prefix) {
+		return "", false
+	}
+	name = strings.TrimPrefix(link, prefix)
+	if name == "" || name != filepath.Base(name) {
+		return "", false
+	}
+	return name, true
+}
+
+// writeBundle packs b with the uploaded files it links to. Links to uploads that have gone missing
+// are left untouched.
+func (s *QServer) writeBundle(w io.Writer, b *models.QuizBundle) error {
+	zw := zip.NewWriter(w)
+	packed := map[string]bool{}
+	pack := func(link string) (string, error) {
+		name, ok := s.uploadedAsset(link)
+		if !ok {
+			return link, nil
+		}
+		if packed[name] {
+			return bundleAssetDir + name, nil
+		}
+		f, err := os.Open(filepath.Join(s.fileUploadDirectory, name))
+		if err != nil {
+			if os.IsNotExist(err) {
+				return link, nil
+			}
+			return link, err
+		}
+		defer f.Close()
+		entry, err := zw.Create(bundleAssetDir + name)
+		if err != nil {
+			return link, err
+		}
+		_, err = io.Copy(entry, f)
+		if err != nil {
+			return link, err
+		}
+		packed[name] = true
+		return bundleAssetDir + name, nil
+	}
+
+	var err error
+	for _, q := range b.Questions {
+		q.ImageLink, err = pack(q.ImageLink)
+		if err != nil {
+			return err
+		}
+		q.AudioLink, err = pack(q.AudioLink)
+		if err != nil {
+			return err
+		}
+	}
+	manifest, err := zw.Create(bundleManifest)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(manifest)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(b)
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// readBundle unpacks a bundle, copying its assets into the upload directory under fresh names and
+// pointing the links at them. The written files are returned so they can be removed again if the
+// import fails.
+func (s *QServer) readBundle(r io.ReaderAt, size int64) (b *models.QuizBundle, written []string, err error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return b, written, fmt.Errorf("%w: %v", InvalidBundleError, err)
+	}
+	files := map[string]*zip.File{}
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	mf, ok := files[bundleManifest]
+	if !ok {
+		return b, written, fmt.Errorf("%w: %s is missing", InvalidBundleError, bundleManifest)
+	}
+	rc, err := mf.Open()
+	if err != nil {
+		return b, written, fmt.Errorf("%w: %v", InvalidBundleError, err)
+	}
+	defer rc.Close()
+	b = &models.QuizBundle{}
+	err = json.NewDecoder(io.LimitReader(rc, maxBundleEntrySize)).Decode(b)
+	if err != nil {
+		return b, written, fmt.Errorf("%w: %v", InvalidBundleError, err)
+	}
+
+	unpacked := map[string]string{}
+	unpack := func(link string) (string, error) {
+		if !strings.HasPrefix(link, bundleAssetDir) {
+			return link, nil
+		}
+		if url, ok := unpacked[link]; ok {
+			return url, nil
+		}
+		f, ok := files[link]
+		name := strings.TrimPrefix(link, bundleAssetDir)
+		if !ok || name != path.Base(name) {
+			return link, fmt.Errorf("%w: %s is missing", InvalidBundleError, link)
+		}
+		assetName := randSeq(15) + filepath.Ext(name)
+		dest := filepath.Join(s.fileUploadDirectory, assetName)
+		err := copyBundleEntry(f, dest)
+		if err != nil {
+			return link, err
+		}
+		written = append(written, dest)
+		unpacked[link] = fmt.Sprintf("%s/uploads/%s", s.externalURL, assetName)
+		return unpacked[link], nil
+	}
+
+	rand.Seed(time.Now().UnixNano())
+	for _, q := range b.Questions {
+		q.ImageLink, err = unpack(q.ImageLink)
+		if err != nil {
+			return b, written, err
+		}
+		q.AudioLink, err = unpack(q.AudioLink)
+		if err != nil {
+			return b, written, err
+		}
+	}
+	return b, written, nil
+}
+
+func copyBundleEntry(f *zip.File, dest string) error {
+	if f.UncompressedSize64 > maxBundleEntrySize {
+		return fmt.Errorf("%w: %s is larger than %d bytes", InvalidBundleError, f.Name, maxBundleEntrySize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return fmt.Errorf("%w: %v", InvalidBundleError, err)
+	}
+	defer rc.Close()
+	out, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, io.LimitReader(rc, maxBundleEntrySize))
+	if err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func (s *QServer) ExportQuiz() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Err string `json:"err,omitempty"`
+		}
+
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		b, err := s.hub.ExportQuiz(req.Context(), uint(id))
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				s.respond(w, req, Response{Err: err.Error()}, http.StatusNotFound, nil)
+				return
+			}
+			if errors.Is(err, NotPermittedError) {
+				s.respond(w, req, nil, http.StatusForbidden, err)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"quiz-%d.zip\"", id))
+		err = s.writeBundle(w, b)
+		if err != nil {
+			// The headers are gone already, all we can do is cut the archive short
+			s.logger.Log("path", req.URL.Path, "method", req.Method, "err", err)
+		}
+	}
+}
+
+func (s *QServer) ImportQuiz() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Quiz *models.Quiz `json:"quiz,omitempty"`
+		}
+
+		req.Body = http.MaxBytesReader(w, req.Body, maxBundleSize)
+		err := req.ParseMultipartForm(10 << 20)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		defer req.MultipartForm.RemoveAll()
+		file, header, err := req.FormFile("bundle")
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		defer file.Close()
+
+		b, written, err := s.readBundle(file, header.Size)
+		if err == nil {
+			var qz *models.Quiz
+			qz, err = s.hub.ImportQuiz(req.Context(), b)
+			if err == nil {
+				s.respond(w, req, Response{Quiz: qz}, http.StatusCreated, nil)
+				return
+			}
+		}
+		for _, f := range written {
+			os.Remove(f)
+		}
+		if errors.Is(err, InvalidBundleError) {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusInternalServerError, err)
+	}
+}
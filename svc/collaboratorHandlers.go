@@ -0,0 +1,148 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
+)
+
+// respondCollaboratorErr maps the errors of collaborator management onto status codes
+func (s *QServer) respondCollaboratorErr(w http.ResponseWriter, req *http.Request, err error) {
+	type Response struct {
+		Err string `json:"err,omitempty"`
+	}
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		s.respond(w, req, Response{Err: err.Error()}, http.StatusNotFound, nil)
+	case errors.Is(err, NotPermittedError):
+		s.respond(w, req, nil, http.StatusForbidden, err)
+	case errors.Is(err, InvalidRoleError):
+		s.respond(w, req, nil, http.StatusBadRequest, err)
+	default:
+		s.respond(w, req, nil, http.StatusInternalServerError, err)
+	}
+}
+
+func (s *QServer) GetCollaborators() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Collaborators []*models.Collaborator `json:"collaborators"`
+		}
+
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		cc, err := s.hub.GetCollaborators(req.Context(), uint(id))
+		if err != nil {
+			s.respondCollaboratorErr(w, req, err)
+			return
+		}
+		s.respond(w, req, Response{Collaborators: cc}, http.StatusOK, nil)
+	}
+}
+
+func (s *QServer) AddCollaborator() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Email string      `json:"email,omitempty"`
+			Role  models.Role `json:"role,omitempty"`
+		}
+
+		r := Request{}
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+
+		defer req.Body.Close()
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		if r.Email == "" {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("You must supply the email of the collaborator"))
+			return
+		}
+		if r.Role == "" {
+			r.Role = models.RoleEditor
+		}
+
+		err = s.hub.AddCollaborator(req.Context(), uint(id), r.Email, r.Role)
+		if err != nil {
+			s.respondCollaboratorErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+func (s *QServer) RemoveCollaborator() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		err = s.hub.RemoveCollaborator(req.Context(), uint(id), params["email"])
+		if err != nil {
+			s.respondCollaboratorErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+func (s *QServer) TransferOwnership() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Email string `json:"email,omitempty"`
+		}
+
+		r := Request{}
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+
+		defer req.Body.Close()
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		if r.Email == "" {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("You must supply the email of the new owner"))
+			return
+		}
+
+		err = s.hub.TransferOwnership(req.Context(), uint(id), r.Email)
+		if err != nil {
+			s.respondCollaboratorErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
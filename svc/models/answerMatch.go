@@ -0,0 +1,318 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Alternatives are answers accepted for a text question besides its Answer, like "Beatles" for
+// "The Beatles" or "Sir Paul McCartney" for "Paul McCartney". They are kept as JSON in a single column.
+type Alternatives []string
+
+func (aa Alternatives) Value() (driver.Value, error) {
+	if aa == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(aa)
+	return string(b), err
+}
+
+func (aa *Alternatives) Scan(src interface{}) error {
+	return scanJSON(src, aa)
+}
+
+// MatchVerdict is how a text answer compares with the answers a question accepts. It only
+// pre-marks the answer, the quizmaster has the last word.
+type MatchVerdict string
+
+const (
+	// MatchCorrect answers are an accepted answer once normalized
+	MatchCorrect MatchVerdict = "correct"
+	// MatchNearMiss answers are a few typos away from an accepted answer
+	MatchNearMiss MatchVerdict = "near_miss"
+	MatchWrong    MatchVerdict = "wrong"
+)
+
+// AnswerMatch is the verdict on a text answer. Accepted is the accepted answer it came closest to
+// and Distance the number of edits between the two after normalizing.
+type AnswerMatch struct {
+	Verdict  MatchVerdict `json:"verdict"`
+	Accepted string       `json:"accepted,omitempty"`
+	Distance int          `json:"distance"`
+}
+
+// maxNearMissDistance caps the typos a near miss may have, however long the answer
+const maxNearMissDistance = 3
+
+// MatchAnswer compares a text answer with the Answer and the Alternatives of q. Answers that
+// normalize to an accepted answer, spaces aside, are correct. Answers within a few edits of one
+// are near misses, one edit for every five characters of the accepted answer. Numbers have to
+// match exactly, 1945 isn't a near miss of 1946. Answers longer than MaxAnswerLength are wrong
+// without being compared.
+func (q *Question) MatchAnswer(text string) AnswerMatch {
+	best := AnswerMatch{Verdict: MatchWrong, Distance: -1}
+	if utf8.RuneCountInString(text) > MaxAnswerLength {
+		return best
+	}
+	given := NormalizeAnswer(text)
+	if given == "" {
+		return best
+	}
+	for _, accepted := range append([]string{q.Answer}, q.Alternatives...) {
+		want := NormalizeAnswer(accepted)
+		if want == "" {
+			continue
+		}
+		if strings.ReplaceAll(given, " ", "") == strings.ReplaceAll(want, " ", "") {
+			return AnswerMatch{Verdict: MatchCorrect, Accepted: accepted}
+		}
+		d := editDistance(given, want)
+		if best.Distance >= 0 && d >= best.Distance {
+			continue
+		}
+		best.Accepted, best.Distance = accepted, d
+		best.Verdict = MatchWrong
+		if !hasDigits(given) && !hasDigits(want) && d <= nearMissThreshold(want) {
+			best.Verdict = MatchNearMiss
+		}
+	}
+	return best
+}
+
+func nearMissThreshold(answer string) int {
+	t := len([]rune(answer)) / 5
+	if t > maxNearMissDistance {
+		return maxNearMissDistance
+	}
+	return t
+}
+
+func hasDigits(s string) bool {
+	return strings.IndexFunc(s, unicode.IsDigit) >= 0
+}
+
+// articles are left out when comparing answers, unless the answer is nothing but articles
+var articles = map[string]bool{"the": true, "a": true, "an": true}
+
+// NormalizeAnswer brings an answer to the form answers are compared in: lower case, diacritics
+// folded, punctuation and articles dropped, number words written as digits and single spaces
+// between words. "The Beatles!" and "beatles" both become "beatles", "Twenty-one" becomes "21".
+func NormalizeAnswer(s string) string {
+	words := strings.Fields(foldAnswer(s))
+	words = numberWords(words)
+	kept := []string{}
+	for _, w := range words {
+		if !articles[w] {
+			kept = append(kept, w)
+		}
+	}
+	if len(kept) == 0 {
+		kept = words
+	}
+	return strings.Join(kept, " ")
+}
+
+// foldAnswer lowercases s, folds its diacritics and turns punctuation into spaces. Apostrophes
+// vanish so "don't" stays one word, separators inside numbers are kept as decimal points or
+// dropped as thousands separators.
+func foldAnswer(s string) string {
+	rs := []rune(strings.ToLower(s))
+	b := strings.Builder{}
+	digitAt := func(i int) bool { return i >= 0 && i < len(rs) && unicode.IsDigit(rs[i]) }
+	for i, r := range rs {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if folded, ok := foldedRunes[r]; ok {
+				b.WriteString(folded)
+			} else {
+				b.WriteRune(r)
+			}
+		case unicode.Is(unicode.Mn, r), r == '\'', r == '’', r == '`':
+		case r == '&':
+			b.WriteString(" and ")
+		case r == '.' && digitAt(i-1) && digitAt(i+1):
+			b.WriteRune(r)
+		case r == ',' && digitAt(i-1) && digitAt(i+1) && digitAt(i+2) && digitAt(i+3) && !digitAt(i+4):
+		default:
+			b.WriteRune(' ')
+		}
+	}
+	return b.String()
+}
+
+// foldedRunes spells letters with diacritics and ligatures in plain latin letters
+var foldedRunes = func() map[rune]string {
+	m := map[rune]string{'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ð': "d", 'þ': "th", 'ł': "l", 'đ': "d", 'ı': "i"}
+	for plain, accented := range map[string]string{
+		"a": "àáâãäåāăą",
+		"c": "çćĉċč",
+		"d": "ď",
+		"e": "èéêëēĕėęě",
+		"g": "ĝğġģ",
+		"h": "ĥħ",
+		"i": "ìíîïĩīĭįİ",
+		"j": "ĵ",
+		"k": "ķ",
+		"l": "ĺļľŀ",
+		"n": "ñńņňŉ",
+		"o": "òóôõöōŏő",
+		"r": "ŕŗř",
+		"s": "śŝşšș",
+		"t": "ţťŧț",
+		"u": "ùúûüũūŭůűų",
+		"w": "ŵ",
+		"y": "ýÿŷ",
+		"z": "źżž",
+	} {
+		for _, r := range accented {
+			m[r] = plain
+		}
+	}
+	return m
+}()
+
+var unitWords = map[string]int{
+	"zero": 0, "one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8,
+	"nine": 9, "ten": 10, "eleven": 11, "twelve": 12, "thirteen": 13, "fourteen": 14, "fifteen": 15,
+	"sixteen": 16, "seventeen": 17, "eighteen": 18, "nineteen": 19, "twenty": 20, "thirty": 30,
+	"forty": 40, "fifty": 50, "sixty": 60, "seventy": 70, "eighty": 80, "ninety": 90,
+}
+
+var scaleWords = map[string]int{"thousand": 1000, "million": 1000000, "billion": 1000000000}
+
+// numberWords replaces runs of spelled out numbers with their digits, "one hundred and five"
+// becomes "105". An "and" only counts as part of a number after hundred or a scale.
+func numberWords(words []string) []string {
+	out := []string{}
+	for i := 0; i < len(words); {
+		n, used := readNumber(words[i:])
+		if used == 0 {
+			out = append(out, words[i])
+			i++
+			continue
+		}
+		out = append(out, strconv.Itoa(n))
+		i += used
+	}
+	return out
+}
+
+// numberPart is the kind of word a spelled out number is made of
+type numberPart int
+
+const (
+	partNone numberPart = iota
+	partZero
+	partUnit // one to nine
+	partTeen // ten to nineteen
+	partTens // twenty, thirty...
+	partHundred
+	partScale
+)
+
+func numberPartOf(w string) numberPart {
+	v, ok := unitWords[w]
+	switch {
+	case ok && v == 0:
+		return partZero
+	case ok && v < 10:
+		return partUnit
+	case ok && v < 20:
+		return partTeen
+	case ok:
+		return partTens
+	case w == "hundred":
+		return partHundred
+	case scaleWords[w] > 0:
+		return partScale
+	}
+	return partNone
+}
+
+// readNumber reads the spelled out number at the start of words, used is 0 when there is none.
+// Words only make up one number the way numbers are said: a unit may follow a tens word, hundred
+// and the scales multiply what comes before them. Anything else starts another number, "seven
+// eleven" is 7 and 11 rather than 18. "a" counts as one before hundred or a scale.
+func readNumber(words []string) (n, used int) {
+	total, current, scale := 0, 0, 0
+	last := partNone
+	for i := 0; i < len(words); i++ {
+		w, part := words[i], numberPartOf(words[i])
+		next := partNone
+		if i+1 < len(words) {
+			next = numberPartOf(words[i+1])
+		}
+		switch {
+		case w == "a" && last == partNone && (next == partHundred || next == partScale):
+			current, part = 1, partUnit
+		case w == "and" && (last == partHundred || last == partScale) && (next == partUnit || next == partTeen || next == partTens):
+			continue
+		case !followsInNumber(last, part, current, scale, scaleWords[w]):
+			return total + current, used
+		case part == partHundred:
+			current *= 100
+		case part == partScale:
+			total += current * scaleWords[w]
+			current, scale = 0, scaleWords[w]
+		default:
+			current += unitWords[w]
+		}
+		last, used = part, i+1
+	}
+	return total + current, used
+}
+
+// followsInNumber reports whether a word of the kind part carries on a number whose last word was
+// of the kind last. current is the part of the number below the last scale, scale the last scale
+// used and wordScale the one of the word.
+func followsInNumber(last, part numberPart, current, scale, wordScale int) bool {
+	switch part {
+	case partZero:
+		return last == partNone
+	case partUnit:
+		return last == partNone || last == partTens || last == partHundred || last == partScale
+	case partTeen, partTens:
+		return last == partNone || last == partHundred || last == partScale
+	case partHundred:
+		return (last == partUnit || last == partTeen) && current < 20
+	case partScale:
+		return last != partNone && last != partZero && last != partScale && (scale == 0 || wordScale < scale)
+	}
+	return false
+}
+
+// editDistance is the Levenshtein distance between a and b in runes
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min3(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
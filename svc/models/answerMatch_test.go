@@ -0,0 +1,82 @@
+package models
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNormalizeAnswer(t *testing.T) {
+	cases := []struct {
+		in, want string
+	}{
+		{"The Beatles!", "beatles"},
+		{"  beatles ", "beatles"},
+		{"Beyoncé", "beyonce"},
+		{"Straße", "strasse"},
+		{"Don't Stop", "dont stop"},
+		{"Simon & Garfunkel", "simon and garfunkel"},
+		{"The The", "the the"},
+		{"3.14", "3.14"},
+		{"1,000,000", "1000000"},
+		{"Twenty-one", "21"},
+		{"one hundred and five", "105"},
+		{"a hundred", "100"},
+		{"a thousand islands", "1000 islands"},
+		{"nineteen hundred eighty four", "1984"},
+		{"two thousand and one", "2001"},
+		{"one million two hundred thousand", "1200000"},
+		{"Seven Eleven", "7 11"},
+		{"nine eleven", "9 11"},
+		{"one two three", "1 2 3"},
+		{"twenty twenty", "20 20"},
+		{"zero one", "0 1"},
+		{"one and two", "1 and 2"},
+		{"hundred years war", "hundred years war"},
+		{"three blind mice", "3 blind mice"},
+	}
+	for _, c := range cases {
+		if got := NormalizeAnswer(c.in); got != c.want {
+			t.Errorf("NormalizeAnswer(%q) = %q, want %q", c.in, got, c.want)
+		}
+	}
+}
+
+func TestMatchAnswer(t *testing.T) {
+	q := NewQuestion(0, "Who sang Hey Jude?", "", "", "The Beatles", 1, 0)
+	q.SetAlternatives([]string{"Paul McCartney", "Fab Four"})
+	year := NewQuestion(0, "When did the war end?", "", "", "1945", 1, 0)
+	cases := []struct {
+		q        *Question
+		in       string
+		verdict  MatchVerdict
+		accepted string
+	}{
+		{q, "beatles", MatchCorrect, "The Beatles"},
+		{q, "the beatles", MatchCorrect, "The Beatles"},
+		{q, "Fab-Four", MatchCorrect, "Fab Four"},
+		{q, "Paul Mc Cartney", MatchCorrect, "Paul McCartney"},
+		{q, "beetles", MatchNearMiss, "The Beatles"},
+		{q, "Paul McCartny", MatchNearMiss, "Paul McCartney"},
+		{q, "rolling stones", MatchWrong, ""},
+		{q, "", MatchWrong, ""},
+		{q, strings.Repeat("beatles ", MaxAnswerLength), MatchWrong, ""},
+		{year, "1945", MatchCorrect, "1945"},
+		{year, "1946", MatchWrong, "1945"},
+	}
+	for _, c := range cases {
+		m := c.q.MatchAnswer(c.in)
+		if m.Verdict != c.verdict || (c.accepted != "" && m.Accepted != c.accepted) {
+			t.Errorf("MatchAnswer(%.20q) = %s of %q, want %s of %q", c.in, m.Verdict, m.Accepted, c.verdict, c.accepted)
+		}
+	}
+}
+
+func TestSubmissionForTeam(t *testing.T) {
+	sub := &Submission{Text: "beetles", Match: MatchNearMiss}
+	if got := sub.ForTeam(); got.Match != "" || got.Text != sub.Text {
+		t.Fatalf("got match %q for the team, want none", got.Match)
+	}
+	if sub.Match != MatchNearMiss {
+		t.Fatal("the verdict was cleared for the quizmaster as well")
+	}
+}
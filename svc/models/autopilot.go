@@ -0,0 +1,111 @@
+package models
+
+import (
+	"math"
+	"time"
+)
+
+// AutopilotStep is what the autopilot is showing of the current question. It waits for the timer
+// of the question, shows the answer, then the scores and goes on to the next question.
+type AutopilotStep string
+
+const (
+	AutopilotQuestion AutopilotStep = "question"
+	AutopilotAnswer   AutopilotStep = "answer"
+	AutopilotScores   AutopilotStep = "scores"
+)
+
+// AutopilotPauses are how long the autopilot lets each step last, in seconds. QuestionSeconds is
+// the timer of questions that have none of their own. Steps of 0 seconds are skipped, apart from
+// the question which has to be timed.
+type AutopilotPauses struct {
+	QuestionSeconds uint `json:"question_seconds"`
+	AnswerSeconds   uint `json:"answer_seconds"`
+	ScoresSeconds   uint `json:"scores_seconds"`
+}
+
+// DefaultAutopilotPauses are the pauses of an autopilot that wasn't told otherwise
+func DefaultAutopilotPauses() AutopilotPauses {
+	return AutopilotPauses{QuestionSeconds: 30, AnswerSeconds: 10, ScoresSeconds: 5}
+}
+
+// AutopilotRunning reports whether the autopilot is in charge of the session right now
+func (s *PlaySession) AutopilotRunning() bool {
+	return s.Autopilot && !s.AutopilotPaused && s.State == StateInProgress
+}
+
+// EngageAutopilot hands the session to the autopilot, which picks up at the step the current
+// question is at
+func (s *PlaySession) EngageAutopilot(p AutopilotPauses, timer uint, now time.Time) {
+	s.Autopilot, s.AutopilotPaused, s.AutopilotRemaining = true, false, 0
+	s.AutopilotPauses = p
+	if s.CurrentAnswer != "" {
+		s.SetAutopilotStep(AutopilotAnswer, now)
+		return
+	}
+	s.AnswersLocked = false
+	if s.QuestionEndsAt == nil {
+		s.StartTimer(s.AutopilotTimer(timer), now)
+	}
+	s.SetAutopilotStep(AutopilotQuestion, now)
+}
+
+// AutopilotTimer is the timer the autopilot plays a question with, given the timer of its own
+func (s *PlaySession) AutopilotTimer(timer uint) uint {
+	if timer > 0 {
+		return timer
+	}
+	return s.AutopilotPauses.QuestionSeconds
+}
+
+// SetAutopilotStep moves the autopilot on to step. The question step lasts as long as the timer of
+// the question, the others as long as their pause.
+func (s *PlaySession) SetAutopilotStep(step AutopilotStep, now time.Time) {
+	s.AutopilotStep, s.AutopilotNextAt = step, nil
+	seconds := uint(0)
+	switch step {
+	case AutopilotQuestion:
+		return
+	case AutopilotAnswer:
+		seconds = s.AutopilotPauses.AnswerSeconds
+	case AutopilotScores:
+		seconds = s.AutopilotPauses.ScoresSeconds
+	}
+	nextAt := now.Add(time.Duration(seconds) * time.Second)
+	s.AutopilotNextAt = &nextAt
+}
+
+// PauseAutopilot freezes the autopilot where it is, the timer of the question included
+func (s *PlaySession) PauseAutopilot(now time.Time) {
+	ends := s.AutopilotNextAt
+	if s.AutopilotStep == AutopilotQuestion {
+		ends = s.QuestionEndsAt
+		s.StopTimer()
+	}
+	s.AutopilotRemaining = 0
+	if ends != nil && ends.After(now) {
+		s.AutopilotRemaining = uint(math.Ceil(ends.Sub(now).Seconds()))
+	}
+	s.AutopilotPaused, s.AutopilotNextAt = true, nil
+}
+
+// ResumeAutopilot picks up the step the autopilot was paused in with the time that was left of it
+func (s *PlaySession) ResumeAutopilot(now time.Time) {
+	left := time.Duration(s.AutopilotRemaining) * time.Second
+	s.AutopilotPaused, s.AutopilotRemaining = false, 0
+	if s.AutopilotStep == AutopilotQuestion {
+		// A question paused with no time left runs out right away
+		endsAt := now.Add(left)
+		s.QuestionEndsAt = &endsAt
+		return
+	}
+	nextAt := now.Add(left)
+	s.AutopilotNextAt = &nextAt
+}
+
+// TakeOver gives the session back to the quizmaster. The timer of the current question keeps
+// running, unless the autopilot was paused.
+func (s *PlaySession) TakeOver() {
+	s.Autopilot, s.AutopilotPaused, s.AutopilotRemaining = false, false, 0
+	s.AutopilotStep, s.AutopilotNextAt = "", nil
+}
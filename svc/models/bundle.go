@@ -0,0 +1,129 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// BundleVersion is the version of the bundle format written by this build
+const BundleVersion = 1
+
+// QuizBundle is the portable form of a quiz used to move it between instances. Questions are in
+// play order. Media links pointing into a bundle archive are relative paths, see svc.writeBundle.
+type QuizBundle struct {
+	Version   int               `json:"version"`
+	Name      string            `json:"name"`
+	Tags      []string          `json:"tags,omitempty"`
+	Rounds    []*BundleRound    `json:"rounds,omitempty"`
+	Questions []*BundleQuestion `json:"questions"`
+}
+
+// BundleRound is a round of a QuizBundle, rounds are in play order and known by their title
+type BundleRound struct {
+	Title            string  `json:"title"`
+	PointsMultiplier float64 `json:"points_multiplier"`
+	TimerSeconds     uint    `json:"timer_seconds,omitempty"`
+}
+
+// BundleQuestion is a question of a QuizBundle. Bundles written before multiple choice questions
+// existed have no type, those are text questions.
+type BundleQuestion struct {
+	Type         QuestionType    `json:"type,omitempty"`
+	Text         string          `json:"text"`
+	Answer       string          `json:"answer"`
+	Alternatives []string        `json:"alternatives,omitempty"`
+	Options      QuestionOptions `json:"options,omitempty"`
+	Numeric      *NumericAnswer  `json:"numeric,omitempty"`
+	Points       uint            `json:"points,omitempty"`
+	TimerSeconds uint            `json:"timer_seconds,omitempty"`
+	ImageLink    string          `json:"image_link,omitempty"`
+	AudioLink    string          `json:"audio_link,omitempty"`
+	Tags         []string        `json:"tags,omitempty"`
+	// Round is the title of the round the question is played in, empty outside of rounds
+	Round string `json:"round,omitempty"`
+}
+
+// Question builds the question bq describes, without its tags
+func (bq *BundleQuestion) Question(quizID uint) *Question {
+	q := NewQuestion(quizID, bq.Text, bq.ImageLink, bq.AudioLink, bq.Answer, bq.Points, bq.TimerSeconds)
+	q.SetAlternatives(bq.Alternatives)
+	switch {
+	case bq.Type == QuestionTypeMultipleChoice:
+		q.SetOptions(bq.Options)
+	case bq.Type == QuestionTypeNumeric && bq.Numeric != nil:
+		q.SetNumeric(*bq.Numeric)
+	case bq.Type != "":
+		q.Type = bq.Type
+	}
+	return q
+}
+
+// NewQuizBundle captures a quiz with its rounds and its questions, given in play order
+func NewQuizBundle(qz *Quiz, qq []*Question) *QuizBundle {
+	b := &QuizBundle{
+		Version:   BundleVersion,
+		Name:      qz.Name,
+		Tags:      tagNames(qz.Tags),
+		Questions: []*BundleQuestion{},
+	}
+	for _, r := range qz.Rounds {
+		b.Rounds = append(b.Rounds, &BundleRound{Title: r.Title, PointsMultiplier: r.PointsMultiplier, TimerSeconds: r.TimerSeconds})
+	}
+	for _, q := range qq {
+		round := ""
+		if r := RoundOf(qz.Rounds, q); r != nil {
+			round = r.Title
+		}
+		b.Questions = append(b.Questions, &BundleQuestion{
+			Type:         q.Type,
+			Text:         q.Text,
+			Answer:       q.Answer,
+			Alternatives: q.Alternatives,
+			Options:      q.Options,
+			Numeric:      q.Numeric,
+			Points:       q.Points,
+			TimerSeconds: q.TimerSeconds,
+			ImageLink:    q.ImageLink,
+			AudioLink:    q.AudioLink,
+			Tags:         tagNames(q.Tags),
+			Round:        round,
+		})
+	}
+	return b
+}
+
+// Round builds the round br describes
+func (br *BundleRound) Round(quizID uint) *Round {
+	return NewRound(quizID, br.Title, br.PointsMultiplier, br.TimerSeconds)
+}
+
+// ValidateRounds checks the rounds of b can be played, that their titles are unique and that
+// questions only refer to rounds of the bundle
+func (b *QuizBundle) ValidateRounds() error {
+	titles := map[string]bool{}
+	for i, br := range b.Rounds {
+		err := br.Round(0).Validate()
+		if err != nil {
+			return fmt.Errorf("round %d: %w", i+1, err)
+		}
+		title := strings.TrimSpace(br.Title)
+		if titles[title] {
+			return fmt.Errorf("%w: there are several rounds called %q", ErrInvalidRound, title)
+		}
+		titles[title] = true
+	}
+	for i, bq := range b.Questions {
+		if bq.Round != "" && !titles[strings.TrimSpace(bq.Round)] {
+			return fmt.Errorf("%w: question %d is in round %q, which the quiz doesn't have", ErrInvalidRound, i+1, bq.Round)
+		}
+	}
+	return nil
+}
+
+func tagNames(tt []*Tag) []string {
+	names := []string{}
+	for _, t := range tt {
+		names = append(names, t.Name)
+	}
+	return names
+}
@@ -6,10 +6,83 @@ import (
 	"gorm.io/gorm"
 )
 
+// BuzzStatus is where a buzz stands with the quizmaster
+type BuzzStatus string
+
+const (
+	BuzzPending  BuzzStatus = "pending"
+	BuzzAccepted BuzzStatus = "accepted"
+	BuzzRejected BuzzStatus = "rejected"
+)
+
+// Buzz is a team buzzing in on a question of a play session. TS is when the server received it,
+// buzzes on a question are lined up by it and the quizmaster accepts or rejects them in that
+// order. Once one is accepted nobody else gets to buzz on the question.
 type Buzz struct {
-	gorm.Model `json:"-"`
-	TS         time.Time `json:"ts,omitempty"`
-	UserID     uint      `json:"user_id,omitempty"`
-	QuestionID uint      `json:"question_id,omitempty"`
-	Question   Question  `json:"question,omitempty"`
+	gorm.Model    `json:"-"`
+	PlaySessionID uint       `gorm:"index" json:"-"`
+	TS            time.Time  `json:"ts,omitempty"`
+	UserID        uint       `json:"user_id,omitempty"`
+	User          *User      `json:"user,omitempty"`
+	TeamID        uint       `json:"team_id,omitempty"`
+	Team          *Team      `json:"team,omitempty"`
+	QuestionID    uint       `json:"question_id,omitempty"`
+	Question      *Question  `json:"question,omitempty"`
+	Status        BuzzStatus `gorm:"not null;default:pending" json:"status"`
+}
+
+func NewBuzz(sessionID, questionID uint, u *User, t *Team, ts time.Time) *Buzz {
+	return &Buzz{
+		PlaySessionID: sessionID,
+		TS:            ts,
+		UserID:        u.ID,
+		TeamID:        t.ID,
+		QuestionID:    questionID,
+		Status:        BuzzPending,
+	}
+}
+
+// BuzzQueue is the line of buzzes on one question, in the order the server received them
+type BuzzQueue []*Buzz
+
+// QueueFor picks the buzzes on a question out of the buzz history of a session, which is ordered
+// by TS already
+func QueueFor(bb []*Buzz, questionID uint) BuzzQueue {
+	q := BuzzQueue{}
+	for _, b := range bb {
+		if b.QuestionID == questionID {
+			q = append(q, b)
+		}
+	}
+	return q
+}
+
+// Locked reports whether the quizmaster accepted a buzz, which closes the buzzers
+func (q BuzzQueue) Locked() bool {
+	for _, b := range q {
+		if b.Status == BuzzAccepted {
+			return true
+		}
+	}
+	return false
+}
+
+// First returns the earliest buzz still waiting for the quizmaster, nil when there is none
+func (q BuzzQueue) First() *Buzz {
+	for _, b := range q {
+		if b.Status == BuzzPending {
+			return b
+		}
+	}
+	return nil
+}
+
+// Of returns the buzz of a team, nil if it hasn't buzzed
+func (q BuzzQueue) Of(teamID uint) *Buzz {
+	for _, b := range q {
+		if b.TeamID == teamID {
+			return b
+		}
+	}
+	return nil
 }
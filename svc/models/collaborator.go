@@ -0,0 +1,37 @@
+package models
+
+// Role is what a collaborator is allowed to do with a quiz
+type Role string
+
+const (
+	// RoleOwner can do everything, including deleting the quiz and managing collaborators
+	RoleOwner Role = "owner"
+	// RoleEditor can change questions and tags
+	RoleEditor Role = "editor"
+	// RoleViewer can see a private quiz and its questions
+	RoleViewer Role = "viewer"
+)
+
+// Valid reports whether r is one of the known roles
+func (r Role) Valid() bool {
+	return r == RoleOwner || r == RoleEditor || r == RoleViewer
+}
+
+func (r Role) CanEdit() bool {
+	return r == RoleOwner || r == RoleEditor
+}
+
+// QuizCollaborator is the join row between a quiz and a user working on it
+type QuizCollaborator struct {
+	QuizID uint `gorm:"primaryKey"`
+	UserID uint `gorm:"primaryKey"`
+	Role   Role `gorm:"not null;default:viewer"`
+}
+
+// Collaborator is a user together with their role on a quiz
+type Collaborator struct {
+	Email     string `json:"email"`
+	Name      string `json:"name,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+	Role      Role   `json:"role"`
+}
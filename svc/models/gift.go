@@ -0,0 +1,373 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// Moodle GIFT is a plain text format for question banks, see
+// https://docs.moodle.org/en/GIFT_format. Questions are separated by blank lines and carry their
+// answers in braces: {=right ~wrong}, {=one =other}, {TRUE}, {#42:2}. Answers with wrong choices
+// become multiple choice questions, numeric answers numeric questions scored within their
+// tolerance, the rest text questions answered by the first correct answer that accept the
+// others as alternatives.
+// $CATEGORY lines become tags of the questions that follow them, one per level of the path.
+
+// giftSpecial are the characters GIFT needs escaped inside text
+const giftSpecial = `~=#{}:`
+
+// ParseGIFT reads a GIFT file into a bundle named name. Questions laqz can't represent, such as
+// essays and matching questions, are skipped with a warning.
+func ParseGIFT(r io.Reader, name string) (b *QuizBundle, warnings []string, err error) {
+	b = &QuizBundle{Version: BundleVersion, Name: name, Questions: []*BundleQuestion{}}
+	category := []string{}
+	block := []string{}
+	n := 0
+	flush := func() {
+		if len(block) == 0 {
+			return
+		}
+		n++
+		q, err := parseGIFTQuestion(strings.Join(block, "\n"))
+		block = block[:0]
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("question %d skipped: %v", n, err))
+			return
+		}
+		if q == nil {
+			return
+		}
+		q.Tags = append([]string{}, category...)
+		b.Questions = append(b.Questions, q)
+	}
+
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1<<20)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		switch {
+		case strings.HasPrefix(line, "//"):
+		case line == "":
+			flush()
+		case strings.HasPrefix(line, "$CATEGORY:"):
+			flush()
+			category = giftCategoryTags(strings.TrimPrefix(line, "$CATEGORY:"))
+		default:
+			block = append(block, line)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return b, warnings, err
+	}
+	flush()
+	return b, warnings, nil
+}
+
+// giftCategoryTags turns a category path like $course$/Science/Physics into tags
+func giftCategoryTags(path string) []string {
+	tags := []string{}
+	for _, level := range strings.Split(path, "/") {
+		level = strings.TrimSpace(level)
+		if level == "" || (strings.HasPrefix(level, "$") && strings.HasSuffix(level, "$")) || level == "top" {
+			continue
+		}
+		tags = append(tags, level)
+	}
+	return tags
+}
+
+// parseGIFTQuestion parses one question, it returns nil for descriptions, which have no answer
+func parseGIFTQuestion(src string) (*BundleQuestion, error) {
+	title := ""
+	if strings.HasPrefix(src, "::") {
+		end := giftIndex(src[2:], "::")
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated title")
+		}
+		title = src[2 : 2+end]
+		src = src[2+end+2:]
+	}
+	src = strings.TrimSpace(src)
+	for _, format := range []string{"[html]", "[moodle]", "[plain]", "[markdown]"} {
+		src = strings.TrimPrefix(src, format)
+	}
+
+	open := giftIndex(src, "{")
+	if open < 0 {
+		return nil, nil
+	}
+	close := giftIndex(src[open:], "}")
+	if close < 0 {
+		return nil, fmt.Errorf("unterminated answer")
+	}
+	close += open
+	text := strings.TrimSpace(src[:open])
+	if after := strings.TrimSpace(src[close+1:]); after != "" {
+		// Missing word format, the answer goes where the braces are
+		text = strings.TrimSpace(text + " _____ " + after)
+	}
+	if text == "" {
+		text = title
+	}
+	q := &BundleQuestion{Type: QuestionTypeText, Text: giftUnescape(text)}
+	err := parseGIFTAnswer(q, src[open+1:close])
+	if err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+func parseGIFTAnswer(q *BundleQuestion, body string) (err error) {
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return fmt.Errorf("essay questions have no answer")
+	}
+	if body[0] == '#' {
+		n, err := parseGIFTNumeric(body[1:])
+		if err != nil {
+			return err
+		}
+		mq := &Question{}
+		mq.SetNumeric(n)
+		q.Type, q.Numeric, q.Answer = mq.Type, mq.Numeric, mq.Answer
+		return nil
+	}
+	switch strings.ToUpper(strings.TrimSpace(giftCut(body, "#"))) {
+	case "T", "TRUE":
+		q.Answer = "True"
+		return nil
+	case "F", "FALSE":
+		q.Answer = "False"
+		return nil
+	}
+
+	options := QuestionOptions{}
+	right, wrong := []string{}, 0
+	for _, choice := range giftChoices(body) {
+		weight, text := giftWeight(strings.TrimSpace(choice[1:]))
+		text = strings.TrimSpace(giftCut(text, "#"))
+		if giftIndex(text, "->") >= 0 {
+			return fmt.Errorf("matching questions are not supported")
+		}
+		text = giftUnescape(text)
+		// Choices worth part of the points are correct too, all of them have to be picked
+		correct := (choice[0] == '=' && (weight == "" || weight == "100")) ||
+			(weight != "" && !strings.HasPrefix(weight, "-") && weight != "0")
+		if correct && choice[0] == '=' {
+			right = append(right, text)
+		}
+		if choice[0] == '~' {
+			wrong++
+		}
+		options = append(options, QuestionOption{Text: text, Correct: correct})
+	}
+	if wrong > 0 {
+		mc := &Question{}
+		mc.SetOptions(options)
+		q.Type, q.Options, q.Answer = mc.Type, mc.Options, mc.Answer
+		if q.Answer == "" {
+			return fmt.Errorf("no correct answer")
+		}
+		return nil
+	}
+	if len(right) == 0 {
+		return fmt.Errorf("no correct answer")
+	}
+	q.Answer, q.Alternatives = right[0], right[1:]
+	return nil
+}
+
+// parseGIFTNumeric reads the first fully correct numeric answer, either value:tolerance or a
+// min..max range
+func parseGIFTNumeric(body string) (n NumericAnswer, err error) {
+	choices := giftChoices(body)
+	if len(choices) == 0 {
+		choices = []string{"=" + body}
+	}
+	for _, choice := range choices {
+		weight, text := giftWeight(strings.TrimSpace(choice[1:]))
+		if choice[0] != '=' || (weight != "" && weight != "100") {
+			continue
+		}
+		text = strings.TrimSpace(giftCut(text, "#"))
+		if text == "" {
+			continue
+		}
+		n.Scoring = ScoringExact
+		if bounds := strings.SplitN(text, "..", 2); len(bounds) == 2 {
+			lo, err1 := strconv.ParseFloat(strings.TrimSpace(bounds[0]), 64)
+			hi, err2 := strconv.ParseFloat(strings.TrimSpace(bounds[1]), 64)
+			if err1 != nil || err2 != nil || hi < lo {
+				return n, fmt.Errorf("bad numeric range %q", text)
+			}
+			n.Target, n.Tolerance = (lo+hi)/2, (hi-lo)/2
+			return n, nil
+		}
+		parts := strings.SplitN(giftUnescape(text), ":", 2)
+		n.Target, err = strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+		if err != nil {
+			return n, fmt.Errorf("bad numeric answer %q", text)
+		}
+		if len(parts) == 2 {
+			n.Tolerance, err = strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+			if err != nil || n.Tolerance < 0 {
+				return n, fmt.Errorf("bad numeric tolerance %q", text)
+			}
+		}
+		return n, nil
+	}
+	return n, fmt.Errorf("no correct numeric answer")
+}
+
+// giftChoices splits an answer body at every unescaped = and ~, keeping the marker
+func giftChoices(body string) []string {
+	choices := []string{}
+	start := -1
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			i++
+		case '=', '~':
+			// "->" inside matching answers is not a marker
+			if body[i] == '=' && i+1 < len(body) && body[i+1] == '>' {
+				continue
+			}
+			if start >= 0 {
+				choices = append(choices, body[start:i])
+			}
+			start = i
+		}
+	}
+	if start >= 0 {
+		choices = append(choices, body[start:])
+	}
+	return choices
+}
+
+// giftWeight splits off a %weight% prefix
+func giftWeight(choice string) (weight, text string) {
+	if !strings.HasPrefix(choice, "%") {
+		return "", choice
+	}
+	end := strings.Index(choice[1:], "%")
+	if end < 0 {
+		return "", choice
+	}
+	return choice[1 : 1+end], choice[end+2:]
+}
+
+// giftIndex is strings.Index ignoring escaped occurrences
+func giftIndex(s, substr string) int {
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' {
+			i++
+			continue
+		}
+		if strings.HasPrefix(s[i:], substr) {
+			return i
+		}
+	}
+	return -1
+}
+
+// giftCut returns s up to the first unescaped sep
+func giftCut(s, sep string) string {
+	if i := giftIndex(s, sep); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
+func giftUnescape(s string) string {
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			i++
+			if s[i] == 'n' {
+				sb.WriteByte('\n')
+				continue
+			}
+		}
+		sb.WriteByte(s[i])
+	}
+	return sb.String()
+}
+
+func giftEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\n':
+			sb.WriteString(`\n`)
+		case r == '\\' || strings.ContainsRune(giftSpecial, r):
+			sb.WriteRune('\\')
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(r)
+		}
+	}
+	return sb.String()
+}
+
+// WriteGIFT writes the questions of b as GIFT short answer or multiple choice questions. Questions
+// are grouped under a $CATEGORY made of their tags, media links are kept as comments.
+func WriteGIFT(w io.Writer, b *QuizBundle) error {
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "// %s\n", strings.ReplaceAll(b.Name, "\n", " "))
+	category := ""
+	for i, q := range b.Questions {
+		fmt.Fprintln(bw)
+		if c := strings.Join(q.Tags, "/"); c != category || (i == 0 && c != "") {
+			category = c
+			fmt.Fprintf(bw, "$CATEGORY: %s\n\n", c)
+		}
+		for _, link := range []string{q.ImageLink, q.AudioLink} {
+			if link != "" {
+				fmt.Fprintf(bw, "// %s\n", link)
+			}
+		}
+		fmt.Fprintf(bw, "::Q%d:: %s {%s}\n", i+1, giftEscape(q.Text), giftAnswer(q))
+	}
+	return bw.Flush()
+}
+
+// giftAnswer writes the braces of a question. Multiple choice questions with several correct
+// options split the points between them, picking a wrong one costs them all.
+func giftAnswer(q *BundleQuestion) string {
+	if q.Type == QuestionTypeNumeric && q.Numeric != nil {
+		// GIFT has no notion of closest or scaled scoring, the tolerance is all it keeps
+		answer := "#" + strconv.FormatFloat(q.Numeric.Target, 'f', -1, 64)
+		if q.Numeric.Tolerance > 0 {
+			answer += ":" + strconv.FormatFloat(q.Numeric.Tolerance, 'f', -1, 64)
+		}
+		return answer
+	}
+	if q.Type != QuestionTypeMultipleChoice {
+		return "=" + giftEscape(q.Answer)
+	}
+	correct := 0
+	for _, o := range q.Options {
+		if o.Correct {
+			correct++
+		}
+	}
+	choices := []string{}
+	for _, o := range q.Options {
+		switch {
+		case correct == 1 && o.Correct:
+			choices = append(choices, "="+giftEscape(o.Text))
+		case correct == 1:
+			choices = append(choices, "~"+giftEscape(o.Text))
+		case o.Correct:
+			weight := strconv.FormatFloat(100/float64(correct), 'f', 5, 64)
+			weight = strings.TrimRight(strings.TrimRight(weight, "0"), ".")
+			choices = append(choices, "~%"+weight+"%"+giftEscape(o.Text))
+		default:
+			choices = append(choices, "~%-100%"+giftEscape(o.Text))
+		}
+	}
+	return strings.Join(choices, " ")
+}
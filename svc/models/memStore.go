@@ -0,0 +1,1652 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ErrDuplicateKey is returned by the in-memory store when a write would break a unique index
+var ErrDuplicateKey = errors.New("duplicate key value violates unique constraint")
+
+// memLink is a row of a many2many join table: the IDs of both sides
+type memLink struct {
+	left  uint
+	right uint
+}
+
+// memData holds the tables of the in-memory store. Rows are stored without their
+// associations, those live in the link tables just like they do in the database.
+type memData struct {
+	seq map[string]uint
+
+	users     map[uint]User
+	quizzes   map[uint]Quiz
+	questions map[uint]Question
+	tags      map[uint]Tag
+	sessions  map[uint]PlaySession
+	teams     map[uint]Team
+	trashed   map[uint]TrashedQuestion
+	subs      map[uint]Submission
+	buzzes    map[uint]Buzz
+	revisions map[uint]QuizRevision
+	rounds    map[uint]Round
+
+	quizCollaborators map[memLink]struct{}     // quiz, user
+	quizRoles         map[memLink]Role         // quiz, user -> role of every quizCollaborators row
+	quizTags          map[memLink]struct{}     // quiz, tag
+	quizQuestions     map[memLink]QuizQuestion // quiz, question -> position and round
+	questionTags      map[memLink]struct{}     // question, tag
+	sessionUsers      map[memLink]struct{}     // session, user
+	sessionTeams      map[memLink]struct{}     // session, team
+	userTeams         map[memLink]struct{}     // user, team
+}
+
+func newMemData() *memData {
+	return &memData{
+		seq:               make(map[string]uint),
+		users:             make(map[uint]User),
+		quizzes:           make(map[uint]Quiz),
+		questions:         make(map[uint]Question),
+		tags:              make(map[uint]Tag),
+		sessions:          make(map[uint]PlaySession),
+		teams:             make(map[uint]Team),
+		trashed:           make(map[uint]TrashedQuestion),
+		subs:              make(map[uint]Submission),
+		buzzes:            make(map[uint]Buzz),
+		revisions:         make(map[uint]QuizRevision),
+		rounds:            make(map[uint]Round),
+		quizCollaborators: make(map[memLink]struct{}),
+		quizRoles:         make(map[memLink]Role),
+		quizTags:          make(map[memLink]struct{}),
+		quizQuestions:     make(map[memLink]QuizQuestion),
+		questionTags:      make(map[memLink]struct{}),
+		sessionUsers:      make(map[memLink]struct{}),
+		sessionTeams:      make(map[memLink]struct{}),
+		userTeams:         make(map[memLink]struct{}),
+	}
+}
+
+// clone copies every table, it is what transactions work on until they commit
+func (d *memData) clone() *memData {
+	c := newMemData()
+	for k, v := range d.seq {
+		c.seq[k] = v
+	}
+	for k, v := range d.users {
+		c.users[k] = v
+	}
+	for k, v := range d.quizzes {
+		c.quizzes[k] = v
+	}
+	for k, v := range d.questions {
+		c.questions[k] = v
+	}
+	for k, v := range d.tags {
+		c.tags[k] = v
+	}
+	for k, v := range d.sessions {
+		c.sessions[k] = v
+	}
+	for k, v := range d.teams {
+		c.teams[k] = v
+	}
+	for k, v := range d.trashed {
+		c.trashed[k] = v
+	}
+	for k, v := range d.subs {
+		c.subs[k] = v
+	}
+	for k, v := range d.buzzes {
+		c.buzzes[k] = v
+	}
+	for k, v := range d.revisions {
+		c.revisions[k] = v
+	}
+	for k, v := range d.rounds {
+		c.rounds[k] = v
+	}
+	copyLinks(c.quizCollaborators, d.quizCollaborators)
+	for k, v := range d.quizRoles {
+		c.quizRoles[k] = v
+	}
+	copyLinks(c.quizTags, d.quizTags)
+	for k, v := range d.quizQuestions {
+		v.RoundID = copyUint(v.RoundID)
+		c.quizQuestions[k] = v
+	}
+	copyLinks(c.questionTags, d.questionTags)
+	copyLinks(c.sessionUsers, d.sessionUsers)
+	copyLinks(c.sessionTeams, d.sessionTeams)
+	copyLinks(c.userTeams, d.userTeams)
+	return c
+}
+
+// copyUint, copyFloat, copyRoundPoints and copyNumeric keep rows from sharing pointers with what callers hold
+func copyUint(u *uint) *uint {
+	if u == nil {
+		return nil
+	}
+	c := *u
+	return &c
+}
+
+func copyFloat(f *float64) *float64 {
+	if f == nil {
+		return nil
+	}
+	c := *f
+	return &c
+}
+
+func copyTime(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	c := *t
+	return &c
+}
+
+func copyRoundPoints(rp RoundPoints) RoundPoints {
+	if rp == nil {
+		return nil
+	}
+	c := RoundPoints{}
+	for k, v := range rp {
+		c[k] = v
+	}
+	return c
+}
+
+func copyNumeric(n *NumericAnswer) *NumericAnswer {
+	if n == nil {
+		return nil
+	}
+	c := *n
+	return &c
+}
+
+// copySnapshot gives a revision its own snapshot, the JSON round trip is what the database does too
+func copySnapshot(s *QuizSnapshot) *QuizSnapshot {
+	if s == nil {
+		return nil
+	}
+	raw, _ := json.Marshal(s)
+	c := &QuizSnapshot{}
+	json.Unmarshal(raw, c)
+	return c
+}
+
+func copyLinks(dst, src map[memLink]struct{}) {
+	for k, v := range src {
+		dst[k] = v
+	}
+}
+
+// QuizMemStore is a QuizStore kept entirely in memory. It is meant for development and tests
+// where running Postgres is overkill. Every read hands out fresh copies, so callers can mutate
+// what they get back without touching the store until they save it.
+type QuizMemStore struct {
+	mu   *sync.RWMutex
+	data *memData
+	// inTx is set on the store handed to WithTx callbacks, which already holds mu
+	inTx bool
+}
+
+func NewQuizMemStore() *QuizMemStore {
+	return &QuizMemStore{mu: &sync.RWMutex{}, data: newMemData()}
+}
+
+func (db *QuizMemStore) lock() {
+	if !db.inTx {
+		db.mu.Lock()
+	}
+}
+
+func (db *QuizMemStore) unlock() {
+	if !db.inTx {
+		db.mu.Unlock()
+	}
+}
+
+func (db *QuizMemStore) rlock() {
+	if !db.inTx {
+		db.mu.RLock()
+	}
+}
+
+func (db *QuizMemStore) runlock() {
+	if !db.inTx {
+		db.mu.RUnlock()
+	}
+}
+
+// WithTx runs fn against a copy of the tables while holding the write lock, the copy replaces
+// the live tables only if fn succeeds. Transactions are serialized, which is plenty for a dev store.
+func (db *QuizMemStore) WithTx(ctx context.Context, fn func(QuizStore) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	db.lock()
+	defer db.unlock()
+	tx := &QuizMemStore{mu: db.mu, data: db.data.clone(), inTx: true}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	db.data = tx.data
+	return nil
+}
+
+func (db *QuizMemStore) CreateUser(u *User) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.saveUser(u, true)
+}
+
+func (db *QuizMemStore) UpdateUser(u *User) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.saveUser(u, false)
+}
+
+func (db *QuizMemStore) GetUserByEmail(email string) (*User, error) {
+	db.rlock()
+	defer db.runlock()
+	for _, id := range sortedIDs(db.data.users) {
+		if db.data.users[id].Email == email {
+			return db.data.user(id), nil
+		}
+	}
+	return &User{}, gorm.ErrRecordNotFound
+}
+
+func (db *QuizMemStore) CreateQuiz(qz *Quiz) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.saveQuiz(qz, true)
+}
+
+func (db *QuizMemStore) GetQuizByName(name string) (*Quiz, error) {
+	db.rlock()
+	defer db.runlock()
+	for _, id := range sortedIDs(db.data.quizzes) {
+		qz := db.data.quizzes[id]
+		if qz.Name == name && !qz.DeletedAt.Valid {
+			qz.ForkedFromID = copyUint(qz.ForkedFromID)
+			return &qz, nil
+		}
+	}
+	return &Quiz{}, gorm.ErrRecordNotFound
+}
+
+func (db *QuizMemStore) QuizNameTaken(name string) (bool, error) {
+	db.rlock()
+	defer db.runlock()
+	for _, qz := range db.data.quizzes {
+		if qz.Name == name {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func (db *QuizMemStore) GetQuiz(id uint) (*Quiz, error) {
+	db.rlock()
+	defer db.runlock()
+	if !db.data.quizExists(id) {
+		return &Quiz{}, gorm.ErrRecordNotFound
+	}
+	qz := db.data.quiz(id)
+	qz.Questions = db.data.quizQuestionList(id)
+	qz.Rounds = db.data.quizRounds(id)
+	return qz, nil
+}
+
+func (db *QuizMemStore) GetPreloadedQuiz(id uint) (*Quiz, error) {
+	return db.GetQuiz(id)
+}
+
+func (db *QuizMemStore) GetAllPublicQuizzes(opts QuizListOptions) (*QuizPage, error) {
+	db.rlock()
+	defer db.runlock()
+	return db.data.listQuizzes(func(qz Quiz) bool { return !qz.Private }, opts)
+}
+
+func (db *QuizMemStore) GetQuizzesByUser(email string, opts QuizListOptions) (*QuizPage, error) {
+	db.rlock()
+	defer db.runlock()
+	uid, ok := db.data.userIDByEmail(email)
+	return db.data.listQuizzes(func(qz Quiz) bool {
+		_, collaborator := db.data.quizCollaborators[memLink{qz.ID, uid}]
+		return ok && collaborator
+	}, opts)
+}
+
+func (db *QuizMemStore) SearchQuizzes(query string, email string, limit int) ([]*Quiz, error) {
+	db.rlock()
+	defer db.runlock()
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	uid, _ := db.data.userIDByEmail(email)
+	candidates := []*Quiz{}
+	for _, id := range sortedIDs(db.data.quizzes) {
+		if !db.data.quizExists(id) {
+			continue
+		}
+		if _, ok := db.data.quizCollaborators[memLink{id, uid}]; db.data.quizzes[id].Private && !ok {
+			continue
+		}
+		qz := db.data.quiz(id)
+		qz.Questions = db.data.quizQuestionList(id)
+		candidates = append(candidates, qz)
+	}
+	return rankQuizzes(query, candidates, limit), nil
+}
+
+func (db *QuizMemStore) UpdateQuiz(qz *Quiz) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.saveQuiz(qz, false)
+}
+
+func (db *QuizMemStore) DeleteQuiz(id uint) error {
+	db.lock()
+	defer db.unlock()
+	if qz, ok := db.data.quizzes[id]; ok && !qz.DeletedAt.Valid {
+		qz.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+		db.data.quizzes[id] = qz
+	}
+	return nil
+}
+
+func (db *QuizMemStore) SetCollaboratorRole(quizID uint, userID uint, role Role) error {
+	db.lock()
+	defer db.unlock()
+	db.data.addCollaborator(quizID, userID)
+	db.data.quizRoles[memLink{quizID, userID}] = role
+	return nil
+}
+
+func (db *QuizMemStore) RemoveCollaborator(quizID uint, userID uint) error {
+	db.lock()
+	defer db.unlock()
+	if _, ok := db.data.quizCollaborators[memLink{quizID, userID}]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(db.data.quizCollaborators, memLink{quizID, userID})
+	delete(db.data.quizRoles, memLink{quizID, userID})
+	return nil
+}
+
+func (db *QuizMemStore) CreateQuestion(q *Question) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.saveQuestion(q, true)
+}
+
+func (db *QuizMemStore) UpdateQuestion(id uint, q *Question) error {
+	db.lock()
+	defer db.unlock()
+	q.ID = id
+	return db.data.saveQuestion(q, false)
+}
+
+func (db *QuizMemStore) DeleteQuestion(id uint, quizID uint) error {
+	db.lock()
+	defer db.unlock()
+	qq, ok := db.data.quizQuestions[memLink{quizID, id}]
+	if !ok {
+		return nil
+	}
+	delete(db.data.quizQuestions, memLink{quizID, id})
+	db.data.seq["trashed_questions"]++
+	tq := TrashedQuestion{ID: db.data.seq["trashed_questions"], QuizID: quizID, QuestionID: id, Position: qq.Position, RoundID: qq.RoundID, TrashedAt: time.Now()}
+	db.data.trashed[tq.ID] = tq
+	return nil
+}
+
+func (db *QuizMemStore) SetQuestionPositions(quizID uint, ids []uint) error {
+	db.lock()
+	defer db.unlock()
+	for i, id := range ids {
+		if qq, ok := db.data.quizQuestions[memLink{quizID, id}]; ok {
+			qq.Position = i + 1
+			db.data.quizQuestions[memLink{quizID, id}] = qq
+		}
+	}
+	return nil
+}
+
+func (db *QuizMemStore) SetQuestionRound(quizID, questionID uint, roundID *uint) error {
+	db.lock()
+	defer db.unlock()
+	qq, ok := db.data.quizQuestions[memLink{quizID, questionID}]
+	if !ok {
+		return gorm.ErrRecordNotFound
+	}
+	qq.RoundID = copyUint(roundID)
+	db.data.quizQuestions[memLink{quizID, questionID}] = qq
+	return nil
+}
+
+func (db *QuizMemStore) GetRounds(quizID uint) ([]*Round, error) {
+	db.rlock()
+	defer db.runlock()
+	return db.data.quizRounds(quizID), nil
+}
+
+func (db *QuizMemStore) GetRound(id uint) (*Round, error) {
+	db.rlock()
+	defer db.runlock()
+	if _, ok := db.data.rounds[id]; !ok {
+		return &Round{}, gorm.ErrRecordNotFound
+	}
+	return db.data.round(id), nil
+}
+
+func (db *QuizMemStore) CreateRound(r *Round) error {
+	db.lock()
+	defer db.unlock()
+	r.Position = 1
+	for _, other := range db.data.rounds {
+		if other.QuizID == r.QuizID && other.Position >= r.Position {
+			r.Position = other.Position + 1
+		}
+	}
+	db.data.seq["rounds"]++
+	r.ID = db.data.seq["rounds"]
+	row := *r
+	row.QuestionIDs = nil
+	db.data.rounds[row.ID] = row
+	return nil
+}
+
+func (db *QuizMemStore) UpdateRound(r *Round) error {
+	db.lock()
+	defer db.unlock()
+	if r.ID == 0 {
+		db.data.seq["rounds"]++
+		r.ID = db.data.seq["rounds"]
+	}
+	row := *r
+	row.QuestionIDs = nil
+	db.data.rounds[row.ID] = row
+	return nil
+}
+
+func (db *QuizMemStore) DeleteRound(id uint) error {
+	db.lock()
+	defer db.unlock()
+	if _, ok := db.data.rounds[id]; !ok {
+		return gorm.ErrRecordNotFound
+	}
+	delete(db.data.rounds, id)
+	for l, qq := range db.data.quizQuestions {
+		if qq.RoundID != nil && *qq.RoundID == id {
+			qq.RoundID = nil
+			db.data.quizQuestions[l] = qq
+		}
+	}
+	for tid, tq := range db.data.trashed {
+		if tq.RoundID != nil && *tq.RoundID == id {
+			tq.RoundID = nil
+			db.data.trashed[tid] = tq
+		}
+	}
+	return nil
+}
+
+func (db *QuizMemStore) SetRoundPositions(quizID uint, ids []uint) error {
+	db.lock()
+	defer db.unlock()
+	for i, id := range ids {
+		if r, ok := db.data.rounds[id]; ok && r.QuizID == quizID {
+			r.Position = i + 1
+			db.data.rounds[id] = r
+		}
+	}
+	return nil
+}
+
+func (db *QuizMemStore) GetQuestion(id uint) (*Question, error) {
+	db.rlock()
+	defer db.runlock()
+	if q, ok := db.data.questions[id]; !ok || q.DeletedAt.Valid {
+		return &Question{}, gorm.ErrRecordNotFound
+	}
+	return db.data.question(id), nil
+}
+
+func (db *QuizMemStore) GetQuestionsByQuiz(qzID uint) ([]*Question, error) {
+	db.rlock()
+	defer db.runlock()
+	if !db.data.quizExists(qzID) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return db.data.quizQuestionList(qzID), nil
+}
+
+func (db *QuizMemStore) GetQuestionBank(userID uint, opts QuestionBankOptions) (*QuestionPage, error) {
+	db.rlock()
+	defer db.runlock()
+	page := &QuestionPage{Questions: make([]*Question, 0)}
+	opts, after, err := opts.normalize()
+	if err != nil {
+		return page, err
+	}
+	terms := searchTerms(opts.Query)
+	qq := []*Question{}
+	ids := sortedIDs(db.data.questions)
+	for i := len(ids) - 1; i >= 0; i-- {
+		row := db.data.questions[ids[i]]
+		if row.UserID != userID || row.DeletedAt.Valid || (after != 0 && row.ID >= after) {
+			continue
+		}
+		if (opts.Type != "" && row.Type != opts.Type) || !bankMatches(terms, &row) {
+			continue
+		}
+		q := db.data.question(row.ID)
+		if !questionHasTags(q, opts.Tags) {
+			continue
+		}
+		q.QuizIDs = []uint{}
+		for _, qzID := range db.data.questionQuizIDs(q.ID) {
+			if db.data.quizExists(qzID) {
+				q.QuizIDs = append(q.QuizIDs, qzID)
+			}
+		}
+		sort.Slice(q.QuizIDs, func(a, b int) bool { return q.QuizIDs[a] < q.QuizIDs[b] })
+		qq = append(qq, q)
+		if len(qq) > opts.Limit {
+			break
+		}
+	}
+	page.NextCursor = nextBankCursor(qq, opts.Limit)
+	if len(qq) > opts.Limit {
+		qq = qq[:opts.Limit]
+	}
+	page.Questions = qq
+	return page, nil
+}
+
+func (db *QuizMemStore) GetQuestionQuizIDs(questionID uint) ([]uint, error) {
+	db.rlock()
+	defer db.runlock()
+	ids := []uint{}
+	for _, id := range db.data.questionQuizIDs(questionID) {
+		if db.data.quizExists(id) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids, nil
+}
+
+func (db *QuizMemStore) GetTagByName(name string) (*Tag, error) {
+	db.rlock()
+	defer db.runlock()
+	for _, id := range sortedIDs(db.data.tags) {
+		if t := db.data.tags[id]; t.Name == name {
+			return &t, nil
+		}
+	}
+	return &Tag{}, gorm.ErrRecordNotFound
+}
+
+func (db *QuizMemStore) GetTagUsage() ([]*TagUsage, error) {
+	db.rlock()
+	defer db.runlock()
+	tus := make([]*TagUsage, 0)
+	for _, id := range sortedIDs(db.data.tags) {
+		tu := &TagUsage{Name: db.data.tags[id].Name}
+		for l := range db.data.quizTags {
+			if l.right == id && db.data.quizExists(l.left) && !db.data.quizzes[l.left].Private {
+				tu.Quizzes++
+			}
+		}
+		for l := range db.data.questionTags {
+			if q, ok := db.data.questions[l.left]; l.right == id && ok && !q.DeletedAt.Valid && db.data.inPublicQuiz(q.ID) {
+				tu.Questions++
+			}
+		}
+		if tu.Quizzes > 0 || tu.Questions > 0 {
+			tus = append(tus, tu)
+		}
+	}
+	sort.SliceStable(tus, func(i, j int) bool {
+		if tus[i].Quizzes != tus[j].Quizzes {
+			return tus[i].Quizzes > tus[j].Quizzes
+		}
+		if tus[i].Questions != tus[j].Questions {
+			return tus[i].Questions > tus[j].Questions
+		}
+		return tus[i].Name < tus[j].Name
+	})
+	return tus, nil
+}
+
+func (db *QuizMemStore) AddQuizTags(quizID uint, tt []*Tag) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.addTags(db.data.quizTags, quizID, tt)
+}
+
+func (db *QuizMemStore) RemoveQuizTags(quizID uint, tt []*Tag) error {
+	db.lock()
+	defer db.unlock()
+	for _, t := range tt {
+		delete(db.data.quizTags, memLink{quizID, t.ID})
+	}
+	return nil
+}
+
+func (db *QuizMemStore) AddQuestionTags(questionID uint, tt []*Tag) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.addTags(db.data.questionTags, questionID, tt)
+}
+
+func (db *QuizMemStore) RemoveQuestionTags(questionID uint, tt []*Tag) error {
+	db.lock()
+	defer db.unlock()
+	for _, t := range tt {
+		delete(db.data.questionTags, memLink{questionID, t.ID})
+	}
+	return nil
+}
+
+func (db *QuizMemStore) CreatePlaySession(s *PlaySession) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.savePlaySession(s, true)
+}
+
+func (db *QuizMemStore) GetPlaySession(code uint) (*PlaySession, error) {
+	db.rlock()
+	defer db.runlock()
+	for _, id := range sortedIDs(db.data.sessions) {
+		s := db.data.sessions[id]
+		if s.Code == code && !s.DeletedAt.Valid {
+			return db.data.playSession(id), nil
+		}
+	}
+	return &PlaySession{}, gorm.ErrRecordNotFound
+}
+
+func (db *QuizMemStore) GetPlaySessionsInProgress() ([]*PlaySession, error) {
+	db.rlock()
+	defer db.runlock()
+	ss := []*PlaySession{}
+	for _, id := range sortedIDs(db.data.sessions) {
+		s := db.data.sessions[id]
+		if s.State == StateInProgress && !s.DeletedAt.Valid {
+			ss = append(ss, db.data.playSession(id))
+		}
+	}
+	return ss, nil
+}
+
+func (db *QuizMemStore) DeletePlaySession(code uint) error {
+	db.lock()
+	defer db.unlock()
+	for id, s := range db.data.sessions {
+		if s.Code == code && !s.DeletedAt.Valid {
+			s.DeletedAt = gorm.DeletedAt{Time: time.Now(), Valid: true}
+			db.data.sessions[id] = s
+		}
+	}
+	return nil
+}
+
+func (db *QuizMemStore) UpdatePlaySession(s *PlaySession) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.savePlaySession(s, false)
+}
+
+func (db *QuizMemStore) UpdateTeam(t *Team) error {
+	db.lock()
+	defer db.unlock()
+	return db.data.saveTeam(t, false)
+}
+
+func (db *QuizMemStore) SaveSubmission(sub *Submission) error {
+	db.lock()
+	defer db.unlock()
+	existing, exists := db.data.subs[sub.ID]
+	if sub.ID == 0 {
+		for _, other := range db.data.subs {
+			if other.PlaySessionID == sub.PlaySessionID && other.QuestionID == sub.QuestionID && other.TeamID == sub.TeamID {
+				existing, exists = other, true
+				sub.ID = other.ID
+			}
+		}
+	}
+	if exists && sub.CreatedAt.IsZero() {
+		sub.CreatedAt = existing.CreatedAt
+	}
+	db.data.stampModel("submissions", &sub.Model, exists)
+	row := *sub
+	row.User, row.Team = nil, nil
+	row.Choices = append(Choices(nil), sub.Choices...)
+	row.Number = copyFloat(sub.Number)
+	db.data.subs[row.ID] = row
+	return nil
+}
+
+func (db *QuizMemStore) GetSubmissions(sessionID, questionID uint) ([]*Submission, error) {
+	db.rlock()
+	defer db.runlock()
+	subs := []*Submission{}
+	for _, id := range sortedIDs(db.data.subs) {
+		sub := db.data.subs[id]
+		if sub.PlaySessionID == sessionID && sub.QuestionID == questionID && !sub.DeletedAt.Valid {
+			sub.Choices = append(Choices(nil), sub.Choices...)
+			sub.Number = copyFloat(sub.Number)
+			sub.User, sub.Team = db.data.userRow(sub.UserID), db.data.teamRow(sub.TeamID)
+			subs = append(subs, &sub)
+		}
+	}
+	return subs, nil
+}
+
+func (db *QuizMemStore) CreateBuzz(b *Buzz) error {
+	db.lock()
+	defer db.unlock()
+	db.data.stampModel("buzzs", &b.Model, false)
+	db.data.buzzes[b.ID] = buzzRow(b)
+	return nil
+}
+
+func (db *QuizMemStore) UpdateBuzz(b *Buzz) error {
+	db.lock()
+	defer db.unlock()
+	_, exists := db.data.buzzes[b.ID]
+	db.data.stampModel("buzzs", &b.Model, exists)
+	db.data.buzzes[b.ID] = buzzRow(b)
+	return nil
+}
+
+// buzzRow is b as it is stored, without its associations
+func buzzRow(b *Buzz) Buzz {
+	row := *b
+	row.User, row.Team, row.Question = nil, nil, nil
+	return row
+}
+
+func (db *QuizMemStore) GetBuzzes(sessionID uint) ([]*Buzz, error) {
+	db.rlock()
+	defer db.runlock()
+	bb := []*Buzz{}
+	for _, id := range sortedIDs(db.data.buzzes) {
+		b := db.data.buzzes[id]
+		if b.PlaySessionID != sessionID || b.DeletedAt.Valid {
+			continue
+		}
+		b.User, b.Team = db.data.userRow(b.UserID), db.data.teamRow(b.TeamID)
+		bb = append(bb, &b)
+	}
+	// Stable, so buzzes received at the same time stay in ID order
+	sort.SliceStable(bb, func(i, j int) bool { return bb[i].TS.Before(bb[j].TS) })
+	return bb, nil
+}
+
+func (db *QuizMemStore) CreateQuizRevision(r *QuizRevision) error {
+	db.lock()
+	defer db.unlock()
+	r.Number = 1
+	for _, other := range db.data.revisions {
+		if other.QuizID == r.QuizID && other.Number >= r.Number {
+			r.Number = other.Number + 1
+		}
+	}
+	db.data.seq["quiz_revisions"]++
+	r.ID = db.data.seq["quiz_revisions"]
+	if r.CreatedAt.IsZero() {
+		r.CreatedAt = time.Now()
+	}
+	row := *r
+	row.User = nil
+	row.Snapshot = copySnapshot(r.Snapshot)
+	db.data.revisions[row.ID] = row
+	return nil
+}
+
+func (db *QuizMemStore) GetQuizRevisions(quizID uint) ([]*QuizRevision, error) {
+	db.rlock()
+	defer db.runlock()
+	rr := []*QuizRevision{}
+	ids := sortedIDs(db.data.revisions)
+	for i := len(ids) - 1; i >= 0; i-- {
+		r := db.data.revisions[ids[i]]
+		if r.QuizID == quizID {
+			r.Snapshot = nil
+			r.User = db.data.userRow(r.UserID)
+			rr = append(rr, &r)
+		}
+	}
+	return rr, nil
+}
+
+func (db *QuizMemStore) GetQuizRevision(quizID, number uint) (*QuizRevision, error) {
+	db.rlock()
+	defer db.runlock()
+	for _, r := range db.data.revisions {
+		if r.QuizID == quizID && r.Number == number {
+			r.Snapshot = copySnapshot(r.Snapshot)
+			r.User = db.data.userRow(r.UserID)
+			return &r, nil
+		}
+	}
+	return &QuizRevision{}, gorm.ErrRecordNotFound
+}
+
+func (db *QuizMemStore) GetTrashedQuizzes(email string) ([]*Quiz, error) {
+	db.rlock()
+	defer db.runlock()
+	qzs := make([]*Quiz, 0)
+	uid, ok := db.data.userIDByEmail(email)
+	if !ok {
+		return qzs, nil
+	}
+	for _, id := range db.data.linkedLeft(db.data.quizCollaborators, uid) {
+		if qz, ok := db.data.quizzes[id]; ok && qz.DeletedAt.Valid && db.data.quizRoles[memLink{id, uid}] == RoleOwner {
+			qzs = append(qzs, db.data.quiz(id))
+		}
+	}
+	sort.SliceStable(qzs, func(i, j int) bool {
+		return qzs[i].DeletedAt.Time.After(qzs[j].DeletedAt.Time)
+	})
+	return qzs, nil
+}
+
+func (db *QuizMemStore) GetTrashedQuiz(id uint) (*Quiz, error) {
+	db.rlock()
+	defer db.runlock()
+	if qz, ok := db.data.quizzes[id]; !ok || !qz.DeletedAt.Valid {
+		return &Quiz{}, gorm.ErrRecordNotFound
+	}
+	return db.data.quiz(id), nil
+}
+
+func (db *QuizMemStore) GetTrashedQuestions(email string) ([]*TrashedQuestion, error) {
+	db.rlock()
+	defer db.runlock()
+	tqs := make([]*TrashedQuestion, 0)
+	uid, ok := db.data.userIDByEmail(email)
+	if !ok {
+		return tqs, nil
+	}
+	for _, tq := range db.data.trashed {
+		if !db.data.quizRoles[memLink{tq.QuizID, uid}].CanEdit() || !db.data.quizExists(tq.QuizID) {
+			continue
+		}
+		if q, ok := db.data.questions[tq.QuestionID]; ok {
+			tq.Question = &q
+		}
+		tq.RoundID = copyUint(tq.RoundID)
+		tq := tq
+		tqs = append(tqs, &tq)
+	}
+	sort.Slice(tqs, func(i, j int) bool {
+		if !tqs[i].TrashedAt.Equal(tqs[j].TrashedAt) {
+			return tqs[i].TrashedAt.After(tqs[j].TrashedAt)
+		}
+		return tqs[i].ID > tqs[j].ID
+	})
+	return tqs, nil
+}
+
+func (db *QuizMemStore) RestoreQuiz(id uint) error {
+	db.lock()
+	defer db.unlock()
+	qz, ok := db.data.quizzes[id]
+	if !ok || !qz.DeletedAt.Valid {
+		return gorm.ErrRecordNotFound
+	}
+	qz.DeletedAt = gorm.DeletedAt{}
+	db.data.quizzes[id] = qz
+	return nil
+}
+
+func (db *QuizMemStore) RestoreQuestion(id uint, quizID uint) error {
+	db.lock()
+	defer db.unlock()
+	var latest *TrashedQuestion
+	for tid, tq := range db.data.trashed {
+		if tq.QuestionID != id || tq.QuizID != quizID {
+			continue
+		}
+		if latest == nil || tq.TrashedAt.After(latest.TrashedAt) || (tq.TrashedAt.Equal(latest.TrashedAt) && tq.ID > latest.ID) {
+			tq := tq
+			latest = &tq
+		}
+		delete(db.data.trashed, tid)
+	}
+	if latest == nil {
+		return gorm.ErrRecordNotFound
+	}
+	if _, ok := db.data.quizQuestions[memLink{quizID, id}]; !ok {
+		db.data.quizQuestions[memLink{quizID, id}] = QuizQuestion{QuizID: quizID, QuestionID: id, Position: latest.Position, RoundID: latest.RoundID}
+	}
+	return nil
+}
+
+func (db *QuizMemStore) PurgeTrash(before time.Time) error {
+	db.lock()
+	defer db.unlock()
+	d := db.data
+	candidates := []uint{}
+	for id, qz := range d.quizzes {
+		if !qz.DeletedAt.Valid || !qz.DeletedAt.Time.Before(before) {
+			continue
+		}
+		for sid, s := range d.sessions {
+			if s.QuizID != id {
+				continue
+			}
+			for _, tid := range d.linkedRight(d.sessionTeams, sid) {
+				for l := range d.userTeams {
+					if l.right == tid {
+						delete(d.userTeams, l)
+					}
+				}
+				delete(d.teams, tid)
+			}
+			for subID, sub := range d.subs {
+				if sub.PlaySessionID == sid {
+					delete(d.subs, subID)
+				}
+			}
+			for bid, b := range d.buzzes {
+				if b.PlaySessionID == sid {
+					delete(d.buzzes, bid)
+				}
+			}
+			deleteLinks(d.sessionUsers, sid)
+			deleteLinks(d.sessionTeams, sid)
+			delete(d.sessions, sid)
+		}
+		for rid, r := range d.revisions {
+			if r.QuizID == id {
+				delete(d.revisions, rid)
+			}
+		}
+		for rid, r := range d.rounds {
+			if r.QuizID == id {
+				delete(d.rounds, rid)
+			}
+		}
+		deleteLinks(d.quizCollaborators, id)
+		for l := range d.quizRoles {
+			if l.left == id {
+				delete(d.quizRoles, l)
+			}
+		}
+		deleteLinks(d.quizTags, id)
+		for l := range d.quizQuestions {
+			if l.left == id {
+				candidates = append(candidates, l.right)
+				delete(d.quizQuestions, l)
+			}
+		}
+		delete(d.quizzes, id)
+		for fid, fork := range d.quizzes {
+			if fork.ForkedFromID != nil && *fork.ForkedFromID == id {
+				fork.ForkedFromID = nil
+				d.quizzes[fid] = fork
+			}
+		}
+	}
+	for tid, tq := range d.trashed {
+		if _, ok := d.quizzes[tq.QuizID]; !ok || tq.TrashedAt.Before(before) {
+			candidates = append(candidates, tq.QuestionID)
+			delete(d.trashed, tid)
+		}
+	}
+	// Questions of purged quizzes or trash entries may now belong nowhere
+	for _, qid := range candidates {
+		if len(d.questionQuizIDs(qid)) > 0 {
+			continue
+		}
+		inTrash := false
+		for _, tq := range d.trashed {
+			inTrash = inTrash || tq.QuestionID == qid
+		}
+		if !inTrash {
+			deleteLinks(d.questionTags, qid)
+			delete(d.questions, qid)
+		}
+	}
+	return nil
+}
+
+// The save functions mirror what gorm does on Create and Save: the record itself is inserted or
+// overwritten, associated records are inserted if they are new and left untouched otherwise,
+// and the join rows are added. Generated IDs and timestamps are written back to the caller's value.
+
+// stampModel assigns an ID and timestamps to a new row, or refreshes UpdatedAt on an existing one
+func (d *memData) stampModel(table string, m *gorm.Model, exists bool) {
+	now := time.Now()
+	if m.ID == 0 {
+		d.seq[table]++
+		m.ID = d.seq[table]
+	} else if m.ID > d.seq[table] {
+		d.seq[table] = m.ID
+	}
+	if !exists && m.CreatedAt.IsZero() {
+		m.CreatedAt = now
+	}
+	m.UpdatedAt = now
+}
+
+// skipSave reports whether a record's row must not be written: associations that already exist are
+// left alone (their own associations are still saved, as gorm does), and Create refuses to overwrite
+// an existing primary key
+func skipSave(id uint, exists, create, association bool) (skip bool, err error) {
+	if id == 0 || !exists {
+		return false, nil
+	}
+	if association {
+		return true, nil
+	}
+	if create {
+		return true, fmt.Errorf("%w: primary key %d", ErrDuplicateKey, id)
+	}
+	return false, nil
+}
+
+func (d *memData) saveUser(u *User, create bool) error {
+	return d.saveUserRecord(u, create, false)
+}
+
+func (d *memData) saveUserRecord(u *User, create, association bool) error {
+	existing, exists := d.users[u.ID]
+	skip, err := skipSave(u.ID, exists, create, association)
+	if err != nil {
+		return err
+	}
+	if !skip {
+		for id, other := range d.users {
+			if id != u.ID && other.Email == u.Email {
+				return fmt.Errorf("%w: users.email", ErrDuplicateKey)
+			}
+		}
+		if exists && u.CreatedAt.IsZero() {
+			u.CreatedAt = existing.CreatedAt
+		}
+		d.stampModel("users", &u.Model, exists)
+		row := *u
+		row.Quizzes = nil
+		row.Teams = nil
+		d.users[row.ID] = row
+	}
+
+	for _, qz := range u.Quizzes {
+		if err := d.saveQuizRecord(qz, false, true); err != nil {
+			return err
+		}
+		d.addCollaborator(qz.ID, u.ID)
+	}
+	for _, t := range u.Teams {
+		if err := d.saveTeamRecord(t, false, true); err != nil {
+			return err
+		}
+		d.userTeams[memLink{u.ID, t.ID}] = struct{}{}
+	}
+	return nil
+}
+
+func (d *memData) saveTag(t *Tag) error {
+	existing, exists := d.tags[t.ID]
+	if t.ID != 0 && exists {
+		return nil
+	}
+	for _, other := range d.tags {
+		if other.Name == t.Name {
+			return fmt.Errorf("%w: tags.name", ErrDuplicateKey)
+		}
+	}
+	if exists && t.CreatedAt.IsZero() {
+		t.CreatedAt = existing.CreatedAt
+	}
+	d.stampModel("tags", &t.Model, exists)
+	d.tags[t.ID] = *t
+	return nil
+}
+
+// addCollaborator links a user to a quiz, new collaborators start out as viewers like the column default
+func (d *memData) addCollaborator(quizID, userID uint) {
+	d.quizCollaborators[memLink{quizID, userID}] = struct{}{}
+	if _, ok := d.quizRoles[memLink{quizID, userID}]; !ok {
+		d.quizRoles[memLink{quizID, userID}] = RoleViewer
+	}
+}
+
+// addTags saves new tags and links all of them to left in links
+func (d *memData) addTags(links map[memLink]struct{}, left uint, tt []*Tag) error {
+	for _, t := range tt {
+		if err := d.saveTag(t); err != nil {
+			return err
+		}
+		links[memLink{left, t.ID}] = struct{}{}
+	}
+	return nil
+}
+
+func (d *memData) saveQuiz(qz *Quiz, create bool) error {
+	return d.saveQuizRecord(qz, create, false)
+}
+
+func (d *memData) saveQuizRecord(qz *Quiz, create, association bool) error {
+	existing, exists := d.quizzes[qz.ID]
+	skip, err := skipSave(qz.ID, exists, create, association)
+	if err != nil {
+		return err
+	}
+	if !skip {
+		for id, other := range d.quizzes {
+			if id != qz.ID && other.Name == qz.Name {
+				return fmt.Errorf("%w: quizzes.name", ErrDuplicateKey)
+			}
+		}
+		if exists && qz.CreatedAt.IsZero() {
+			qz.CreatedAt = existing.CreatedAt
+		}
+		d.stampModel("quizzes", &qz.Model, exists)
+		row := *qz
+		row.ForkedFromID = copyUint(qz.ForkedFromID)
+		row.Forks = 0
+		row.Collaborators = nil
+		row.Tags = nil
+		row.Questions = nil
+		d.quizzes[row.ID] = row
+	}
+
+	for _, u := range qz.Collaborators {
+		if err := d.saveUserRecord(u, false, true); err != nil {
+			return err
+		}
+		d.addCollaborator(qz.ID, u.ID)
+	}
+	for _, t := range qz.Tags {
+		if err := d.saveTag(t); err != nil {
+			return err
+		}
+		d.quizTags[memLink{qz.ID, t.ID}] = struct{}{}
+	}
+	for _, q := range qz.Questions {
+		if err := d.saveQuestionRecord(q, false, true); err != nil {
+			return err
+		}
+		// New join rows start at position 0 outside of any round, like the column defaults
+		if _, ok := d.quizQuestions[memLink{qz.ID, q.ID}]; !ok {
+			d.quizQuestions[memLink{qz.ID, q.ID}] = QuizQuestion{QuizID: qz.ID, QuestionID: q.ID}
+		}
+	}
+	return nil
+}
+
+func (d *memData) saveQuestion(q *Question, create bool) error {
+	return d.saveQuestionRecord(q, create, false)
+}
+
+func (d *memData) saveQuestionRecord(q *Question, create, association bool) error {
+	existing, exists := d.questions[q.ID]
+	skip, err := skipSave(q.ID, exists, create, association)
+	if err != nil {
+		return err
+	}
+	if !skip {
+		if exists && q.CreatedAt.IsZero() {
+			q.CreatedAt = existing.CreatedAt
+		}
+		d.stampModel("questions", &q.Model, exists)
+		row := *q
+		row.Tags = nil
+		row.Options = append(QuestionOptions(nil), q.Options...)
+		row.Alternatives = append(Alternatives(nil), q.Alternatives...)
+		row.Numeric = copyNumeric(q.Numeric)
+		d.questions[row.ID] = row
+	}
+
+	for _, t := range q.Tags {
+		if err := d.saveTag(t); err != nil {
+			return err
+		}
+		d.questionTags[memLink{q.ID, t.ID}] = struct{}{}
+	}
+	return nil
+}
+
+func (d *memData) savePlaySession(s *PlaySession, create bool) error {
+	existing, exists := d.sessions[s.ID]
+	if _, err := skipSave(s.ID, exists, create, false); err != nil {
+		return err
+	}
+	if !create {
+		if !exists || existing.Version != s.Version || existing.DeletedAt.Valid {
+			return &ConflictError{Table: "play_sessions", ID: s.ID, Version: s.Version}
+		}
+		s.Version++
+	}
+	for id, other := range d.sessions {
+		if id != s.ID && other.Code == s.Code {
+			return fmt.Errorf("%w: play_sessions.code", ErrDuplicateKey)
+		}
+	}
+	if s.Quiz != nil {
+		if err := d.saveQuizRecord(s.Quiz, false, true); err != nil {
+			return err
+		}
+		s.QuizID = s.Quiz.ID
+	}
+	if exists && s.CreatedAt.IsZero() {
+		s.CreatedAt = existing.CreatedAt
+	}
+	d.stampModel("play_sessions", &s.Model, exists)
+	row := *s
+	row.Quiz = nil
+	row.CurrentQuestion = nil
+	row.CurrentRound = nil
+	row.CurrentRoundID = copyUint(s.CurrentRoundID)
+	row.QuestionEndsAt = copyTime(s.QuestionEndsAt)
+	row.AutopilotNextAt = copyTime(s.AutopilotNextAt)
+	row.Users = nil
+	row.Teams = nil
+	d.sessions[row.ID] = row
+
+	for _, u := range s.Users {
+		if err := d.saveUserRecord(u, false, true); err != nil {
+			return err
+		}
+		d.sessionUsers[memLink{s.ID, u.ID}] = struct{}{}
+	}
+	for _, t := range s.Teams {
+		if err := d.saveTeamRecord(t, false, true); err != nil {
+			return err
+		}
+		d.sessionTeams[memLink{s.ID, t.ID}] = struct{}{}
+	}
+	return nil
+}
+
+func (d *memData) saveTeam(t *Team, create bool) error {
+	return d.saveTeamRecord(t, create, false)
+}
+
+func (d *memData) saveTeamRecord(t *Team, create, association bool) error {
+	existing, exists := d.teams[t.ID]
+	skip, err := skipSave(t.ID, exists, create, association)
+	if err != nil {
+		return err
+	}
+	if !create && !association {
+		if !exists || existing.Version != t.Version {
+			return &ConflictError{Table: "teams", ID: t.ID, Version: t.Version}
+		}
+		t.Version++
+	}
+	if !skip {
+		if exists && t.CreatedAt.IsZero() {
+			t.CreatedAt = existing.CreatedAt
+		}
+		d.stampModel("teams", &t.Model, exists)
+		row := *t
+		row.Users = nil
+		row.RoundPoints = copyRoundPoints(t.RoundPoints)
+		d.teams[row.ID] = row
+	}
+
+	for _, u := range t.Users {
+		if err := d.saveUserRecord(u, false, true); err != nil {
+			return err
+		}
+		d.userTeams[memLink{u.ID, t.ID}] = struct{}{}
+	}
+	return nil
+}
+
+// The read functions below rebuild records with their associations preloaded one level deep,
+// matching the Preload calls of QuizPGStore.
+
+func (d *memData) quizExists(id uint) bool {
+	qz, ok := d.quizzes[id]
+	return ok && !qz.DeletedAt.Valid
+}
+
+func (d *memData) user(id uint) *User {
+	u := d.users[id]
+	return &u
+}
+
+// userRow is the user of a row as Preload finds it, nil when the user is gone
+func (d *memData) userRow(id uint) *User {
+	if _, ok := d.users[id]; !ok {
+		return nil
+	}
+	return d.user(id)
+}
+
+// quiz returns the quiz with its collaborators and tags
+func (d *memData) quiz(id uint) *Quiz {
+	qz := d.quizzes[id]
+	qz.ForkedFromID = copyUint(qz.ForkedFromID)
+	for _, fork := range d.quizzes {
+		if fork.ForkedFromID != nil && *fork.ForkedFromID == id && !fork.DeletedAt.Valid {
+			qz.Forks++
+		}
+	}
+	qz.Collaborators = []*User{}
+	for _, uid := range d.linkedRight(d.quizCollaborators, id) {
+		qz.Collaborators = append(qz.Collaborators, d.user(uid))
+	}
+	qz.Roles = make(map[uint]Role)
+	for _, u := range qz.Collaborators {
+		qz.Roles[u.ID] = d.quizRoles[memLink{id, u.ID}]
+	}
+	qz.Tags = []*Tag{}
+	for _, tid := range d.linkedRight(d.quizTags, id) {
+		t := d.tags[tid]
+		qz.Tags = append(qz.Tags, &t)
+	}
+	return &qz
+}
+
+// question returns the question with its tags
+func (d *memData) question(id uint) *Question {
+	q := d.questions[id]
+	q.Options = append(QuestionOptions(nil), q.Options...)
+	q.Alternatives = append(Alternatives(nil), q.Alternatives...)
+	q.Numeric = copyNumeric(q.Numeric)
+	q.Tags = []*Tag{}
+	for _, tid := range d.linkedRight(d.questionTags, id) {
+		t := d.tags[tid]
+		q.Tags = append(q.Tags, &t)
+	}
+	return &q
+}
+
+// quizQuestionList returns the questions of a quiz in play order, with the round they are in
+func (d *memData) quizQuestionList(quizID uint) []*Question {
+	qq := []*Question{}
+	for _, qid := range d.quizQuestionIDs(quizID) {
+		if q, ok := d.questions[qid]; !ok || q.DeletedAt.Valid {
+			continue
+		}
+		q := d.question(qid)
+		q.RoundID = copyUint(d.quizQuestions[memLink{quizID, qid}].RoundID)
+		qq = append(qq, q)
+	}
+	return qq
+}
+
+// quizQuestionIDs returns the IDs of a quiz's questions by round position, position, then ID
+func (d *memData) quizQuestionIDs(quizID uint) []uint {
+	ids := []uint{}
+	for l := range d.quizQuestions {
+		if l.left == quizID {
+			ids = append(ids, l.right)
+		}
+	}
+	roundPosition := func(qq QuizQuestion) int {
+		if qq.RoundID == nil {
+			return 0
+		}
+		return d.rounds[*qq.RoundID].Position
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		qi, qj := d.quizQuestions[memLink{quizID, ids[i]}], d.quizQuestions[memLink{quizID, ids[j]}]
+		if ri, rj := roundPosition(qi), roundPosition(qj); ri != rj {
+			return ri < rj
+		}
+		if qi.Position != qj.Position {
+			return qi.Position < qj.Position
+		}
+		return ids[i] < ids[j]
+	})
+	return ids
+}
+
+// quizRounds returns the rounds of a quiz by position, then ID
+func (d *memData) quizRounds(quizID uint) []*Round {
+	rr := []*Round{}
+	for _, id := range sortedIDs(d.rounds) {
+		if d.rounds[id].QuizID == quizID {
+			rr = append(rr, d.round(id))
+		}
+	}
+	sort.SliceStable(rr, func(i, j int) bool { return rr[i].Position < rr[j].Position })
+	return rr
+}
+
+// round returns the round with its live questions in play order
+func (d *memData) round(id uint) *Round {
+	r := d.rounds[id]
+	r.QuestionIDs = []uint{}
+	for _, qid := range d.quizQuestionIDs(r.QuizID) {
+		qq := d.quizQuestions[memLink{r.QuizID, qid}]
+		if q, ok := d.questions[qid]; !ok || q.DeletedAt.Valid || qq.RoundID == nil || *qq.RoundID != id {
+			continue
+		}
+		r.QuestionIDs = append(r.QuestionIDs, qid)
+	}
+	return &r
+}
+
+func (d *memData) team(id uint) *Team {
+	t := d.teams[id]
+	t.RoundPoints = copyRoundPoints(t.RoundPoints)
+	t.Users = []*User{}
+	for _, uid := range d.linkedLeft(d.userTeams, id) {
+		t.Users = append(t.Users, d.user(uid))
+	}
+	return &t
+}
+
+// teamRow is a team without its users as Preload finds it, nil when the team is gone
+func (d *memData) teamRow(id uint) *Team {
+	t, ok := d.teams[id]
+	if !ok {
+		return nil
+	}
+	t.RoundPoints = copyRoundPoints(t.RoundPoints)
+	return &t
+}
+
+func (d *memData) playSession(id uint) *PlaySession {
+	s := d.sessions[id]
+	s.CurrentRoundID = copyUint(s.CurrentRoundID)
+	s.QuestionEndsAt = copyTime(s.QuestionEndsAt)
+	s.AutopilotNextAt = copyTime(s.AutopilotNextAt)
+	if d.quizExists(s.QuizID) {
+		qz := d.quizzes[s.QuizID]
+		qz.ForkedFromID = copyUint(qz.ForkedFromID)
+		s.Quiz = &qz
+	}
+	s.Users = []*User{}
+	for _, uid := range d.linkedRight(d.sessionUsers, id) {
+		s.Users = append(s.Users, d.user(uid))
+	}
+	s.Teams = []*Team{}
+	for _, tid := range d.linkedRight(d.sessionTeams, id) {
+		s.Teams = append(s.Teams, d.team(tid))
+	}
+	return &s
+}
+
+// listQuizzes pages through the live quizzes accepted by include, mirroring QuizPGStore.listQuizzes
+func (d *memData) listQuizzes(include func(qz Quiz) bool, opts QuizListOptions) (*QuizPage, error) {
+	page := &QuizPage{Quizzes: make([]*Quiz, 0)}
+	opts, cursor, err := opts.normalize()
+	if err != nil {
+		return page, err
+	}
+	plays := make(map[uint]int64)
+	for _, s := range d.sessions {
+		plays[s.QuizID]++
+	}
+	rows := []listedQuiz{}
+	for id, qz := range d.quizzes {
+		if !d.quizExists(id) || !include(qz) || !d.hasTags(id, opts.Tags) {
+			continue
+		}
+		row := listedQuiz{ID: id, Name: qz.Name, Plays: plays[id]}
+		if cursor != nil && !cursor.after(row) {
+			continue
+		}
+		rows = append(rows, row)
+	}
+	sortListed(opts.Sort, rows)
+	page.NextCursor = nextCursor(opts.Sort, rows, opts.Limit)
+	if len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+	}
+	for _, r := range rows {
+		page.Quizzes = append(page.Quizzes, d.quiz(r.ID))
+	}
+	return page, nil
+}
+
+// hasTags reports whether a quiz carries every one of the named tags
+func (d *memData) hasTags(quizID uint, names []string) bool {
+	for _, name := range names {
+		found := false
+		for _, tid := range d.linkedRight(d.quizTags, quizID) {
+			found = found || d.tags[tid].Name == name
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// questionHasTags reports whether q carries every tag in names
+func questionHasTags(q *Question, names []string) bool {
+	for _, name := range names {
+		found := false
+		for _, t := range q.Tags {
+			found = found || t.Name == name
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// linkedRight returns the sorted right-hand IDs linked to left in a join table
+func (d *memData) linkedRight(links map[memLink]struct{}, left uint) []uint {
+	ids := []uint{}
+	for l := range links {
+		if l.left == left {
+			ids = append(ids, l.right)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// linkedLeft returns the sorted left-hand IDs linked to right in a join table
+func (d *memData) linkedLeft(links map[memLink]struct{}, right uint) []uint {
+	ids := []uint{}
+	for l := range links {
+		if l.right == right {
+			ids = append(ids, l.left)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+// sortedIDs returns the keys of a table in ascending order so scans are deterministic
+func sortedIDs(table interface{}) []uint {
+	ids := []uint{}
+	switch t := table.(type) {
+	case map[uint]User:
+		for id := range t {
+			ids = append(ids, id)
+		}
+	case map[uint]Quiz:
+		for id := range t {
+			ids = append(ids, id)
+		}
+	case map[uint]Tag:
+		for id := range t {
+			ids = append(ids, id)
+		}
+	case map[uint]Question:
+		for id := range t {
+			ids = append(ids, id)
+		}
+	case map[uint]PlaySession:
+		for id := range t {
+			ids = append(ids, id)
+		}
+	case map[uint]Submission:
+		for id := range t {
+			ids = append(ids, id)
+		}
+	case map[uint]Buzz:
+		for id := range t {
+			ids = append(ids, id)
+		}
+	case map[uint]QuizRevision:
+		for id := range t {
+			ids = append(ids, id)
+		}
+	case map[uint]Round:
+		for id := range t {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func (d *memData) userIDByEmail(email string) (uint, bool) {
+	for id, u := range d.users {
+		if u.Email == email {
+			return id, true
+		}
+	}
+	return 0, false
+}
+
+// questionQuizIDs returns the quizzes a question is attached to
+func (d *memData) questionQuizIDs(questionID uint) []uint {
+	ids := []uint{}
+	for l := range d.quizQuestions {
+		if l.right == questionID {
+			ids = append(ids, l.left)
+		}
+	}
+	return ids
+}
+
+// inPublicQuiz reports whether a question is part of a public quiz that isn't trashed
+func (d *memData) inPublicQuiz(questionID uint) bool {
+	for _, quizID := range d.questionQuizIDs(questionID) {
+		if d.quizExists(quizID) && !d.quizzes[quizID].Private {
+			return true
+		}
+	}
+	return false
+}
+
+// deleteLinks removes every join row whose left side is left
+func deleteLinks(links map[memLink]struct{}, left uint) {
+	for l := range links {
+		if l.left == left {
+			delete(links, l)
+		}
+	}
+}
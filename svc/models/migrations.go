@@ -0,0 +1,919 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Migration is a single numbered schema change. Up applies it and Down reverts it, both run inside
+// a transaction together with the bookkeeping in schema_migrations.
+//
+// Migrations must never depend on the current model structs, as those keep changing. Each one
+// declares snapshots of the tables as they look at that version instead.
+type Migration struct {
+	Version uint
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// SchemaMigration is the record of an applied migration
+type SchemaMigration struct {
+	Version   uint      `gorm:"primaryKey;autoIncrement:false"`
+	Name      string    `gorm:"not null"`
+	AppliedAt time.Time `gorm:"not null"`
+}
+
+// MigrationStatus reports whether a known migration has been applied
+type MigrationStatus struct {
+	Version   uint       `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Migrator is implemented by the stores that keep a versioned schema.
+// steps limits how many migrations are applied or reverted, 0 means all of them.
+type Migrator interface {
+	MigrateUp(steps int) (err error)
+	MigrateDown(steps int) (err error)
+	MigrationStatus() (ss []*MigrationStatus, err error)
+}
+
+// migrations is the ordered list of every schema change. Append only, never edit a released one.
+var migrations = []*Migration{
+	{
+		Version: 1,
+		Name:    "initial schema",
+		// AutoMigrate rather than CreateTable so that databases created before versioned
+		// migrations existed are adopted as they are.
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(v1Tables()...)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(v1Tables()...)
+		},
+	},
+	{
+		Version: 2,
+		Name:    "version columns for optimistic locking",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&v2PlaySession{}, "Version")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().AddColumn(&v2Team{}, "Version")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropColumn(&v2PlaySession{}, "Version")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&v2Team{}, "Version")
+		},
+	},
+	{
+		Version: 3,
+		Name:    "question positions",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&v3QuizQuestion{}, "Position")
+			if err != nil {
+				return err
+			}
+			// Keep the order questions were played in so far, which was by ID
+			return tx.Exec(`update quiz_questions set position = (
+				select count(*) from quiz_questions qq
+				where qq.quiz_id = quiz_questions.quiz_id and qq.question_id <= quiz_questions.question_id)`).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&v3QuizQuestion{}, "Position")
+		},
+	},
+	{
+		Version: 4,
+		Name:    "question trash",
+		Up: func(tx *gorm.DB) error {
+			return tx.Migrator().CreateTable(&v4TrashedQuestion{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v4TrashedQuestion{})
+		},
+	},
+	{
+		Version: 5,
+		Name:    "unique normalized tag names",
+		Up: func(tx *gorm.DB) error {
+			err := v5MergeTags(tx)
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&v5Tag{}, "idx_tags_name")
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropIndex(&v5Tag{}, "idx_tags_name")
+		},
+	},
+	{
+		Version: 6,
+		Name:    "collaborator roles",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&v6QuizCollaborator{}, "Role")
+			if err != nil {
+				return err
+			}
+			// Every collaborator could do everything so far
+			return tx.Exec("update quiz_collaborators set role = 'owner'").Error
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropColumn(&v6QuizCollaborator{}, "Role")
+		},
+	},
+	{
+		Version: 7,
+		Name:    "multiple choice questions",
+		Up: func(tx *gorm.DB) error {
+			for _, col := range []string{"Type", "Options"} {
+				err := tx.Migrator().AddColumn(&v7Question{}, col)
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateTable(&v7Submission{})
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropTable(&v7Submission{})
+			if err != nil {
+				return err
+			}
+			for _, col := range []string{"Type", "Options"} {
+				err = tx.Migrator().DropColumn(&v7Question{}, col)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 8,
+		Name:    "numeric questions",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&v8Question{}, "Numeric")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().AddColumn(&v8Submission{}, "Number")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropColumn(&v8Submission{}, "Number")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&v8Question{}, "Numeric")
+		},
+	},
+	{
+		Version: 9,
+		Name:    "quiz forks",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&v9Quiz{}, "ForkedFromID")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().CreateIndex(&v9Quiz{}, "idx_quizzes_forked_from_id")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropIndex(&v9Quiz{}, "idx_quizzes_forked_from_id")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&v9Quiz{}, "ForkedFromID")
+		},
+	},
+	{
+		Version: 10,
+		Name:    "quiz revisions",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().CreateTable(&v10QuizRevision{})
+			if err != nil {
+				return err
+			}
+			return v10BaselineRevisions(tx)
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&v10QuizRevision{})
+		},
+	},
+	{
+		Version: 11,
+		Name:    "quiz rounds",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().CreateTable(&v11Round{})
+			if err != nil {
+				return err
+			}
+			columns := []struct {
+				table  interface{}
+				column string
+			}{
+				{&v11QuizQuestion{}, "RoundID"},
+				{&v11TrashedQuestion{}, "RoundID"},
+				{&v11Team{}, "RoundPoints"},
+				{&v11PlaySession{}, "CurrentRoundID"},
+			}
+			for _, c := range columns {
+				err = tx.Migrator().AddColumn(c.table, c.column)
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateIndex(&v11QuizQuestion{}, "idx_quiz_questions_round_id")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropIndex(&v11QuizQuestion{}, "idx_quiz_questions_round_id")
+			if err != nil {
+				return err
+			}
+			columns := []struct {
+				table  interface{}
+				column string
+			}{
+				{&v11PlaySession{}, "CurrentRoundID"},
+				{&v11Team{}, "RoundPoints"},
+				{&v11TrashedQuestion{}, "RoundID"},
+				{&v11QuizQuestion{}, "RoundID"},
+			}
+			for _, c := range columns {
+				err = tx.Migrator().DropColumn(c.table, c.column)
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().DropTable(&v11Round{})
+		},
+	},
+	{
+		Version: 12,
+		Name:    "buzzers",
+		Up: func(tx *gorm.DB) error {
+			for _, col := range []string{"PlaySessionID", "TeamID", "Status"} {
+				err := tx.Migrator().AddColumn(&v12Buzz{}, col)
+				if err != nil {
+					return err
+				}
+			}
+			return tx.Migrator().CreateIndex(&v12Buzz{}, "idx_buzzs_play_session_id")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropIndex(&v12Buzz{}, "idx_buzzs_play_session_id")
+			if err != nil {
+				return err
+			}
+			for _, col := range []string{"Status", "TeamID", "PlaySessionID"} {
+				err = tx.Migrator().DropColumn(&v12Buzz{}, col)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+	{
+		Version: 13,
+		Name:    "text answers",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&v13Submission{}, "Text")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().AddColumn(&v13PlaySession{}, "AnswersLocked")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropColumn(&v13PlaySession{}, "AnswersLocked")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&v13Submission{}, "Text")
+		},
+	},
+	{
+		Version: 14,
+		Name:    "alternative answers",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&v14Question{}, "Alternatives")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().AddColumn(&v14Submission{}, "Match")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropColumn(&v14Submission{}, "Match")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&v14Question{}, "Alternatives")
+		},
+	},
+	{
+		Version: 15,
+		Name:    "question timers",
+		Up: func(tx *gorm.DB) error {
+			err := tx.Migrator().AddColumn(&v15PlaySession{}, "QuestionEndsAt")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().AddColumn(&v15PlaySession{}, "AutoReveal")
+		},
+		Down: func(tx *gorm.DB) error {
+			err := tx.Migrator().DropColumn(&v15PlaySession{}, "AutoReveal")
+			if err != nil {
+				return err
+			}
+			return tx.Migrator().DropColumn(&v15PlaySession{}, "QuestionEndsAt")
+		},
+	},
+	{
+		Version: 16,
+		Name:    "autopilot",
+		Up: func(tx *gorm.DB) error {
+			for _, col := range v16AutopilotColumns {
+				err := tx.Migrator().AddColumn(&v16PlaySession{}, col)
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+		Down: func(tx *gorm.DB) error {
+			for i := len(v16AutopilotColumns) - 1; i >= 0; i-- {
+				err := tx.Migrator().DropColumn(&v16PlaySession{}, v16AutopilotColumns[i])
+				if err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	},
+}
+
+// Tables as of version 1
+type v1User struct {
+	gorm.Model
+	Email     string `gorm:"uniqueIndex"`
+	Name      string
+	AvatarURL string
+}
+
+type v1Quiz struct {
+	gorm.Model
+	Name    string `gorm:"uniqueIndex"`
+	Private bool
+}
+
+type v1Question struct {
+	gorm.Model
+	UserID       uint
+	QuizID       uint
+	Points       uint
+	Text         string
+	ImageLink    string
+	AudioLink    string
+	Answer       string
+	TimerSeconds uint
+}
+
+type v1Tag struct {
+	gorm.Model
+	Name string
+}
+
+type v1PlaySession struct {
+	gorm.Model
+	Code                 uint `gorm:"uniqueIndex"`
+	QuizID               uint
+	State                string
+	CurrentQuestionIndex int
+	CurrentAnswer        string
+	QuizMaster           string
+}
+
+type v1Team struct {
+	gorm.Model
+	Name   string
+	Points int
+}
+
+type v1Buzz struct {
+	gorm.Model
+	TS         time.Time
+	UserID     uint
+	QuestionID uint
+}
+
+type v1QuizCollaborator struct {
+	QuizID uint `gorm:"primaryKey"`
+	UserID uint `gorm:"primaryKey"`
+}
+
+type v1QuizTag struct {
+	QuizID uint `gorm:"primaryKey"`
+	TagID  uint `gorm:"primaryKey"`
+}
+
+type v1QuizQuestion struct {
+	QuizID     uint `gorm:"primaryKey"`
+	QuestionID uint `gorm:"primaryKey"`
+}
+
+type v1QuestionTag struct {
+	QuestionID uint `gorm:"primaryKey"`
+	TagID      uint `gorm:"primaryKey"`
+}
+
+type v1SessionUser struct {
+	PlaySessionID uint `gorm:"primaryKey"`
+	UserID        uint `gorm:"primaryKey"`
+}
+
+type v1SessionTeam struct {
+	PlaySessionID uint `gorm:"primaryKey"`
+	TeamID        uint `gorm:"primaryKey"`
+}
+
+type v1UserTeam struct {
+	UserID uint `gorm:"primaryKey"`
+	TeamID uint `gorm:"primaryKey"`
+}
+
+func (v1User) TableName() string             { return "users" }
+func (v1Quiz) TableName() string             { return "quizzes" }
+func (v1Question) TableName() string         { return "questions" }
+func (v1Tag) TableName() string              { return "tags" }
+func (v1PlaySession) TableName() string      { return "play_sessions" }
+func (v1Team) TableName() string             { return "teams" }
+func (v1Buzz) TableName() string             { return "buzzs" }
+func (v1QuizCollaborator) TableName() string { return "quiz_collaborators" }
+func (v1QuizTag) TableName() string          { return "quiz_tags" }
+func (v1QuizQuestion) TableName() string     { return "quiz_questions" }
+func (v1QuestionTag) TableName() string      { return "question_tags" }
+func (v1SessionUser) TableName() string      { return "session_users" }
+func (v1SessionTeam) TableName() string      { return "session_teams" }
+func (v1UserTeam) TableName() string         { return "user_teams" }
+
+func v1Tables() []interface{} {
+	return []interface{}{
+		&v1User{}, &v1Quiz{}, &v1Question{}, &v1Tag{}, &v1PlaySession{}, &v1Team{}, &v1Buzz{},
+		&v1QuizCollaborator{}, &v1QuizTag{}, &v1QuizQuestion{}, &v1QuestionTag{},
+		&v1SessionUser{}, &v1SessionTeam{}, &v1UserTeam{},
+	}
+}
+
+// Columns added in version 2
+type v2PlaySession struct {
+	Version uint `gorm:"not null;default:0"`
+}
+
+type v2Team struct {
+	Version uint `gorm:"not null;default:0"`
+}
+
+func (v2PlaySession) TableName() string { return "play_sessions" }
+func (v2Team) TableName() string        { return "teams" }
+
+// Columns added in version 3
+type v3QuizQuestion struct {
+	Position int `gorm:"not null;default:0"`
+}
+
+func (v3QuizQuestion) TableName() string { return "quiz_questions" }
+
+// Tables added in version 4
+type v4TrashedQuestion struct {
+	ID         uint `gorm:"primaryKey"`
+	QuizID     uint `gorm:"index"`
+	QuestionID uint
+	Position   int
+	TrashedAt  time.Time `gorm:"index"`
+}
+
+func (v4TrashedQuestion) TableName() string { return "trashed_questions" }
+
+// Indexes added in version 5
+type v5Tag struct {
+	ID   uint
+	Name string `gorm:"uniqueIndex:idx_tags_name"`
+}
+
+func (v5Tag) TableName() string { return "tags" }
+
+// Columns added in version 6
+type v6QuizCollaborator struct {
+	Role string `gorm:"not null;default:viewer"`
+}
+
+func (v6QuizCollaborator) TableName() string { return "quiz_collaborators" }
+
+// Columns and tables added in version 7
+type v7Question struct {
+	Type    string `gorm:"not null;default:text"`
+	Options string `gorm:"type:text"`
+}
+
+type v7Submission struct {
+	gorm.Model
+	PlaySessionID uint `gorm:"uniqueIndex:idx_submissions_team"`
+	QuestionID    uint `gorm:"uniqueIndex:idx_submissions_team"`
+	TeamID        uint `gorm:"uniqueIndex:idx_submissions_team"`
+	UserID        uint
+	Choices       string `gorm:"type:text"`
+	Graded        bool
+	Correct       bool
+	Points        int
+}
+
+func (v7Question) TableName() string   { return "questions" }
+func (v7Submission) TableName() string { return "submissions" }
+
+// Columns added in version 8
+type v8Question struct {
+	Numeric string `gorm:"type:text"`
+}
+
+type v8Submission struct {
+	Number *float64
+}
+
+func (v8Question) TableName() string   { return "questions" }
+func (v8Submission) TableName() string { return "submissions" }
+
+// Columns added in version 9
+type v9Quiz struct {
+	ForkedFromID *uint `gorm:"index"`
+}
+
+func (v9Quiz) TableName() string { return "quizzes" }
+
+// Tables added in version 10
+type v10QuizRevision struct {
+	ID        uint `gorm:"primaryKey"`
+	QuizID    uint `gorm:"uniqueIndex:idx_quiz_revisions_number"`
+	Number    uint `gorm:"uniqueIndex:idx_quiz_revisions_number"`
+	UserID    uint
+	Action    string
+	CreatedAt time.Time
+	Snapshot  string `gorm:"type:text"`
+}
+
+func (v10QuizRevision) TableName() string { return "quiz_revisions" }
+
+// v10Snapshot is the snapshot format of version 10, JSON columns are copied over as they are
+type v10Snapshot struct {
+	Tags      []string               `json:"tags"`
+	Questions []*v10SnapshotQuestion `json:"questions"`
+}
+
+type v10SnapshotQuestion struct {
+	ID           uint            `json:"id"`
+	Type         string          `json:"type,omitempty"`
+	Text         string          `json:"text"`
+	Answer       string          `json:"answer"`
+	Options      json.RawMessage `json:"options,omitempty"`
+	Numeric      json.RawMessage `json:"numeric,omitempty"`
+	Points       uint            `json:"points,omitempty"`
+	TimerSeconds uint            `json:"timer_seconds,omitempty"`
+	ImageLink    string          `json:"image_link,omitempty"`
+	AudioLink    string          `json:"audio_link,omitempty"`
+	Tags         []string        `json:"tags,omitempty"`
+}
+
+// v10BaselineRevisions records the content every quiz has before revisions start being kept, so
+// the first change made afterwards can still be rolled back
+func v10BaselineRevisions(tx *gorm.DB) error {
+	quizIDs := []uint{}
+	err := tx.Raw("select id from quizzes order by id").Scan(&quizIDs).Error
+	if err != nil || len(quizIDs) == 0 {
+		return err
+	}
+	names := []struct {
+		OwnerID uint
+		Name    string
+	}{}
+	quizTags := map[uint][]string{}
+	err = tx.Raw("select quiz_tags.quiz_id as owner_id, tags.name from quiz_tags join tags on tags.id = quiz_tags.tag_id order by tags.name").Scan(&names).Error
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		quizTags[n.OwnerID] = append(quizTags[n.OwnerID], n.Name)
+	}
+	names = names[:0]
+	questionTags := map[uint][]string{}
+	err = tx.Raw("select question_tags.question_id as owner_id, tags.name from question_tags join tags on tags.id = question_tags.tag_id order by tags.name").Scan(&names).Error
+	if err != nil {
+		return err
+	}
+	for _, n := range names {
+		questionTags[n.OwnerID] = append(questionTags[n.OwnerID], n.Name)
+	}
+	rows := []struct {
+		QuizID       uint
+		ID           uint
+		Type         string
+		Text         string
+		Answer       string
+		Options      *string
+		Numeric      *string
+		Points       uint
+		TimerSeconds uint
+		ImageLink    string
+		AudioLink    string
+	}{}
+	err = tx.Raw(`select quiz_questions.quiz_id, questions.id, questions.type, questions.text, questions.answer,
+			questions.options, questions.numeric, questions.points, questions.timer_seconds,
+			questions.image_link, questions.audio_link
+		from quiz_questions join questions on questions.id = quiz_questions.question_id
+		where questions.deleted_at is null
+		order by quiz_questions.quiz_id, quiz_questions.position, questions.id`).Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	snapshots := map[uint]*v10Snapshot{}
+	for _, id := range quizIDs {
+		tags := quizTags[id]
+		if tags == nil {
+			tags = []string{}
+		}
+		snapshots[id] = &v10Snapshot{Tags: tags, Questions: []*v10SnapshotQuestion{}}
+	}
+	for _, r := range rows {
+		s, ok := snapshots[r.QuizID]
+		if !ok {
+			continue
+		}
+		q := &v10SnapshotQuestion{
+			ID: r.ID, Type: r.Type, Text: r.Text, Answer: r.Answer, Points: r.Points, TimerSeconds: r.TimerSeconds,
+			ImageLink: r.ImageLink, AudioLink: r.AudioLink, Tags: questionTags[r.ID],
+		}
+		if r.Options != nil && *r.Options != "" {
+			q.Options = json.RawMessage(*r.Options)
+		}
+		if r.Numeric != nil && *r.Numeric != "" {
+			q.Numeric = json.RawMessage(*r.Numeric)
+		}
+		s.Questions = append(s.Questions, q)
+	}
+	now := time.Now()
+	for _, id := range quizIDs {
+		raw, err := json.Marshal(snapshots[id])
+		if err != nil {
+			return err
+		}
+		err = tx.Create(&v10QuizRevision{QuizID: id, Number: 1, Action: "baseline", CreatedAt: now, Snapshot: string(raw)}).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Tables and columns added in version 11
+type v11Round struct {
+	ID               uint `gorm:"primaryKey"`
+	QuizID           uint `gorm:"index"`
+	Title            string
+	Position         int     `gorm:"not null;default:0"`
+	PointsMultiplier float64 `gorm:"not null;default:1"`
+	TimerSeconds     uint
+}
+
+type v11QuizQuestion struct {
+	RoundID *uint `gorm:"index"`
+}
+
+type v11TrashedQuestion struct {
+	RoundID *uint
+}
+
+type v11Team struct {
+	RoundPoints string `gorm:"type:text"`
+}
+
+type v11PlaySession struct {
+	CurrentRoundID *uint
+}
+
+func (v11Round) TableName() string           { return "rounds" }
+func (v11QuizQuestion) TableName() string    { return "quiz_questions" }
+func (v11TrashedQuestion) TableName() string { return "trashed_questions" }
+func (v11Team) TableName() string            { return "teams" }
+func (v11PlaySession) TableName() string     { return "play_sessions" }
+
+// Columns added to buzzes in version 12
+type v12Buzz struct {
+	PlaySessionID uint `gorm:"index"`
+	TeamID        uint
+	Status        string `gorm:"not null;default:pending"`
+}
+
+func (v12Buzz) TableName() string { return "buzzs" }
+
+// Columns added in version 13
+type v13Submission struct {
+	Text string
+}
+
+type v13PlaySession struct {
+	AnswersLocked bool
+}
+
+func (v13Submission) TableName() string  { return "submissions" }
+func (v13PlaySession) TableName() string { return "play_sessions" }
+
+// Columns added in version 14
+type v14Question struct {
+	Alternatives string `gorm:"type:text"`
+}
+
+type v14Submission struct {
+	Match string
+}
+
+func (v14Question) TableName() string   { return "questions" }
+func (v14Submission) TableName() string { return "submissions" }
+
+// Columns added in version 15
+type v15PlaySession struct {
+	QuestionEndsAt *time.Time
+	AutoReveal     bool
+}
+
+func (v15PlaySession) TableName() string { return "play_sessions" }
+
+// Columns added in version 16
+type v16PlaySession struct {
+	Autopilot                bool
+	AutopilotPaused          bool
+	AutopilotStep            string
+	AutopilotNextAt          *time.Time
+	AutopilotRemaining       uint
+	AutopilotQuestionSeconds uint
+	AutopilotAnswerSeconds   uint
+	AutopilotScoresSeconds   uint
+}
+
+var v16AutopilotColumns = []string{
+	"Autopilot", "AutopilotPaused", "AutopilotStep", "AutopilotNextAt", "AutopilotRemaining",
+	"AutopilotQuestionSeconds", "AutopilotAnswerSeconds", "AutopilotScoresSeconds",
+}
+
+func (v16PlaySession) TableName() string { return "play_sessions" }
+
+// v5MergeTags lowercases tag names and collapses their whitespace, tags that end up with the same
+// name are merged into the oldest one. The normalization is frozen here on purpose, synonyms
+// added to NormalizeTagName later only apply to new tags.
+func v5MergeTags(tx *gorm.DB) error {
+	tags := []*v5Tag{}
+	err := tx.Order("id").Find(&tags).Error
+	if err != nil {
+		return err
+	}
+	keep := map[string]uint{}
+	for _, t := range tags {
+		name := strings.Join(strings.Fields(strings.ToLower(t.Name)), " ")
+		keepID, ok := keep[name]
+		if !ok {
+			keep[name] = t.ID
+			if name != t.Name {
+				err = tx.Exec("update tags set name = ? where id = ?", name, t.ID).Error
+			}
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		stmts := []string{
+			"delete from quiz_tags where tag_id = @dup and quiz_id in (select quiz_id from quiz_tags where tag_id = @keep)",
+			"update quiz_tags set tag_id = @keep where tag_id = @dup",
+			"delete from question_tags where tag_id = @dup and question_id in (select question_id from question_tags where tag_id = @keep)",
+			"update question_tags set tag_id = @keep where tag_id = @dup",
+			"delete from tags where id = @dup",
+		}
+		for _, st := range stmts {
+			err = tx.Exec(st, map[string]interface{}{"dup": t.ID, "keep": keepID}).Error
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// Migrate brings the schema up to date
+func (db *QuizPGStore) Migrate() error {
+	return db.MigrateUp(0)
+}
+
+func (db *QuizPGStore) appliedMigrations() (applied map[uint]*SchemaMigration, err error) {
+	err = db.client.AutoMigrate(&SchemaMigration{})
+	if err != nil {
+		return nil, fmt.Errorf("Could not create the schema_migrations table: %w", err)
+	}
+	rows := []*SchemaMigration{}
+	err = db.client.Find(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	applied = make(map[uint]*SchemaMigration)
+	for _, r := range rows {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// MigrateUp applies pending migrations in ascending order
+func (db *QuizPGStore) MigrateUp(steps int) error {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	done := 0
+	for _, m := range migrations {
+		if _, ok := applied[m.Version]; ok {
+			continue
+		}
+		if steps > 0 && done >= steps {
+			break
+		}
+		err = db.client.Transaction(func(tx *gorm.DB) error {
+			if err := m.Up(tx); err != nil {
+				return err
+			}
+			return tx.Create(&SchemaMigration{Version: m.Version, Name: m.Name, AppliedAt: time.Now().UTC()}).Error
+		})
+		if err != nil {
+			return fmt.Errorf("Migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		done++
+	}
+	return nil
+}
+
+// MigrateDown reverts applied migrations, newest first
+func (db *QuizPGStore) MigrateDown(steps int) error {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return err
+	}
+	done := 0
+	for i := len(migrations) - 1; i >= 0; i-- {
+		m := migrations[i]
+		if _, ok := applied[m.Version]; !ok {
+			continue
+		}
+		if steps > 0 && done >= steps {
+			break
+		}
+		err = db.client.Transaction(func(tx *gorm.DB) error {
+			if err := m.Down(tx); err != nil {
+				return err
+			}
+			return tx.Delete(&SchemaMigration{}, m.Version).Error
+		})
+		if err != nil {
+			return fmt.Errorf("Reverting migration %d (%s) failed: %w", m.Version, m.Name, err)
+		}
+		done++
+	}
+	return nil
+}
+
+// MigrationStatus lists every migration known to this build, plus any applied ones it doesn't know
+func (db *QuizPGStore) MigrationStatus() (ss []*MigrationStatus, err error) {
+	applied, err := db.appliedMigrations()
+	if err != nil {
+		return ss, err
+	}
+	ss = []*MigrationStatus{}
+	for _, m := range migrations {
+		st := &MigrationStatus{Version: m.Version, Name: m.Name}
+		if a, ok := applied[m.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = &a.AppliedAt
+			delete(applied, m.Version)
+		}
+		ss = append(ss, st)
+	}
+	for _, a := range applied {
+		appliedAt := a.AppliedAt
+		ss = append(ss, &MigrationStatus{Version: a.Version, Name: a.Name, Applied: true, AppliedAt: &appliedAt})
+	}
+	sort.Slice(ss, func(i, j int) bool {
+		return ss[i].Version < ss[j].Version
+	})
+	return ss, nil
+}
@@ -0,0 +1,118 @@
+package models
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// forEachMigrator runs fn against every store keeping a versioned schema, each time on an empty
+// database. Postgres is skipped unless LAQZ_TEST_POSTGRES is set.
+func forEachMigrator(t *testing.T, fn func(t *testing.T, db *QuizPGStore)) {
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := NewQuizSQLiteStore(filepath.Join(t.TempDir(), "laqz.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, db.QuizPGStore)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv(postgresTestDSN)
+		if dsn == "" {
+			t.Skipf("%s is not set", postgresTestDSN)
+		}
+		db, err := NewQuizPGStore(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.MigrateDown(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, db)
+	})
+}
+
+func appliedVersions(t *testing.T, db Migrator) []uint {
+	t.Helper()
+	ss, err := db.MigrationStatus()
+	if err != nil {
+		t.Fatal(err)
+	}
+	vv := []uint{}
+	for _, st := range ss {
+		if st.Applied {
+			vv = append(vv, st.Version)
+		}
+	}
+	return vv
+}
+
+func TestMigrationsRoundTrip(t *testing.T) {
+	forEachMigrator(t, func(t *testing.T, db *QuizPGStore) {
+		err := db.MigrateUp(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appliedVersions(t, db); len(got) != len(migrations) {
+			t.Fatalf("got %d migrations applied, want %d", len(got), len(migrations))
+		}
+		err = db.MigrateDown(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appliedVersions(t, db); len(got) != 0 {
+			t.Fatalf("got migrations %v still applied after reverting all", got)
+		}
+		err = db.MigrateUp(0)
+		if err != nil {
+			t.Fatalf("migrating up again: %v", err)
+		}
+		u := &User{Email: "ann@example.com"}
+		err = db.CreateUser(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.CreateQuiz(NewQuiz("Capitals", u, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+func TestMigrationsSteps(t *testing.T) {
+	forEachMigrator(t, func(t *testing.T, db *QuizPGStore) {
+		err := db.MigrateUp(2)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appliedVersions(t, db); len(got) != 2 || got[1] != migrations[1].Version {
+			t.Fatalf("got migrations %v applied, want the first two", got)
+		}
+		err = db.MigrateUp(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		u := &User{Email: "ann@example.com"}
+		err = db.CreateUser(u)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Reverting the newest migrations keeps the data of the tables they didn't create
+		err = db.MigrateDown(3)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := appliedVersions(t, db); len(got) != len(migrations)-3 {
+			t.Fatalf("got %d migrations applied, want %d", len(got), len(migrations)-3)
+		}
+		err = db.MigrateUp(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := db.GetUserByEmail(u.Email)
+		if err != nil || got.ID != u.ID {
+			t.Fatalf("got user %d, %v after the round trip; want %d", got.ID, err, u.ID)
+		}
+	})
+}
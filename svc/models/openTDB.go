@@ -0,0 +1,184 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidOpenTDB is returned when a file is not an Open Trivia DB dump
+var ErrInvalidOpenTDB = errors.New("invalid Open Trivia DB dump")
+
+// Open Trivia DB difficulties and the points they are worth. Questions without points are
+// exported as medium.
+var openTDBPoints = map[string]uint{
+	"easy":   1,
+	"medium": 2,
+	"hard":   3,
+}
+
+// OpenTDBDump is the response of the Open Trivia DB api.php, see https://opentdb.com/api_config.php
+type OpenTDBDump struct {
+	ResponseCode int                `json:"response_code"`
+	Results      []*OpenTDBQuestion `json:"results"`
+}
+
+// OpenTDBQuestion is a question of an OpenTDBDump
+type OpenTDBQuestion struct {
+	Category         string   `json:"category"`
+	Type             string   `json:"type"`
+	Difficulty       string   `json:"difficulty"`
+	Question         string   `json:"question"`
+	CorrectAnswer    string   `json:"correct_answer"`
+	IncorrectAnswers []string `json:"incorrect_answers"`
+}
+
+// ParseOpenTDB reads an Open Trivia DB dump into a bundle named name. The texts may use any of
+// the api encodings: HTML entities (the default), url3986 or base64. Categories such as
+// "Entertainment: Video Games" become one tag per part, the difficulty becomes the points.
+// Questions become multiple choice questions, with their options sorted so the position of the
+// correct one gives nothing away. True or false questions keep True first.
+func ParseOpenTDB(r io.Reader, name string) (b *QuizBundle, warnings []string, err error) {
+	dump := &OpenTDBDump{}
+	err = json.NewDecoder(r).Decode(dump)
+	if err != nil {
+		return b, warnings, fmt.Errorf("%w: %v", ErrInvalidOpenTDB, err)
+	}
+	if dump.ResponseCode != 0 {
+		return b, warnings, fmt.Errorf("%w: response code %d", ErrInvalidOpenTDB, dump.ResponseCode)
+	}
+	decode := openTDBDecoder(dump.Results)
+
+	b = &QuizBundle{Version: BundleVersion, Name: name, Questions: []*BundleQuestion{}}
+	for i, oq := range dump.Results {
+		q := &BundleQuestion{Type: QuestionTypeText, Tags: []string{}}
+		var category, difficulty string
+		src := []string{oq.Question, oq.CorrectAnswer, oq.Category, oq.Difficulty}
+		dest := []*string{&q.Text, &q.Answer, &category, &difficulty}
+		incorrect := make([]string, len(oq.IncorrectAnswers))
+		for j := range oq.IncorrectAnswers {
+			src = append(src, oq.IncorrectAnswers[j])
+			dest = append(dest, &incorrect[j])
+		}
+		for j := range src {
+			*dest[j], err = decode(src[j])
+			if err != nil {
+				break
+			}
+		}
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("question %d skipped: %v", i+1, err))
+			err = nil
+			continue
+		}
+		if len(incorrect) > 0 {
+			options := QuestionOptions{{Text: q.Answer, Correct: true}}
+			for _, text := range incorrect {
+				options = append(options, QuestionOption{Text: text})
+			}
+			sort.SliceStable(options, func(a, b int) bool {
+				if oq.Type == "boolean" {
+					return options[a].Text == "True" && options[b].Text != "True"
+				}
+				return options[a].Text < options[b].Text
+			})
+			q.Type, q.Options = QuestionTypeMultipleChoice, options
+		}
+		err = q.Question(0).Validate()
+		if err != nil {
+			warnings = append(warnings, fmt.Sprintf("question %d skipped: %v", i+1, err))
+			err = nil
+			continue
+		}
+		q.Points = openTDBPoints[strings.ToLower(difficulty)]
+		for _, part := range strings.Split(category, ":") {
+			if part = strings.TrimSpace(part); part != "" {
+				q.Tags = append(q.Tags, part)
+			}
+		}
+		b.Questions = append(b.Questions, q)
+	}
+	return b, warnings, nil
+}
+
+// openTDBDecoder picks the decoding of a dump. The api uses one encoding for the whole response
+// and doesn't say which, base64 gives itself away in the difficulty, url3986 in the categories.
+func openTDBDecoder(qq []*OpenTDBQuestion) func(string) (string, error) {
+	base64Encoded := len(qq) > 0
+	urlEncoded := false
+	for _, q := range qq {
+		if _, ok := openTDBPoints[strings.ToLower(q.Difficulty)]; ok {
+			base64Encoded = false
+		}
+		if strings.Contains(q.Category, "%20") || strings.Contains(q.Category, "%3A") {
+			urlEncoded = true
+		}
+	}
+	switch {
+	case base64Encoded:
+		return func(s string) (string, error) {
+			d, err := base64.StdEncoding.DecodeString(s)
+			return string(d), err
+		}
+	case urlEncoded:
+		return url.QueryUnescape
+	default:
+		return func(s string) (string, error) {
+			return html.UnescapeString(s), nil
+		}
+	}
+}
+
+// WriteOpenTDB writes the questions of b as an Open Trivia DB dump with the default HTML
+// encoding. True and False answers make boolean questions, anything else a multiple choice one.
+// Text questions have no incorrect answers and the correct options of questions that have several
+// are written as a single answer. The tags are joined into the category.
+func WriteOpenTDB(w io.Writer, b *QuizBundle) error {
+	dump := &OpenTDBDump{Results: []*OpenTDBQuestion{}}
+	for _, q := range b.Questions {
+		oq := &OpenTDBQuestion{
+			Category:         html.EscapeString(strings.Join(q.Tags, ": ")),
+			Type:             "multiple",
+			Difficulty:       openTDBDifficulty(q.Points),
+			Question:         html.EscapeString(q.Text),
+			CorrectAnswer:    html.EscapeString(q.Answer),
+			IncorrectAnswers: []string{},
+		}
+		for _, o := range q.Options {
+			if !o.Correct && q.Type == QuestionTypeMultipleChoice {
+				oq.IncorrectAnswers = append(oq.IncorrectAnswers, html.EscapeString(o.Text))
+			}
+		}
+		if q.Answer == "True" || q.Answer == "False" {
+			oq.Type = "boolean"
+			oq.IncorrectAnswers = []string{"True"}
+			if q.Answer == "True" {
+				oq.IncorrectAnswers = []string{"False"}
+			}
+		}
+		dump.Results = append(dump.Results, oq)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(dump)
+}
+
+func openTDBDifficulty(points uint) string {
+	switch {
+	case points == 0:
+		return "medium"
+	case points <= openTDBPoints["easy"]:
+		return "easy"
+	case points <= openTDBPoints["medium"]:
+		return "medium"
+	default:
+		return "hard"
+	}
+}
@@ -21,9 +21,28 @@ type PlaySession struct {
 	CurrentQuestionIndex int       `json:"current_question_index"`
 	CurrentQuestion      *Question `gorm:"-" json:"current_question"`
 	CurrentAnswer        string    `json:"current_answer,omitempty"`
+	AnswersLocked        bool      `json:"answers_locked"`
 	QuizMaster           string    `json:"quiz_master"`
 	Users                []*User   `gorm:"many2many:session_users" json:"users"`
 	Teams                []*Team   `gorm:"many2many:session_teams" json:"teams"`
+	// CurrentRoundID is the round of the current question, nil outside of rounds
+	CurrentRoundID *uint  `json:"current_round_id,omitempty"`
+	CurrentRound   *Round `gorm:"-" json:"current_round,omitempty"`
+	// QuestionEndsAt is when the timer of the current question runs out, nil when it has none
+	QuestionEndsAt *time.Time `json:"question_ends_at,omitempty"`
+	// AutoReveal reveals the answer to a question as soon as its timer runs out
+	AutoReveal bool `json:"auto_reveal"`
+	// Autopilot runs the session without the quizmaster, see AutopilotStep
+	Autopilot       bool          `json:"autopilot"`
+	AutopilotPaused bool          `json:"autopilot_paused"`
+	AutopilotStep   AutopilotStep `json:"autopilot_step,omitempty"`
+	// AutopilotNextAt is when the autopilot moves on, nil while the timer of the question runs
+	AutopilotNextAt *time.Time `json:"autopilot_next_at,omitempty"`
+	// AutopilotRemaining is what was left of the step the autopilot was paused in, in seconds
+	AutopilotRemaining uint            `json:"autopilot_remaining,omitempty"`
+	AutopilotPauses    AutopilotPauses `gorm:"embedded;embeddedPrefix:autopilot_" json:"autopilot_pauses"`
+	// Version is bumped on every update, stale updates fail with a ConflictError
+	Version uint `gorm:"not null;default:0" json:"version"`
 }
 
 func NewPlaySession(qm string, q *Quiz) (s *PlaySession) {
@@ -51,6 +70,16 @@ func (s *PlaySession) UpdateQuestion(q *Question) {
 	s.CurrentQuestion = q
 }
 
+// UpdateRound makes r, nil outside of rounds, the round being played
+func (s *PlaySession) UpdateRound(r *Round) {
+	s.CurrentRound = r
+	s.CurrentRoundID = nil
+	if r != nil {
+		id := r.ID
+		s.CurrentRoundID = &id
+	}
+}
+
 func (s *PlaySession) SetCurrentAnswer(answer string) {
 	s.CurrentAnswer = answer
 }
@@ -59,6 +88,29 @@ func (s *PlaySession) ClearCurrentAnswer() {
 	s.CurrentAnswer = ""
 }
 
+// AnswersOpen reports whether teams may still submit or change answers to the current question
+func (s *PlaySession) AnswersOpen() bool {
+	return s.State == StateInProgress && s.CurrentAnswer == "" && !s.AnswersLocked
+}
+
+// StartTimer gives teams seconds from now to answer the current question, 0 means no timer
+func (s *PlaySession) StartTimer(seconds uint, now time.Time) {
+	s.QuestionEndsAt = nil
+	if seconds > 0 {
+		endsAt := now.Add(time.Duration(seconds) * time.Second)
+		s.QuestionEndsAt = &endsAt
+	}
+}
+
+func (s *PlaySession) StopTimer() {
+	s.QuestionEndsAt = nil
+}
+
+// TimeUp reports whether the timer of the current question had run out at t
+func (s *PlaySession) TimeUp(t time.Time) bool {
+	return s.QuestionEndsAt != nil && !t.Before(*s.QuestionEndsAt)
+}
+
 func (s *PlaySession) SetFinished() {
 	s.State = StateFinished
 }
@@ -73,6 +125,31 @@ func (s *PlaySession) GetTeam(name string) (t *Team, err error) {
 
 }
 
+// TeamOf returns the team the user plays in, nil if they haven't joined one
+func (s *PlaySession) TeamOf(email string) *Team {
+	for _, t := range s.Teams {
+		for _, u := range t.Users {
+			if u != nil && u.Email == email {
+				return t
+			}
+		}
+	}
+	return nil
+}
+
+// IsParticipant reports whether the user runs the session or joined it
+func (s *PlaySession) IsParticipant(email string) bool {
+	if email == s.QuizMaster {
+		return true
+	}
+	for _, u := range s.Users {
+		if u != nil && u.Email == email {
+			return true
+		}
+	}
+	return s.TeamOf(email) != nil
+}
+
 func (s *PlaySession) AddTeamPoints(points int, teamName string) (err error) {
 	targetTeamIndex := 0
 	found := false
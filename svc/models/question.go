@@ -1,23 +1,154 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ErrInvalidQuestion is returned for questions that can't be asked
+var ErrInvalidQuestion = errors.New("You must supply atleast some text and an answer")
+
+// ErrInvalidOptions is returned for multiple choice questions whose options can't be played
+var ErrInvalidOptions = errors.New("Multiple choice questions need atleast two options with text and atleast one correct option")
+
+// ErrInvalidSubmission is returned for answers that don't fit the question they answer
+var ErrInvalidSubmission = errors.New("Invalid answer")
+
+// ErrInvalidChoices is returned for picks that don't fit the options of a question
+var ErrInvalidChoices = fmt.Errorf("%w: invalid choice of options", ErrInvalidSubmission)
+
+// QuestionType decides how a question is answered and graded
+type QuestionType string
+
+const (
+	// QuestionTypeText questions take a free text answer the quizmaster judges
+	QuestionTypeText QuestionType = "text"
+	// QuestionTypeMultipleChoice questions are answered by picking options and graded on reveal
+	QuestionTypeMultipleChoice QuestionType = "multiple_choice"
+	// QuestionTypeNumeric questions are answered with a number and scored by distance on reveal
+	QuestionTypeNumeric QuestionType = "numeric"
+)
+
+// ErrInvalidNumeric is returned for numeric questions whose answer can't be scored
+var ErrInvalidNumeric = errors.New("Numeric questions need a finite value, a tolerance that isn't negative and a known scoring mode")
+
+// NumericScoring decides which answers to a numeric question earn points
+type NumericScoring string
+
+const (
+	// ScoringExact awards the points to answers within the tolerance of the value
+	ScoringExact NumericScoring = "exact"
+	// ScoringClosest awards the points to the answers closest to the value, ties all win. With a
+	// tolerance set the closest answers must also be within it.
+	ScoringClosest NumericScoring = "closest"
+	// ScoringScaled awards points falling off linearly with the distance, full points for the
+	// value down to none at the tolerance, which must be set
+	ScoringScaled NumericScoring = "scaled"
+)
+
+// NumericAnswer is the answer of a numeric question, Target is the exact value
+type NumericAnswer struct {
+	Target    float64        `json:"value,omitempty"`
+	Tolerance float64        `json:"tolerance,omitempty"`
+	Scoring   NumericScoring `json:"scoring,omitempty"`
+}
+
+func (n NumericAnswer) Value() (driver.Value, error) {
+	b, err := json.Marshal(n)
+	return string(b), err
+}
+
+func (n *NumericAnswer) Scan(src interface{}) error {
+	return scanJSON(src, n)
+}
+
+// validate checks the answer can be scored, an empty scoring mode means exact
+func (n *NumericAnswer) validate() error {
+	if math.IsNaN(n.Target) || math.IsInf(n.Target, 0) || math.IsNaN(n.Tolerance) || math.IsInf(n.Tolerance, 0) || n.Tolerance < 0 {
+		return ErrInvalidNumeric
+	}
+	switch n.Scoring {
+	case "", ScoringExact, ScoringClosest:
+	case ScoringScaled:
+		if n.Tolerance == 0 {
+			return fmt.Errorf("%w: scaled scoring needs a tolerance", ErrInvalidNumeric)
+		}
+	default:
+		return fmt.Errorf("%w: unknown scoring mode %q", ErrInvalidNumeric, n.Scoring)
+	}
+	return nil
+}
+
+// QuestionOption is one of the options of a multiple choice question
+type QuestionOption struct {
+	Text    string `json:"text"`
+	Correct bool   `json:"correct,omitempty"`
+}
+
+// QuestionOptions are kept as JSON in a single column, they only ever make sense with their question
+type QuestionOptions []QuestionOption
+
+func (oo QuestionOptions) Value() (driver.Value, error) {
+	if oo == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(oo)
+	return string(b), err
+}
+
+func (oo *QuestionOptions) Scan(src interface{}) error {
+	return scanJSON(src, oo)
+}
+
+// scanJSON decodes a JSON column into dst, NULL leaves it empty
+func scanJSON(src interface{}, dst interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case string:
+		return json.Unmarshal([]byte(v), dst)
+	case []byte:
+		return json.Unmarshal(v, dst)
+	default:
+		return fmt.Errorf("Cannot scan %T into %T", src, dst)
+	}
+}
 
 type Question struct {
 	gorm.Model
-	UserID       uint   `json:"-"`
-	QuizID       uint   `json:"-"`
-	Points       uint   `json:"points,omitempty"`
-	Text         string `json:"text,omitempty"`
-	ImageLink    string `json:"image_link,omitempty"`
-	AudioLink    string `json:"audio_link,omitempty"`
-	Answer       string `json:"answer,omitempty"`
-	TimerSeconds uint   `json:"timer_seconds,omitempty"`
-	Tags         []*Tag `gorm:"many2many:question_tags" json:"tags,omitempty"`
+	UserID       uint            `json:"-"`
+	QuizID       uint            `json:"-"`
+	Type         QuestionType    `gorm:"not null;default:text" json:"type,omitempty"`
+	Points       uint            `json:"points,omitempty"`
+	Text         string          `json:"text,omitempty"`
+	ImageLink    string          `json:"image_link,omitempty"`
+	AudioLink    string          `json:"audio_link,omitempty"`
+	Answer       string          `json:"answer,omitempty"`
+	Alternatives Alternatives    `gorm:"type:text" json:"alternatives,omitempty"`
+	Options      QuestionOptions `gorm:"type:text" json:"options,omitempty"`
+	Numeric      *NumericAnswer  `gorm:"type:text" json:"numeric,omitempty"`
+	TimerSeconds uint            `json:"timer_seconds,omitempty"`
+	Tags         []*Tag          `gorm:"many2many:question_tags" json:"tags,omitempty"`
+	// QuizIDs lists the live quizzes the question is part of. Only question banks fill it in, it
+	// is never saved.
+	QuizIDs []uint `gorm:"-" json:"quiz_ids,omitempty"`
+	// RoundID is the round the question is played in, nil outside of rounds. Rounds belong to a
+	// quiz, so only the questions loaded for a quiz have it filled in. It is saved through
+	// QuizStore.SetQuestionRound.
+	RoundID *uint `gorm:"-" json:"round_id,omitempty"`
 }
 
 func NewQuestion(quizID uint, text, imageLink, audioLink, answer string, points, timerSeconds uint) *Question {
 	return &Question{
 		QuizID:       quizID,
+		Type:         QuestionTypeText,
 		Text:         text,
 		ImageLink:    imageLink,
 		AudioLink:    audioLink,
@@ -26,3 +157,181 @@ func NewQuestion(quizID uint, text, imageLink, audioLink, answer string, points,
 		TimerSeconds: timerSeconds,
 	}
 }
+
+// SetOptions makes q a multiple choice question. The Answer becomes the text of the correct
+// options, so everything that shows answers keeps working.
+func (q *Question) SetOptions(oo QuestionOptions) {
+	q.Type = QuestionTypeMultipleChoice
+	q.Options = append(QuestionOptions{}, oo...)
+	correct := []string{}
+	for _, o := range oo {
+		if o.Correct {
+			correct = append(correct, o.Text)
+		}
+	}
+	q.Answer = strings.Join(correct, ", ")
+}
+
+// SetAlternatives replaces the alternative answers of q, leaving out blank ones and repeats
+func (q *Question) SetAlternatives(aa []string) {
+	q.Alternatives = nil
+	seen := map[string]bool{}
+	for _, a := range aa {
+		a = strings.TrimSpace(a)
+		if a == "" || seen[a] {
+			continue
+		}
+		seen[a] = true
+		q.Alternatives = append(q.Alternatives, a)
+	}
+}
+
+// SetNumeric makes q a numeric question, its Answer becomes the value
+func (q *Question) SetNumeric(n NumericAnswer) {
+	q.Type = QuestionTypeNumeric
+	if n.Scoring == "" {
+		n.Scoring = ScoringExact
+	}
+	q.Numeric = &n
+	q.Answer = strconv.FormatFloat(n.Target, 'f', -1, 64)
+}
+
+// IsMultipleChoice reports whether q is answered by picking options
+func (q *Question) IsMultipleChoice() bool {
+	return q.Type == QuestionTypeMultipleChoice
+}
+
+// IsNumeric reports whether q is answered with a number
+func (q *Question) IsNumeric() bool {
+	return q.Type == QuestionTypeNumeric
+}
+
+// IsGraded reports whether answers to q are graded on reveal rather than judged by the quizmaster
+func (q *Question) IsGraded() bool {
+	return q.IsMultipleChoice() || q.IsNumeric()
+}
+
+// Worth is what a correct answer to q earns, questions without points are worth one
+func (q *Question) Worth() int {
+	if q.Points == 0 {
+		return 1
+	}
+	return int(q.Points)
+}
+
+// Validate checks the rules every question has to follow however it is created
+func (q *Question) Validate() error {
+	switch q.Type {
+	case "", QuestionTypeText:
+		if len(q.Options) > 0 {
+			return fmt.Errorf("%w: only multiple choice questions have options", ErrInvalidOptions)
+		}
+		if q.Numeric != nil {
+			return fmt.Errorf("%w: only numeric questions have a numeric answer", ErrInvalidNumeric)
+		}
+	case QuestionTypeNumeric:
+		if q.Numeric == nil {
+			return ErrInvalidNumeric
+		}
+		if err := q.Numeric.validate(); err != nil {
+			return err
+		}
+		if len(q.Options) > 0 {
+			return fmt.Errorf("%w: only multiple choice questions have options", ErrInvalidOptions)
+		}
+	case QuestionTypeMultipleChoice:
+		if len(q.Options) < 2 {
+			return ErrInvalidOptions
+		}
+		correct := 0
+		for _, o := range q.Options {
+			if strings.TrimSpace(o.Text) == "" {
+				return ErrInvalidOptions
+			}
+			if o.Correct {
+				correct++
+			}
+		}
+		if correct == 0 {
+			return ErrInvalidOptions
+		}
+		if q.Numeric != nil {
+			return fmt.Errorf("%w: only numeric questions have a numeric answer", ErrInvalidNumeric)
+		}
+	default:
+		return fmt.Errorf("%w: unknown question type %q", ErrInvalidQuestion, q.Type)
+	}
+	if len(q.Alternatives) > 0 && q.Type != "" && q.Type != QuestionTypeText {
+		return fmt.Errorf("%w: only text questions have alternative answers", ErrInvalidQuestion)
+	}
+	if q.Text == "" || q.Answer == "" {
+		return ErrInvalidQuestion
+	}
+	return nil
+}
+
+// ForPlayers returns a copy of q to show players before the answer is revealed, without the
+// answer or its alternatives and without marking the correct options. The scoring of numeric questions is kept.
+func (q *Question) ForPlayers() *Question {
+	c := *q
+	c.Answer = ""
+	c.Alternatives = nil
+	c.Options = nil
+	c.Numeric = nil
+	if q.Numeric != nil {
+		c.Numeric = &NumericAnswer{Tolerance: q.Numeric.Tolerance, Scoring: q.Numeric.Scoring}
+	}
+	for _, o := range q.Options {
+		c.Options = append(c.Options, QuestionOption{Text: o.Text})
+	}
+	return &c
+}
+
+// correctCount is the number of correct options
+func (q *Question) correctCount() int {
+	n := 0
+	for _, o := range q.Options {
+		if o.Correct {
+			n++
+		}
+	}
+	return n
+}
+
+// CheckChoices validates a pick of options by index. Each option can be picked once, and
+// questions with a single correct option take a single pick.
+func (q *Question) CheckChoices(choices []int) error {
+	if !q.IsMultipleChoice() {
+		return fmt.Errorf("%w: not a multiple choice question", ErrInvalidChoices)
+	}
+	if len(choices) == 0 {
+		return fmt.Errorf("%w: pick atleast one option", ErrInvalidChoices)
+	}
+	if q.correctCount() == 1 && len(choices) > 1 {
+		return fmt.Errorf("%w: pick a single option", ErrInvalidChoices)
+	}
+	seen := map[int]bool{}
+	for _, c := range choices {
+		if c < 0 || c >= len(q.Options) {
+			return fmt.Errorf("%w: there is no option %d", ErrInvalidChoices, c)
+		}
+		if seen[c] {
+			return fmt.Errorf("%w: option %d is picked twice", ErrInvalidChoices, c)
+		}
+		seen[c] = true
+	}
+	return nil
+}
+
+// Grade reports whether exactly the correct options were picked
+func (q *Question) Grade(choices []int) bool {
+	if q.CheckChoices(choices) != nil || len(choices) != q.correctCount() {
+		return false
+	}
+	for _, c := range choices {
+		if !q.Options[c].Correct {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,88 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// QuestionBankOptions selects a page of a user's question bank, newest questions first. Every word
+// of Query has to appear in the text or the answer, questions must carry every tag in Tags and be
+// of Type when it is set. Cursor is the NextCursor of the previous page, empty for the first one.
+type QuestionBankOptions struct {
+	Query  string
+	Tags   []string
+	Type   QuestionType
+	Cursor string
+	Limit  int
+}
+
+// QuestionPage is one page of a question bank. NextCursor is empty on the last page.
+type QuestionPage struct {
+	Questions  []*Question `json:"questions"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+}
+
+// bankCursor is the position after the last question of a page, it is handed out base64 encoded
+type bankCursor struct {
+	ID uint `json:"id"`
+}
+
+// normalize fills in defaults and decodes the cursor, after is 0 on the first page
+func (o QuestionBankOptions) normalize() (opts QuestionBankOptions, after uint, err error) {
+	switch o.Type {
+	case "", QuestionTypeText, QuestionTypeMultipleChoice, QuestionTypeNumeric:
+	default:
+		return o, 0, fmt.Errorf("%w: unknown question type %q", ErrInvalidListOptions, o.Type)
+	}
+	if o.Limit <= 0 {
+		o.Limit = DefaultListLimit
+	}
+	tags := []string{}
+	for _, t := range o.Tags {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	o.Tags = tags
+	if o.Cursor == "" {
+		return o, 0, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(o.Cursor)
+	if err != nil {
+		return o, 0, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+	c := &bankCursor{}
+	err = json.Unmarshal(raw, c)
+	if err != nil || c.ID == 0 {
+		return o, 0, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+	return o, c.ID, nil
+}
+
+// nextBankCursor returns the cursor for the page after qq, when more than limit were found
+func nextBankCursor(qq []*Question, limit int) string {
+	if len(qq) <= limit {
+		return ""
+	}
+	raw, _ := json.Marshal(&bankCursor{ID: qq[limit-1].ID})
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// bankMatches reports whether q contains every search term in its text or answer
+func bankMatches(terms []string, q *Question) bool {
+	text, answer := strings.ToLower(q.Text), strings.ToLower(q.Answer)
+	for _, term := range terms {
+		if !strings.Contains(text, term) && !strings.Contains(answer, term) {
+			return false
+		}
+	}
+	return true
+}
+
+// likePattern escapes term for a LIKE ... ESCAPE '\' match anywhere in a column
+func likePattern(term string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return "%" + r.Replace(term) + "%"
+}
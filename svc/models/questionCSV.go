@@ -0,0 +1,149 @@
+package models
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidCSV is returned when a CSV file can't be read at all, as opposed to single bad rows
+var ErrInvalidCSV = errors.New("invalid CSV")
+
+// csvColumns maps the accepted header names onto the Question field they fill
+var csvColumns = map[string]string{
+	"text":          "text",
+	"question":      "text",
+	"answer":        "answer",
+	"alternatives":  "alternatives",
+	"points":        "points",
+	"timer_seconds": "timer_seconds",
+	"timer":         "timer_seconds",
+	"image_link":    "image_link",
+	"image":         "image_link",
+	"audio_link":    "audio_link",
+	"audio":         "audio_link",
+	"tags":          "tags",
+}
+
+// CSVTagSeparator separates the tags inside the tags column, and the answers inside the
+// alternatives column
+const CSVTagSeparator = ";"
+
+// CSVRow is a parsed CSV row. Row counts the header as row 1, Errors is empty for valid rows.
+type CSVRow struct {
+	Row      int       `json:"row"`
+	Question *Question `json:"-"`
+	Errors   []string  `json:"errors,omitempty"`
+}
+
+// ParseQuestionCSV reads questions from a CSV file whose first row names the columns. Columns
+// may come in any order, text and answer are required. Tags are new Tag values holding only the
+// names, as written in the file. Every row is checked with Question.Validate.
+func ParseQuestionCSV(r io.Reader) (rows []*CSVRow, err error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	header, err := cr.Read()
+	if err != nil {
+		if err == io.EOF {
+			return rows, fmt.Errorf("%w: the file is empty", ErrInvalidCSV)
+		}
+		return rows, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+	}
+	columns := make([]string, len(header))
+	seen := map[string]bool{}
+	for i, h := range header {
+		// Spreadsheets like to start their exports with a byte order mark
+		name := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")))
+		field, ok := csvColumns[name]
+		if !ok {
+			return rows, fmt.Errorf("%w: unknown column %q", ErrInvalidCSV, h)
+		}
+		if seen[field] {
+			return rows, fmt.Errorf("%w: column %q appears twice", ErrInvalidCSV, h)
+		}
+		seen[field] = true
+		columns[i] = field
+	}
+	if !seen["text"] || !seen["answer"] {
+		return rows, fmt.Errorf("%w: the text and answer columns are required", ErrInvalidCSV)
+	}
+
+	rows = []*CSVRow{}
+	for n := 2; ; n++ {
+		record, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rows, fmt.Errorf("%w: %v", ErrInvalidCSV, err)
+		}
+		if isBlankRecord(record) {
+			continue
+		}
+		rows = append(rows, parseCSVRecord(n, columns, record))
+	}
+	return rows, nil
+}
+
+func parseCSVRecord(n int, columns []string, record []string) *CSVRow {
+	row := &CSVRow{Row: n, Question: &Question{}}
+	if len(record) > len(columns) {
+		row.Errors = append(row.Errors, fmt.Sprintf("has %d cells but there are only %d columns", len(record), len(columns)))
+	}
+	q := row.Question
+	for i, field := range columns {
+		if i >= len(record) {
+			break
+		}
+		cell := strings.TrimSpace(record[i])
+		switch field {
+		case "text":
+			q.Text = cell
+		case "answer":
+			q.Answer = cell
+		case "alternatives":
+			q.SetAlternatives(strings.Split(cell, CSVTagSeparator))
+		case "image_link":
+			q.ImageLink = cell
+		case "audio_link":
+			q.AudioLink = cell
+		case "points", "timer_seconds":
+			if cell == "" {
+				continue
+			}
+			v, err := strconv.ParseUint(cell, 10, 32)
+			if err != nil {
+				row.Errors = append(row.Errors, fmt.Sprintf("%s must be a whole number, got %q", field, cell))
+				continue
+			}
+			if field == "points" {
+				q.Points = uint(v)
+			} else {
+				q.TimerSeconds = uint(v)
+			}
+		case "tags":
+			for _, name := range strings.Split(cell, CSVTagSeparator) {
+				if name = strings.TrimSpace(name); name != "" {
+					q.Tags = append(q.Tags, &Tag{Name: name})
+				}
+			}
+		}
+	}
+	if err := q.Validate(); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+	return row
+}
+
+func isBlankRecord(record []string) bool {
+	for _, cell := range record {
+		if strings.TrimSpace(cell) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -9,9 +9,32 @@ type Quiz struct {
 	Collaborators []*User     `gorm:"many2many:quiz_collaborators" json:"collaborators"`
 	Tags          []*Tag      `gorm:"many2many:quiz_tags" json:"tags"`
 	Questions     []*Question `gorm:"many2many:quiz_questions" json:"questions"`
+	// ForkedFromID is the quiz this one was forked from, kept for attribution. It is cleared when
+	// that quiz is purged from the trash.
+	ForkedFromID *uint `gorm:"index" json:"forked_from_id,omitempty"`
+	// Forks counts the quizzes forked from this one that aren't in the trash. Stores fill it in
+	// whenever they load a quiz, it is never saved.
+	Forks int64 `gorm:"-" json:"forks"`
+	// Rounds are the rounds of the quiz in play order. Stores fill them in when they load a single
+	// quiz, they are never saved through the quiz.
+	Rounds []*Round `gorm:"-" json:"rounds,omitempty"`
+	// Roles holds the role of every collaborator by user ID. Stores fill it in whenever they
+	// load Collaborators, it is never saved through the quiz.
+	Roles map[uint]Role `gorm:"-" json:"-"`
 }
 
-// NewQuiz is used to initialize an empty Quiz
+// QuizQuestion is the join row between a quiz and one of its questions.
+// Position decides the order questions are asked in within their round, ties are broken by
+// question ID. RoundID is nil for questions outside of any round.
+type QuizQuestion struct {
+	QuizID     uint  `gorm:"primaryKey"`
+	QuestionID uint  `gorm:"primaryKey"`
+	Position   int   `gorm:"not null;default:0"`
+	RoundID    *uint `gorm:"index"`
+}
+
+// NewQuiz is used to initialize an empty Quiz. The owner's role is only recorded once the
+// quiz has been created, see QuizStore.SetCollaboratorRole.
 func NewQuiz(name string, owner *User, tt []*Tag) *Quiz {
 	return &Quiz{
 		Name:          name,
@@ -30,6 +53,16 @@ func (qz *Quiz) AddQuestion(q *Question) {
 	qz.Questions = append(qz.Questions, q)
 }
 
+// HasQuestion reports whether the question is one of the quiz's, trashed questions aside
+func (qz *Quiz) HasQuestion(id uint) bool {
+	for _, q := range qz.Questions {
+		if q.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 // TogglePrivacy toggle Quiz Privacy
 func (qz *Quiz) TogglePrivacy() {
 	qz.Private = !qz.Private
@@ -42,11 +75,29 @@ func (qz *Quiz) CanView(email string) bool {
 	return qz.IsCollaborator(email)
 }
 
+// IsCollaborator reports whether the user has any role on the quiz, viewers included
 func (qz *Quiz) IsCollaborator(email string) bool {
+	return qz.RoleOf(email) != ""
+}
+
+// CanEdit reports whether the user may change the questions and tags of the quiz
+func (qz *Quiz) CanEdit(email string) bool {
+	return qz.RoleOf(email).CanEdit()
+}
+
+func (qz *Quiz) IsOwner(email string) bool {
+	return qz.RoleOf(email) == RoleOwner
+}
+
+// RoleOf returns the role of the user on the quiz, empty if they aren't a collaborator
+func (qz *Quiz) RoleOf(email string) Role {
+	if email == "" {
+		return ""
+	}
 	for i := range qz.Collaborators {
 		if qz.Collaborators[i].Email == email {
-			return true
+			return qz.Roles[qz.Collaborators[i].ID]
 		}
 	}
-	return false
+	return ""
 }
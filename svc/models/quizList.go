@@ -0,0 +1,140 @@
+package models
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidListOptions is returned for an unknown sort order or a cursor that can't be used
+var ErrInvalidListOptions = errors.New("invalid list options")
+
+// QuizSort is the order quiz listings are returned in
+type QuizSort string
+
+const (
+	SortNewest     QuizSort = "newest"
+	SortName       QuizSort = "name"
+	SortMostPlayed QuizSort = "played"
+)
+
+// DefaultListLimit is the page size used when none is given
+const DefaultListLimit = 20
+
+// QuizListOptions selects a page of a quiz listing. Quizzes must carry every tag in Tags.
+// Cursor is the NextCursor of the previous page, empty for the first one.
+type QuizListOptions struct {
+	Sort   QuizSort
+	Tags   []string
+	Cursor string
+	Limit  int
+}
+
+// QuizPage is one page of a quiz listing. NextCursor is empty on the last page.
+type QuizPage struct {
+	Quizzes    []*Quiz `json:"quizzes"`
+	NextCursor string  `json:"next_cursor,omitempty"`
+}
+
+// quizCursor is the position after the last quiz of a page, it is handed out base64 encoded
+type quizCursor struct {
+	Sort  QuizSort `json:"s"`
+	ID    uint     `json:"id"`
+	Name  string   `json:"n,omitempty"`
+	Plays int64    `json:"p,omitempty"`
+}
+
+// listedQuiz is a quiz together with the keys it is sorted by
+type listedQuiz struct {
+	ID    uint
+	Name  string
+	Plays int64
+}
+
+// normalize fills in defaults and decodes the cursor
+func (o QuizListOptions) normalize() (QuizListOptions, *quizCursor, error) {
+	if o.Sort == "" {
+		o.Sort = SortNewest
+	}
+	switch o.Sort {
+	case SortNewest, SortName, SortMostPlayed:
+	default:
+		return o, nil, fmt.Errorf("%w: unknown sort %q", ErrInvalidListOptions, o.Sort)
+	}
+	if o.Limit <= 0 {
+		o.Limit = DefaultListLimit
+	}
+	tags := []string{}
+	for _, t := range o.Tags {
+		if t = strings.TrimSpace(t); t != "" {
+			tags = append(tags, t)
+		}
+	}
+	o.Tags = tags
+	if o.Cursor == "" {
+		return o, nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(o.Cursor)
+	if err != nil {
+		return o, nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+	c := &quizCursor{}
+	err = json.Unmarshal(raw, c)
+	if err != nil {
+		return o, nil, fmt.Errorf("%w: malformed cursor", ErrInvalidListOptions)
+	}
+	if c.Sort != o.Sort {
+		return o, nil, fmt.Errorf("%w: cursor belongs to a listing sorted by %q", ErrInvalidListOptions, c.Sort)
+	}
+	return o, c, nil
+}
+
+func (c *quizCursor) encode() string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// nextCursor returns the cursor for the page after rows, when more than limit were found
+func nextCursor(sort QuizSort, rows []listedQuiz, limit int) string {
+	if len(rows) <= limit {
+		return ""
+	}
+	last := rows[limit-1]
+	return (&quizCursor{Sort: sort, ID: last.ID, Name: last.Name, Plays: last.Plays}).encode()
+}
+
+// after reports whether q sorts strictly after the cursor
+func (c *quizCursor) after(q listedQuiz) bool {
+	switch c.Sort {
+	case SortName:
+		return q.Name > c.Name || (q.Name == c.Name && q.ID > c.ID)
+	case SortMostPlayed:
+		return q.Plays < c.Plays || (q.Plays == c.Plays && q.ID < c.ID)
+	default:
+		return q.ID < c.ID
+	}
+}
+
+// sortListed orders rows the way the database does for the same sort
+func sortListed(s QuizSort, rows []listedQuiz) {
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		switch s {
+		case SortName:
+			if a.Name != b.Name {
+				return a.Name < b.Name
+			}
+			return a.ID < b.ID
+		case SortMostPlayed:
+			if a.Plays != b.Plays {
+				return a.Plays > b.Plays
+			}
+			return a.ID > b.ID
+		default:
+			return a.ID > b.ID
+		}
+	})
+}
@@ -0,0 +1,123 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+// listAll follows the cursors of a listing to its end and returns the names of the quizzes found
+func listAll(t *testing.T, list func(opts QuizListOptions) (*QuizPage, error), opts QuizListOptions) []string {
+	t.Helper()
+	names := []string{}
+	for pages := 0; ; pages++ {
+		if pages > 10 {
+			t.Fatal("the listing never ends")
+		}
+		page, err := list(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(page.Quizzes) > opts.Limit {
+			t.Fatalf("got a page of %d quizzes, want at most %d", len(page.Quizzes), opts.Limit)
+		}
+		for _, qz := range page.Quizzes {
+			names = append(names, qz.Name)
+		}
+		if page.NextCursor == "" {
+			return names
+		}
+		opts.Cursor = page.NextCursor
+	}
+}
+
+func TestQuizListing(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db QuizStore) {
+		ann := newTestUser(t, db, "ann@example.com")
+		geo := &Tag{Name: "geography"}
+		for i, name := range []string{"E", "C", "A", "D", "B"} {
+			qz := NewQuiz(name, ann, nil)
+			qz.Private = false
+			if i%2 == 0 {
+				qz.AddTags([]*Tag{geo})
+			}
+			err := db.CreateQuiz(qz)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = db.SetCollaboratorRole(qz.ID, ann.ID, RoleOwner)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if name == "A" {
+				err = db.CreatePlaySession(NewPlaySession(ann.Email, qz))
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		newTestQuiz(t, db, "Private", ann)
+
+		cases := []struct {
+			name string
+			opts QuizListOptions
+			user bool
+			want []string
+		}{
+			{"newest first", QuizListOptions{Limit: 2}, false, []string{"B", "D", "A", "C", "E"}},
+			{"by name", QuizListOptions{Sort: SortName, Limit: 2}, false, []string{"A", "B", "C", "D", "E"}},
+			{"most played", QuizListOptions{Sort: SortMostPlayed, Limit: 2}, false, []string{"A", "B", "D", "C", "E"}},
+			{"tagged", QuizListOptions{Tags: []string{"geography"}, Limit: 2}, false, []string{"B", "A", "E"}},
+			{"untagged", QuizListOptions{Tags: []string{"history"}, Limit: 2}, false, []string{}},
+			{"single page", QuizListOptions{Sort: SortName}, false, []string{"A", "B", "C", "D", "E"}},
+			{"of a user", QuizListOptions{Sort: SortName, Limit: 4}, true, []string{"A", "B", "C", "D", "E", "Private"}},
+		}
+		for _, c := range cases {
+			list := db.GetAllPublicQuizzes
+			if c.user {
+				list = func(opts QuizListOptions) (*QuizPage, error) {
+					return db.GetQuizzesByUser(ann.Email, opts)
+				}
+			}
+			opts := c.opts
+			if opts.Limit == 0 {
+				opts.Limit = DefaultListLimit
+			}
+			got := listAll(t, list, opts)
+			if !equalStrings(got, c.want) {
+				t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+			}
+		}
+	})
+}
+
+func TestQuizListingInvalidOptions(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db QuizStore) {
+		ann := newTestUser(t, db, "ann@example.com")
+		for _, name := range []string{"A", "B"} {
+			qz := NewQuiz(name, ann, nil)
+			qz.Private = false
+			err := db.CreateQuiz(qz)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		page, err := db.GetAllPublicQuizzes(QuizListOptions{Limit: 1})
+		if err != nil || page.NextCursor == "" {
+			t.Fatalf("got cursor %q, %v; want one for the second page", page.NextCursor, err)
+		}
+		cases := []struct {
+			name string
+			opts QuizListOptions
+		}{
+			{"unknown sort", QuizListOptions{Sort: "random"}},
+			{"malformed cursor", QuizListOptions{Cursor: "not a cursor"}},
+			{"cursor of another sort", QuizListOptions{Sort: SortName, Cursor: page.NextCursor}},
+		}
+		for _, c := range cases {
+			_, err := db.GetAllPublicQuizzes(c.opts)
+			if !errors.Is(err, ErrInvalidListOptions) {
+				t.Errorf("%s: got %v, want ErrInvalidListOptions", c.name, err)
+			}
+		}
+	})
+}
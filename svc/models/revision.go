@@ -0,0 +1,270 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"time"
+)
+
+// QuizRevision records the content of a quiz after a change, who made it and what it was. Numbers
+// count up from 1 for every quiz, rolling back adds a revision rather than dropping later ones.
+type QuizRevision struct {
+	ID        uint          `gorm:"primaryKey" json:"-"`
+	QuizID    uint          `gorm:"uniqueIndex:idx_quiz_revisions_number" json:"quiz_id"`
+	Number    uint          `gorm:"uniqueIndex:idx_quiz_revisions_number" json:"number"`
+	UserID    uint          `json:"-"`
+	User      *User         `json:"user,omitempty"`
+	Action    string        `json:"action"`
+	CreatedAt time.Time     `json:"created_at"`
+	Snapshot  *QuizSnapshot `gorm:"type:text" json:"snapshot,omitempty"`
+}
+
+// QuizSnapshot is the content of a quiz at a revision: its tags, its rounds and its questions in
+// play order
+type QuizSnapshot struct {
+	Tags      []string            `json:"tags"`
+	Rounds    []*BundleRound      `json:"rounds,omitempty"`
+	Questions []*RevisionQuestion `json:"questions"`
+}
+
+// RevisionQuestion is a question of a QuizSnapshot, the ID ties it to the question it was taken from
+type RevisionQuestion struct {
+	ID uint `json:"id"`
+	BundleQuestion
+}
+
+func (s QuizSnapshot) Value() (driver.Value, error) {
+	b, err := json.Marshal(s)
+	return string(b), err
+}
+
+func (s *QuizSnapshot) Scan(src interface{}) error {
+	return scanJSON(src, s)
+}
+
+// NewQuizSnapshot captures the tags and questions of qz, which must have its questions loaded in
+// play order. Tag names are sorted so snapshots compare equal whatever order tags were added in.
+func NewQuizSnapshot(qz *Quiz) *QuizSnapshot {
+	b := NewQuizBundle(qz, qz.Questions)
+	s := &QuizSnapshot{Tags: sortedNames(b.Tags), Rounds: b.Rounds, Questions: []*RevisionQuestion{}}
+	for i, bq := range b.Questions {
+		bq.Tags = sortedNames(bq.Tags)
+		s.Questions = append(s.Questions, &RevisionQuestion{ID: qz.Questions[i].ID, BundleQuestion: *bq})
+	}
+	return s
+}
+
+func sortedNames(names []string) []string {
+	names = append([]string{}, names...)
+	sort.Strings(names)
+	return names
+}
+
+// QuizDiff is what changed between two revisions of a quiz. Rounds are named by their title,
+// RoundsChanged are the ones found in both revisions whose settings or place changed.
+type QuizDiff struct {
+	From          uint            `json:"from"`
+	To            uint            `json:"to"`
+	TagsAdded     []string        `json:"tags_added"`
+	TagsRemoved   []string        `json:"tags_removed"`
+	RoundsAdded   []string        `json:"rounds_added"`
+	RoundsRemoved []string        `json:"rounds_removed"`
+	RoundsChanged []string        `json:"rounds_changed"`
+	Questions     []*QuestionDiff `json:"questions"`
+}
+
+// QuestionChange is how a question differs between two revisions
+type QuestionChange string
+
+const (
+	QuestionAdded   QuestionChange = "added"
+	QuestionRemoved QuestionChange = "removed"
+	QuestionChanged QuestionChange = "changed"
+	QuestionMoved   QuestionChange = "moved"
+)
+
+// QuestionDiff describes one question that differs. Fields names the changed fields of a changed
+// question, which may also have moved. Positions count from 1 and are 0 where the question is absent.
+type QuestionDiff struct {
+	ID           uint              `json:"id"`
+	Change       QuestionChange    `json:"change"`
+	Fields       []string          `json:"fields,omitempty"`
+	Moved        bool              `json:"moved,omitempty"`
+	FromPosition int               `json:"from_position"`
+	ToPosition   int               `json:"to_position"`
+	Before       *RevisionQuestion `json:"before,omitempty"`
+	After        *RevisionQuestion `json:"after,omitempty"`
+}
+
+// DiffRevisions compares the snapshots of two revisions. Questions only count as moved when their
+// order relative to the questions found in both revisions changed, not because others came or went.
+func DiffRevisions(from, to *QuizRevision) *QuizDiff {
+	d := &QuizDiff{From: from.Number, To: to.Number, TagsAdded: []string{}, TagsRemoved: []string{}, Questions: []*QuestionDiff{}}
+	a, b := from.Snapshot, to.Snapshot
+	if a == nil {
+		a = &QuizSnapshot{}
+	}
+	if b == nil {
+		b = &QuizSnapshot{}
+	}
+	d.TagsAdded, d.TagsRemoved = diffNames(a.Tags, b.Tags), diffNames(b.Tags, a.Tags)
+	d.RoundsAdded, d.RoundsRemoved = diffNames(roundTitles(a.Rounds), roundTitles(b.Rounds)), diffNames(roundTitles(b.Rounds), roundTitles(a.Rounds))
+	d.RoundsChanged = diffRounds(a.Rounds, b.Rounds)
+
+	before, after := map[uint]int{}, map[uint]int{}
+	for i, q := range a.Questions {
+		before[q.ID] = i
+	}
+	for i, q := range b.Questions {
+		after[q.ID] = i
+	}
+	// Ranks among the questions both revisions share
+	rankBefore, rankAfter := map[uint]int{}, map[uint]int{}
+	for _, q := range a.Questions {
+		if _, ok := after[q.ID]; ok {
+			rankBefore[q.ID] = len(rankBefore)
+		}
+	}
+	for _, q := range b.Questions {
+		if _, ok := before[q.ID]; ok {
+			rankAfter[q.ID] = len(rankAfter)
+		}
+	}
+
+	for i, q := range a.Questions {
+		if _, ok := after[q.ID]; !ok {
+			d.Questions = append(d.Questions, &QuestionDiff{ID: q.ID, Change: QuestionRemoved, FromPosition: i + 1, Before: q})
+		}
+	}
+	for i, q := range b.Questions {
+		j, ok := before[q.ID]
+		if !ok {
+			d.Questions = append(d.Questions, &QuestionDiff{ID: q.ID, Change: QuestionAdded, ToPosition: i + 1, After: q})
+			continue
+		}
+		qd := &QuestionDiff{
+			ID:           q.ID,
+			Fields:       diffQuestionFields(&a.Questions[j].BundleQuestion, &q.BundleQuestion),
+			Moved:        rankBefore[q.ID] != rankAfter[q.ID],
+			FromPosition: j + 1,
+			ToPosition:   i + 1,
+		}
+		switch {
+		case len(qd.Fields) > 0:
+			qd.Change, qd.Before, qd.After = QuestionChanged, a.Questions[j], q
+		case qd.Moved:
+			qd.Change = QuestionMoved
+		default:
+			continue
+		}
+		d.Questions = append(d.Questions, qd)
+	}
+	return d
+}
+
+// diffNames returns the names in b that aren't in a
+func diffNames(a, b []string) []string {
+	in := map[string]bool{}
+	for _, n := range a {
+		in[n] = true
+	}
+	diff := []string{}
+	for _, n := range b {
+		if !in[n] {
+			diff = append(diff, n)
+		}
+	}
+	return diff
+}
+
+func roundTitles(rr []*BundleRound) []string {
+	titles := []string{}
+	for _, r := range rr {
+		titles = append(titles, r.Title)
+	}
+	return titles
+}
+
+// diffRounds returns the titles of the rounds in both a and b whose settings differ or whose
+// order relative to the other rounds found in both changed
+func diffRounds(a, b []*BundleRound) []string {
+	before := map[string]*BundleRound{}
+	for _, r := range a {
+		before[r.Title] = r
+	}
+	shared := map[string]bool{}
+	for _, r := range b {
+		shared[r.Title] = before[r.Title] != nil
+	}
+	rank := map[string]int{}
+	for _, r := range a {
+		if shared[r.Title] {
+			rank[r.Title] = len(rank)
+		}
+	}
+	changed := []string{}
+	i := 0
+	for _, r := range b {
+		if !shared[r.Title] {
+			continue
+		}
+		if *before[r.Title] != *r || rank[r.Title] != i {
+			changed = append(changed, r.Title)
+		}
+		i++
+	}
+	return changed
+}
+
+// diffQuestionFields names the fields that differ between two versions of a question, using the
+// JSON names clients know them by
+func diffQuestionFields(a, b *BundleQuestion) []string {
+	fields := []struct {
+		name   string
+		before interface{}
+		after  interface{}
+	}{
+		{"type", a.Type, b.Type},
+		{"text", a.Text, b.Text},
+		{"answer", a.Answer, b.Answer},
+		{"alternatives", a.Alternatives, b.Alternatives},
+		{"options", a.Options, b.Options},
+		{"numeric", a.Numeric, b.Numeric},
+		{"points", a.Points, b.Points},
+		{"timer_seconds", a.TimerSeconds, b.TimerSeconds},
+		{"image_link", a.ImageLink, b.ImageLink},
+		{"audio_link", a.AudioLink, b.AudioLink},
+		{"tags", a.Tags, b.Tags},
+		{"round", a.Round, b.Round},
+	}
+	changed := []string{}
+	for _, f := range fields {
+		if !sameValue(f.before, f.after) {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
+
+// sameValue is reflect.DeepEqual, except that empty slices equal nil ones as they do once stored
+func sameValue(a, b interface{}) bool {
+	va, vb := reflect.ValueOf(a), reflect.ValueOf(b)
+	if va.Kind() == reflect.Slice && vb.Kind() == reflect.Slice && va.Len() == 0 && vb.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+// Restore sets the content of q back to what rq recorded, all but the tags and the round, and
+// reports whether anything changed
+func (rq *RevisionQuestion) Restore(q *Question) (changed bool) {
+	before := NewQuizBundle(&Quiz{}, []*Question{q}).Questions[0]
+	r := rq.Question(q.QuizID)
+	q.Type, q.Text, q.Answer, q.Alternatives, q.Options, q.Numeric = r.Type, r.Text, r.Answer, r.Alternatives, r.Options, r.Numeric
+	q.Points, q.TimerSeconds, q.ImageLink, q.AudioLink = r.Points, r.TimerSeconds, r.ImageLink, r.AudioLink
+	after := NewQuizBundle(&Quiz{}, []*Question{q}).Questions[0]
+	before.Tags, after.Tags = nil, nil
+	return len(diffQuestionFields(before, after)) > 0
+}
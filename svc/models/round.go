@@ -0,0 +1,149 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ErrInvalidRound is returned for rounds that can't be played
+var ErrInvalidRound = errors.New("Rounds need a title and a points multiplier above zero")
+
+// Round groups questions of a quiz that are played together, like a picture or a music round.
+// Rounds are played in Position order, each with its questions in their quiz order. Questions
+// outside of any round are played before the first round.
+type Round struct {
+	ID       uint   `gorm:"primaryKey" json:"id"`
+	QuizID   uint   `gorm:"index" json:"quiz_id"`
+	Title    string `json:"title"`
+	Position int    `gorm:"not null;default:0" json:"position"`
+	// PointsMultiplier scales every point scored while the round is played
+	PointsMultiplier float64 `gorm:"not null;default:1" json:"points_multiplier"`
+	// TimerSeconds is the timer of the questions of the round that don't set their own, 0 for none
+	TimerSeconds uint `json:"timer_seconds,omitempty"`
+	// QuestionIDs are the questions of the round in play order. Stores fill it in, it is never saved.
+	QuestionIDs []uint `gorm:"-" json:"question_ids"`
+}
+
+func NewRound(quizID uint, title string, multiplier float64, timerSeconds uint) *Round {
+	return &Round{
+		QuizID:           quizID,
+		Title:            strings.TrimSpace(title),
+		PointsMultiplier: multiplier,
+		TimerSeconds:     timerSeconds,
+	}
+}
+
+// Validate checks the round can be played
+func (r *Round) Validate() error {
+	if strings.TrimSpace(r.Title) == "" {
+		return ErrInvalidRound
+	}
+	if math.IsNaN(r.PointsMultiplier) || math.IsInf(r.PointsMultiplier, 0) || r.PointsMultiplier <= 0 {
+		return ErrInvalidRound
+	}
+	return nil
+}
+
+// Scale applies the multiplier of the round to points, rounded to whole points. Points scored
+// outside of any round, where r is nil, count as they are.
+func (r *Round) Scale(points int) int {
+	if r == nil {
+		return points
+	}
+	return int(math.Round(float64(points) * r.PointsMultiplier))
+}
+
+// TimerFor returns the timer of q when it is played in r, its own timer wins over the round's
+func (r *Round) TimerFor(q *Question) uint {
+	if q.TimerSeconds != 0 || r == nil {
+		return q.TimerSeconds
+	}
+	return r.TimerSeconds
+}
+
+// RoundOf finds the round a question is played in among rr, nil when it isn't in any of them
+func RoundOf(rr []*Round, q *Question) *Round {
+	if q == nil || q.RoundID == nil {
+		return nil
+	}
+	for _, r := range rr {
+		if r.ID == *q.RoundID {
+			return r
+		}
+	}
+	return nil
+}
+
+// RoundPoints are the points a team scored in every round by round ID, 0 stands for the
+// questions outside of any round. They are kept as JSON in a single column of the team.
+type RoundPoints map[uint]int
+
+func (rp RoundPoints) Value() (driver.Value, error) {
+	if rp == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(rp)
+	return string(b), err
+}
+
+func (rp *RoundPoints) Scan(src interface{}) error {
+	return scanJSON(src, rp)
+}
+
+// RoundScore is what every team scored in one round of a play session, by team name
+type RoundScore struct {
+	RoundID uint           `json:"round_id,omitempty"`
+	Title   string         `json:"title"`
+	Points  map[string]int `json:"points"`
+}
+
+// Scoreboard breaks the points of the teams of a play session down by round, in play order
+type Scoreboard struct {
+	Rounds []*RoundScore  `json:"rounds"`
+	Totals map[string]int `json:"totals"`
+}
+
+// NewScoreboard adds up the points of the teams for every round of rr. Points scored outside of
+// any round come first, those scored in rounds deleted since come last without a title.
+func NewScoreboard(rr []*Round, teams []*Team) *Scoreboard {
+	sb := &Scoreboard{Rounds: []*RoundScore{}, Totals: map[string]int{}}
+	score := func(id uint, title string) *RoundScore {
+		rs := &RoundScore{RoundID: id, Title: title, Points: map[string]int{}}
+		for _, t := range teams {
+			rs.Points[t.Name] += t.RoundPoints[id]
+		}
+		return rs
+	}
+	known := map[uint]bool{}
+	for _, r := range rr {
+		known[r.ID] = true
+	}
+	gone := []uint{}
+	for _, t := range teams {
+		sb.Totals[t.Name] += t.Points
+		for id, points := range t.RoundPoints {
+			if id != 0 && !known[id] && points != 0 {
+				known[id] = true
+				gone = append(gone, id)
+			}
+		}
+	}
+	for _, t := range teams {
+		if t.RoundPoints[0] != 0 {
+			sb.Rounds = append(sb.Rounds, score(0, ""))
+			break
+		}
+	}
+	for _, r := range rr {
+		sb.Rounds = append(sb.Rounds, score(r.ID, r.Title))
+	}
+	sort.Slice(gone, func(i, j int) bool { return gone[i] < gone[j] })
+	for _, id := range gone {
+		sb.Rounds = append(sb.Rounds, score(id, ""))
+	}
+	return sb
+}
@@ -0,0 +1,80 @@
+package models
+
+import (
+	"sort"
+	"strings"
+)
+
+// DefaultSearchLimit caps the number of quizzes a search returns when no limit is given
+const DefaultSearchLimit = 20
+
+// The fallback search used by stores without full-text search. Every word of the query has to
+// appear somewhere in the quiz, a word found in the name counts more than one in a tag, which
+// in turn counts more than one in the text of a question.
+const (
+	searchNameWeight     = 4
+	searchTagWeight      = 2
+	searchQuestionWeight = 1
+)
+
+// searchTerms splits a query into the lowercased words the fallback search looks for
+func searchTerms(query string) []string {
+	return strings.Fields(strings.ToLower(query))
+}
+
+// searchScore ranks qz against terms using its tags and questions, 0 means it doesn't match
+func searchScore(terms []string, qz *Quiz) int {
+	if len(terms) == 0 {
+		return 0
+	}
+	name := strings.ToLower(qz.Name)
+	score := 0
+	for _, term := range terms {
+		termScore := 0
+		if strings.Contains(name, term) {
+			termScore += searchNameWeight
+		}
+		for _, t := range qz.Tags {
+			if strings.Contains(strings.ToLower(t.Name), term) {
+				termScore += searchTagWeight
+			}
+		}
+		for _, q := range qz.Questions {
+			if strings.Contains(strings.ToLower(q.Text), term) {
+				termScore += searchQuestionWeight
+			}
+		}
+		if termScore == 0 {
+			return 0
+		}
+		score += termScore
+	}
+	return score
+}
+
+// rankQuizzes keeps the candidates matching query, best first, at most limit of them.
+// Questions are only needed for scoring and are dropped so answers never end up in results.
+func rankQuizzes(query string, candidates []*Quiz, limit int) []*Quiz {
+	terms := searchTerms(query)
+	scores := make(map[*Quiz]int)
+	qzs := make([]*Quiz, 0)
+	for _, qz := range candidates {
+		score := searchScore(terms, qz)
+		if score == 0 {
+			continue
+		}
+		qz.Questions = nil
+		scores[qz] = score
+		qzs = append(qzs, qz)
+	}
+	sort.SliceStable(qzs, func(i, j int) bool {
+		if scores[qzs[i]] != scores[qzs[j]] {
+			return scores[qzs[i]] > scores[qzs[j]]
+		}
+		return qzs[i].ID < qzs[j].ID
+	})
+	if limit > 0 && len(qzs) > limit {
+		qzs = qzs[:limit]
+	}
+	return qzs
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"context"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+// QuizSQLiteStore keeps everything in a single SQLite file, for single-host deployments where
+// running Postgres is overkill. The queries of QuizPGStore are plain enough to run on SQLite
+// unchanged, so it is embedded as-is and only dialect specific methods are overridden here.
+type QuizSQLiteStore struct {
+	*QuizPGStore
+}
+
+// NewQuizSQLiteStore opens (or creates) the SQLite database at path. The schema is left alone, see Migrator.
+func NewQuizSQLiteStore(path string) (*QuizSQLiteStore, error) {
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{})
+	if err != nil {
+		return &QuizSQLiteStore{&QuizPGStore{}}, err
+	}
+	// SQLite only allows a single writer, funnel everything through one connection
+	// instead of failing with "database is locked" under concurrent requests.
+	sqlDB, err := db.DB()
+	if err != nil {
+		return &QuizSQLiteStore{&QuizPGStore{}}, err
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	return &QuizSQLiteStore{&QuizPGStore{client: db}}, nil
+}
+
+// WithTx hands fn a QuizSQLiteStore so the SQLite overrides stay in effect inside transactions
+func (db *QuizSQLiteStore) WithTx(ctx context.Context, fn func(QuizStore) error) error {
+	return db.client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&QuizSQLiteStore{&QuizPGStore{client: tx}})
+	})
+}
+
+// SearchQuizzes has no full-text search to lean on, it scores every visible quiz in Go instead
+func (db *QuizSQLiteStore) SearchQuizzes(query string, email string, limit int) (qzs []*Quiz, err error) {
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	candidates := []*Quiz{}
+	err = db.client.Preload("Collaborators").Preload("Tags").Preload("Questions").
+		Where(`private = false or exists (
+			select 1 from quiz_collaborators join users on users.id = quiz_collaborators.user_id
+			where quiz_collaborators.quiz_id = quizzes.id and users.email = ?)`, email).
+		Find(&candidates).Error
+	if err != nil {
+		return make([]*Quiz, 0), err
+	}
+	err = db.loadRoles(candidates...)
+	if err != nil {
+		return make([]*Quiz, 0), err
+	}
+	err = db.loadForks(candidates...)
+	if err != nil {
+		return make([]*Quiz, 0), err
+	}
+	return rankQuizzes(query, candidates, limit), nil
+}
@@ -1,13 +1,35 @@
 package models
 
 import (
-	"sort"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 	"gorm.io/gorm/clause"
 )
 
+// ErrConflict matches every ConflictError with errors.Is
+var ErrConflict = errors.New("record was modified concurrently")
+
+// ConflictError is returned when an update is based on a stale version of a record
+type ConflictError struct {
+	Table   string
+	ID      uint
+	Version uint
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s %d was modified concurrently, version %d is stale", e.Table, e.ID, e.Version)
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
 type QuizStore interface {
 	CreateUser(u *User) error
 	UpdateUser(u *User) error
@@ -15,28 +37,112 @@ type QuizStore interface {
 	CreateQuiz(qz *Quiz) error
 	DeleteQuiz(id uint) error
 	GetQuizByName(name string) (qz *Quiz, err error)
+	// QuizNameTaken reports whether a quiz goes by name, trashed ones included as names stay unique
+	QuizNameTaken(name string) (taken bool, err error)
 	GetQuiz(id uint) (qz *Quiz, err error)
 	GetPreloadedQuiz(id uint) (qz *Quiz, err error)
-	GetAllPublicQuizzes() (qzs []*Quiz, err error)
-	GetQuizzesByUser(email string) (qzs []*Quiz, err error)
+	// GetAllPublicQuizzes and GetQuizzesByUser return one page of a listing, see QuizListOptions
+	GetAllPublicQuizzes(opts QuizListOptions) (page *QuizPage, err error)
+	GetQuizzesByUser(email string, opts QuizListOptions) (page *QuizPage, err error)
+	// SearchQuizzes ranks the quizzes visible to email, public ones or those they collaborate on,
+	// by how well their name, tags and question text match query. Best matches come first.
+	SearchQuizzes(query string, email string, limit int) (qzs []*Quiz, err error)
 	UpdateQuiz(qz *Quiz) error
+	// SetCollaboratorRole adds the user to the quiz's collaborators or changes their role
+	SetCollaboratorRole(quizID uint, userID uint, role Role) error
+	RemoveCollaborator(quizID uint, userID uint) error
 	CreateQuestion(q *Question) error
 	UpdateQuestion(id uint, q *Question) error
+	// DeleteQuestion detaches a question from a quiz and keeps it in the trash
 	DeleteQuestion(id uint, quizID uint) error
 	GetQuestion(id uint) (q *Question, err error)
 	GetQuestionsByQuiz(qzID uint) (qq []*Question, err error)
+	// GetQuestionBank returns one page of the questions written by the user, see QuestionBankOptions
+	GetQuestionBank(userID uint, opts QuestionBankOptions) (page *QuestionPage, err error)
+	// GetQuestionQuizIDs lists the live quizzes a question is part of
+	GetQuestionQuizIDs(questionID uint) (ids []uint, err error)
+	// SetQuestionPositions numbers the given questions of a quiz 1..n in the order supplied
+	SetQuestionPositions(quizID uint, ids []uint) error
+	// SetQuestionRound moves a question of a quiz into a round, nil takes it out of any round
+	SetQuestionRound(quizID, questionID uint, roundID *uint) error
+	// GetRounds lists the rounds of a quiz in play order with their questions
+	GetRounds(quizID uint) (rr []*Round, err error)
+	GetRound(id uint) (r *Round, err error)
+	// CreateRound adds r after the last round of its quiz
+	CreateRound(r *Round) error
+	UpdateRound(r *Round) error
+	// DeleteRound removes a round, its questions stay in the quiz outside of any round
+	DeleteRound(id uint) error
+	// SetRoundPositions numbers the given rounds of a quiz 1..n in the order supplied
+	SetRoundPositions(quizID uint, ids []uint) error
 	GetTagByName(name string) (t *Tag, err error)
+	// GetTagUsage lists the tags of public quizzes and of the questions in them with how often they
+	// are used, most used first. Tags used by nothing public are left out.
+	GetTagUsage() (tus []*TagUsage, err error)
+	// AddQuizTags and AddQuestionTags attach tags, creating the ones that are new.
+	// RemoveQuizTags and RemoveQuestionTags only detach them, the tags themselves stay.
+	AddQuizTags(quizID uint, tt []*Tag) error
+	RemoveQuizTags(quizID uint, tt []*Tag) error
+	AddQuestionTags(questionID uint, tt []*Tag) error
+	RemoveQuestionTags(questionID uint, tt []*Tag) error
 	CreatePlaySession(s *PlaySession) error
 	UpdatePlaySession(s *PlaySession) error
 	GetPlaySession(code uint) (s *PlaySession, err error)
+	// GetPlaySessionsInProgress lists the play sessions that have started and aren't over yet
+	GetPlaySessionsInProgress() (ss []*PlaySession, err error)
 	DeletePlaySession(code uint) (err error)
 	UpdateTeam(t *Team) error
+	// SaveSubmission stores a team's answer, replacing the one it gave to the same question before
+	SaveSubmission(sub *Submission) error
+	// GetSubmissions lists the answers to a question of a play session with their users and teams,
+	// oldest first
+	GetSubmissions(sessionID, questionID uint) (subs []*Submission, err error)
+	CreateBuzz(b *Buzz) error
+	UpdateBuzz(b *Buzz) error
+	// GetBuzzes lists the buzzes of a play session with their users and teams, in the order the
+	// server received them
+	GetBuzzes(sessionID uint) (bb []*Buzz, err error)
+	// CreateQuizRevision numbers r as the next revision of its quiz and stores it
+	CreateQuizRevision(r *QuizRevision) error
+	// GetQuizRevisions lists the revisions of a quiz with their authors, newest first and without
+	// their snapshots
+	GetQuizRevisions(quizID uint) (rr []*QuizRevision, err error)
+	GetQuizRevision(quizID, number uint) (r *QuizRevision, err error)
+	GetTrashedQuizzes(email string) (qzs []*Quiz, err error)
+	GetTrashedQuiz(id uint) (qz *Quiz, err error)
+	GetTrashedQuestions(email string) (tqs []*TrashedQuestion, err error)
+	RestoreQuiz(id uint) error
+	RestoreQuestion(id uint, quizID uint) error
+	// PurgeTrash permanently removes quizzes and questions trashed before the given time
+	PurgeTrash(before time.Time) error
+	// WithTx runs fn inside a transaction. Everything done through the store handed to fn
+	// is committed when fn returns nil and rolled back when it returns an error.
+	WithTx(ctx context.Context, fn func(QuizStore) error) error
+}
+
+// NewQuizStore opens the QuizStore matching the scheme of the dsn.
+// memory:// gives an empty in-memory store, sqlite://<path> a SQLite file at path,
+// anything else is handed to Postgres.
+func NewQuizStore(dsn string) (QuizStore, error) {
+	scheme, rest := "", dsn
+	if i := strings.Index(dsn, "://"); i >= 0 {
+		scheme, rest = strings.ToLower(dsn[:i]), dsn[i+len("://"):]
+	}
+	switch scheme {
+	case "memory":
+		return NewQuizMemStore(), nil
+	case "sqlite", "sqlite3":
+		return NewQuizSQLiteStore(rest)
+	default:
+		return NewQuizPGStore(dsn)
+	}
 }
 
 type QuizPGStore struct {
 	client *gorm.DB
 }
 
+// NewQuizPGStore connects to Postgres. The schema is left alone, see Migrator.
 func NewQuizPGStore(dsn string) (*QuizPGStore, error) {
 	db, err := gorm.Open(postgres.New(postgres.Config{
 		DSN: dsn,
@@ -44,20 +150,13 @@ func NewQuizPGStore(dsn string) (*QuizPGStore, error) {
 	if err != nil {
 		return &QuizPGStore{}, err
 	}
-	st := &QuizPGStore{client: db}
-	err = st.Migrate()
-	return st, err
-
+	return &QuizPGStore{client: db}, nil
 }
 
-func (db *QuizPGStore) Migrate() error {
-	return db.client.AutoMigrate(
-		&User{},
-		&Question{},
-		&Quiz{},
-		&PlaySession{},
-		&Team{},
-	)
+func (db *QuizPGStore) WithTx(ctx context.Context, fn func(QuizStore) error) error {
+	return db.client.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		return fn(&QuizPGStore{client: tx})
+	})
 }
 
 func (db *QuizPGStore) CreateUser(u *User) error {
@@ -84,35 +183,255 @@ func (db *QuizPGStore) GetQuizByName(name string) (qz *Quiz, err error) {
 	return
 }
 
+func (db *QuizPGStore) QuizNameTaken(name string) (taken bool, err error) {
+	var count int64
+	err = db.client.Unscoped().Model(&Quiz{}).Where("name = ?", name).Count(&count).Error
+	return count > 0, err
+}
+
 func (db *QuizPGStore) GetQuiz(id uint) (qz *Quiz, err error) {
 	qz = &Quiz{}
-	err = db.client.Preload("Collaborators").Preload("Tags").Preload("Questions").Where("id = ?", id).First(qz).Error
+	err = db.client.Preload("Collaborators").Preload("Tags").Where("id = ?", id).First(qz).Error
+	if err != nil {
+		return
+	}
+	err = db.loadRoles(qz)
+	if err != nil {
+		return
+	}
+	err = db.loadForks(qz)
+	if err != nil {
+		return
+	}
+	// Preload can't order by the join table, load the questions in play order instead
+	qz.Questions, err = db.GetQuestionsByQuiz(id)
+	if err != nil {
+		return
+	}
+	qz.Rounds, err = db.GetRounds(id)
 	return
 }
 
 func (db *QuizPGStore) GetPreloadedQuiz(id uint) (qz *Quiz, err error) {
 	qz = &Quiz{}
 	err = db.client.Preload(clause.Associations).Where("id = ?", id).First(qz).Error
+	if err != nil {
+		return
+	}
+	err = db.loadRoles(qz)
+	if err != nil {
+		return
+	}
+	err = db.loadForks(qz)
+	if err != nil {
+		return
+	}
+	qz.Questions, err = db.GetQuestionsByQuiz(id)
+	if err != nil {
+		return
+	}
+	qz.Rounds, err = db.GetRounds(id)
 	return
 }
 
-func (db *QuizPGStore) GetAllPublicQuizzes() (qzs []*Quiz, err error) {
-	qzs = make([]*Quiz, 0)
-	err = db.client.Preload("Collaborators").Preload("Tags").Where("private = false").Find(&qzs).Error
+// quizPlaysSQL counts the play sessions ever started for a quiz, ended ones included
+const quizPlaysSQL = "(select count(*) from play_sessions where play_sessions.quiz_id = quizzes.id)"
+
+func (db *QuizPGStore) GetAllPublicQuizzes(opts QuizListOptions) (page *QuizPage, err error) {
+	return db.listQuizzes(db.client.Where("quizzes.private = false"), opts)
+}
+
+func (db *QuizPGStore) GetQuizzesByUser(email string, opts QuizListOptions) (page *QuizPage, err error) {
+	return db.listQuizzes(db.client.Where(`exists (
+		select 1 from quiz_collaborators join users on users.id = quiz_collaborators.user_id
+		where quiz_collaborators.quiz_id = quizzes.id and users.email = ?)`, email), opts)
+}
+
+// listQuizzes pages through the quizzes selected by scope using keyset pagination, so later pages
+// cost the same as the first one
+func (db *QuizPGStore) listQuizzes(scope *gorm.DB, opts QuizListOptions) (page *QuizPage, err error) {
+	page = &QuizPage{Quizzes: make([]*Quiz, 0)}
+	opts, cursor, err := opts.normalize()
+	if err != nil {
+		return
+	}
+	q := scope.Model(&Quiz{}).Select("quizzes.id, quizzes.name, " + quizPlaysSQL + " as plays")
+	for _, t := range opts.Tags {
+		q = q.Where(`exists (select 1 from quiz_tags join tags on tags.id = quiz_tags.tag_id
+			where quiz_tags.quiz_id = quizzes.id and tags.name = ?)`, t)
+	}
+	switch opts.Sort {
+	case SortName:
+		if cursor != nil {
+			q = q.Where("(quizzes.name > ? or (quizzes.name = ? and quizzes.id > ?))", cursor.Name, cursor.Name, cursor.ID)
+		}
+		q = q.Order("quizzes.name, quizzes.id")
+	case SortMostPlayed:
+		if cursor != nil {
+			q = q.Where("("+quizPlaysSQL+" < ? or ("+quizPlaysSQL+" = ? and quizzes.id < ?))", cursor.Plays, cursor.Plays, cursor.ID)
+		}
+		q = q.Order("plays desc, quizzes.id desc")
+	default:
+		if cursor != nil {
+			q = q.Where("quizzes.id < ?", cursor.ID)
+		}
+		q = q.Order("quizzes.id desc")
+	}
+	rows := []listedQuiz{}
+	err = q.Limit(opts.Limit + 1).Scan(&rows).Error
+	if err != nil {
+		return
+	}
+	page.NextCursor = nextCursor(opts.Sort, rows, opts.Limit)
+	if len(rows) > opts.Limit {
+		rows = rows[:opts.Limit]
+	}
+	ids := []uint{}
+	for _, r := range rows {
+		ids = append(ids, r.ID)
+	}
+	page.Quizzes, err = db.quizzesByIDs(ids)
 	return
 }
 
-func (db *QuizPGStore) GetQuizzesByUser(email string) (qzs []*Quiz, err error) {
-	u := &User{}
-	err = db.client.Preload("Quizzes.Tags").Preload("Quizzes.Collaborators").Preload("Quizzes").Where("email = ?", email).First(u).Error
-	qzs = u.Quizzes
+// quizzesByIDs loads quizzes with their collaborators and tags, in the order of ids
+func (db *QuizPGStore) quizzesByIDs(ids []uint) (qzs []*Quiz, err error) {
+	qzs = make([]*Quiz, 0)
+	if len(ids) == 0 {
+		return
+	}
+	found := []*Quiz{}
+	err = db.client.Preload("Collaborators").Preload("Tags").Where("id IN ?", ids).Find(&found).Error
+	if err != nil {
+		return
+	}
+	byID := make(map[uint]*Quiz)
+	for _, qz := range found {
+		byID[qz.ID] = qz
+	}
+	for _, id := range ids {
+		if qz, ok := byID[id]; ok {
+			qzs = append(qzs, qz)
+		}
+	}
+	err = db.loadRoles(qzs...)
+	if err != nil {
+		return
+	}
+	err = db.loadForks(qzs...)
 	return
 }
 
+// loadRoles fills in the Roles of the given quizzes
+func (db *QuizPGStore) loadRoles(qzs ...*Quiz) error {
+	if len(qzs) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*Quiz)
+	for _, qz := range qzs {
+		qz.Roles = make(map[uint]Role)
+		byID[qz.ID] = qz
+	}
+	ids := []uint{}
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	rows := []*QuizCollaborator{}
+	err := db.client.Where("quiz_id IN ?", ids).Find(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		byID[r.QuizID].Roles[r.UserID] = r.Role
+	}
+	return nil
+}
+
+// loadForks fills in the Forks of the given quizzes
+func (db *QuizPGStore) loadForks(qzs ...*Quiz) error {
+	if len(qzs) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*Quiz)
+	for _, qz := range qzs {
+		qz.Forks = 0
+		byID[qz.ID] = qz
+	}
+	ids := []uint{}
+	for id := range byID {
+		ids = append(ids, id)
+	}
+	rows := []struct {
+		ForkedFromID uint
+		Forks        int64
+	}{}
+	err := db.client.Model(&Quiz{}).Select("forked_from_id, count(*) as forks").
+		Where("forked_from_id IN ?", ids).Group("forked_from_id").Scan(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, r := range rows {
+		byID[r.ForkedFromID].Forks = r.Forks
+	}
+	return nil
+}
+
+// SearchQuizzes uses Postgres full-text search. Quiz names weigh most, then tags, then question text.
+func (db *QuizPGStore) SearchQuizzes(query string, email string, limit int) (qzs []*Quiz, err error) {
+	qzs = make([]*Quiz, 0)
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	}
+	hits := []struct {
+		ID   uint
+		Rank float64
+	}{}
+	err = db.client.Raw(`select id, ts_rank(document, query) as rank from (
+			select quizzes.id,
+				setweight(to_tsvector('english', quizzes.name), 'A') ||
+				setweight(to_tsvector('english', coalesce((select string_agg(tags.name, ' ') from quiz_tags
+					join tags on tags.id = quiz_tags.tag_id
+					where quiz_tags.quiz_id = quizzes.id), '')), 'B') ||
+				setweight(to_tsvector('english', coalesce((select string_agg(questions.text, ' ') from quiz_questions
+					join questions on questions.id = quiz_questions.question_id and questions.deleted_at is null
+					where quiz_questions.quiz_id = quizzes.id), '')), 'C') as document
+			from quizzes
+			where quizzes.deleted_at is null and (quizzes.private = false or exists (
+				select 1 from quiz_collaborators join users on users.id = quiz_collaborators.user_id
+				where quiz_collaborators.quiz_id = quizzes.id and users.email = ?))
+		) docs, plainto_tsquery('english', ?) query
+		where document @@ query
+		order by rank desc, id
+		limit ?`, email, query, limit).Scan(&hits).Error
+	if err != nil {
+		return
+	}
+	ids := []uint{}
+	for _, h := range hits {
+		ids = append(ids, h.ID)
+	}
+	return db.quizzesByIDs(ids)
+}
+
 func (db *QuizPGStore) UpdateQuiz(qz *Quiz) error {
 	return db.client.Save(qz).Error
 }
 
+func (db *QuizPGStore) SetCollaboratorRole(quizID uint, userID uint, role Role) error {
+	return db.client.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "quiz_id"}, {Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"role"}),
+	}).Create(&QuizCollaborator{QuizID: quizID, UserID: userID, Role: role}).Error
+}
+
+func (db *QuizPGStore) RemoveCollaborator(quizID uint, userID uint) error {
+	res := db.client.Where("quiz_id = ? AND user_id = ?", quizID, userID).Delete(&QuizCollaborator{})
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
 func (db *QuizPGStore) CreateQuestion(q *Question) error {
 	return db.client.Create(q).Error
 }
@@ -123,12 +442,23 @@ func (db *QuizPGStore) UpdateQuestion(id uint, q *Question) error {
 }
 
 func (db *QuizPGStore) DeleteQuestion(id uint, quizID uint) error {
-	return db.client.Exec("delete from quiz_questions where question_id=? and quiz_id=?", id, quizID).Error
+	return db.client.Transaction(func(tx *gorm.DB) error {
+		qqs := []*QuizQuestion{}
+		err := tx.Where("question_id = ? and quiz_id = ?", id, quizID).Find(&qqs).Error
+		if err != nil || len(qqs) == 0 {
+			return err
+		}
+		err = tx.Exec("delete from quiz_questions where question_id=? and quiz_id=?", id, quizID).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(&TrashedQuestion{QuizID: quizID, QuestionID: id, Position: qqs[0].Position, RoundID: qqs[0].RoundID, TrashedAt: time.Now()}).Error
+	})
 }
 
 func (db *QuizPGStore) GetQuestion(id uint) (q *Question, err error) {
 	q = &Question{}
-	err = db.client.First(q, id).Error
+	err = db.client.Preload("Tags").First(q, id).Error
 	return
 }
 
@@ -141,24 +471,250 @@ func (db *QuizPGStore) DeleteQuiz(id uint) (err error) {
 }
 
 func (db *QuizPGStore) GetQuestionsByQuiz(qzID uint) (qq []*Question, err error) {
-	qz := &Quiz{}
-	err = db.client.Preload("Questions").First(qz, qzID).Error
+	err = db.client.First(&Quiz{}, qzID).Error
 	if err != nil {
 		return qq, err
 	}
-	qq = qz.Questions
-	sort.Slice(qq, func(i, j int) bool {
-		return qq[i].ID < qq[j].ID
-	})
+	qq = []*Question{}
+	err = db.client.Preload("Tags").Joins("JOIN quiz_questions ON quiz_questions.question_id = questions.id").
+		Joins("LEFT JOIN rounds ON rounds.id = quiz_questions.round_id").
+		Where("quiz_questions.quiz_id = ?", qzID).
+		Order("coalesce(rounds.position, 0), quiz_questions.position, questions.id").
+		Find(&qq).Error
+	if err != nil || len(qq) == 0 {
+		return qq, err
+	}
+	// The round is kept on the join row, a question shared with other quizzes has one in each
+	rows := []*QuizQuestion{}
+	err = db.client.Where("quiz_id = ? AND round_id IS NOT NULL", qzID).Find(&rows).Error
+	if err != nil {
+		return qq, err
+	}
+	rounds := make(map[uint]*uint)
+	for _, r := range rows {
+		rounds[r.QuestionID] = r.RoundID
+	}
+	for _, q := range qq {
+		q.RoundID = rounds[q.ID]
+	}
 	return qq, nil
 }
 
+func (db *QuizPGStore) GetQuestionBank(userID uint, opts QuestionBankOptions) (page *QuestionPage, err error) {
+	page = &QuestionPage{Questions: make([]*Question, 0)}
+	opts, after, err := opts.normalize()
+	if err != nil {
+		return
+	}
+	q := db.client.Preload("Tags").Where("questions.user_id = ?", userID)
+	for _, term := range searchTerms(opts.Query) {
+		q = q.Where(`(lower(questions.text) like @term escape '\' or lower(questions.answer) like @term escape '\')`,
+			map[string]interface{}{"term": likePattern(term)})
+	}
+	for _, t := range opts.Tags {
+		q = q.Where(`exists (select 1 from question_tags join tags on tags.id = question_tags.tag_id
+			where question_tags.question_id = questions.id and tags.name = ?)`, t)
+	}
+	if opts.Type != "" {
+		q = q.Where("questions.type = ?", opts.Type)
+	}
+	if after != 0 {
+		q = q.Where("questions.id < ?", after)
+	}
+	qq := []*Question{}
+	err = q.Order("questions.id desc").Limit(opts.Limit + 1).Find(&qq).Error
+	if err != nil {
+		return
+	}
+	page.NextCursor = nextBankCursor(qq, opts.Limit)
+	if len(qq) > opts.Limit {
+		qq = qq[:opts.Limit]
+	}
+	if len(qq) == 0 {
+		return
+	}
+	byID := make(map[uint]*Question)
+	ids := []uint{}
+	for _, bq := range qq {
+		bq.QuizIDs = []uint{}
+		byID[bq.ID] = bq
+		ids = append(ids, bq.ID)
+	}
+	rows := []*QuizQuestion{}
+	err = db.client.Joins("JOIN quizzes ON quizzes.id = quiz_questions.quiz_id AND quizzes.deleted_at IS NULL").
+		Where("quiz_questions.question_id IN ?", ids).Order("quiz_questions.quiz_id").Find(&rows).Error
+	if err != nil {
+		return
+	}
+	for _, r := range rows {
+		byID[r.QuestionID].QuizIDs = append(byID[r.QuestionID].QuizIDs, r.QuizID)
+	}
+	page.Questions = qq
+	return
+}
+
+func (db *QuizPGStore) GetQuestionQuizIDs(questionID uint) (ids []uint, err error) {
+	ids = []uint{}
+	err = db.client.Model(&QuizQuestion{}).
+		Joins("JOIN quizzes ON quizzes.id = quiz_questions.quiz_id AND quizzes.deleted_at IS NULL").
+		Where("quiz_questions.question_id = ?", questionID).Order("quiz_questions.quiz_id").
+		Pluck("quiz_questions.quiz_id", &ids).Error
+	return
+}
+
+func (db *QuizPGStore) SetQuestionPositions(quizID uint, ids []uint) error {
+	return db.client.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			err := tx.Model(&QuizQuestion{}).Where("quiz_id = ? AND question_id = ?", quizID, id).Update("position", i+1).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (db *QuizPGStore) SetQuestionRound(quizID, questionID uint, roundID *uint) error {
+	res := db.client.Model(&QuizQuestion{}).Where("quiz_id = ? AND question_id = ?", quizID, questionID).Update("round_id", roundID)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+func (db *QuizPGStore) GetRounds(quizID uint) (rr []*Round, err error) {
+	rr = []*Round{}
+	err = db.client.Where("quiz_id = ?", quizID).Order("position, id").Find(&rr).Error
+	if err != nil {
+		return
+	}
+	err = db.loadRoundQuestions(rr...)
+	return
+}
+
+func (db *QuizPGStore) GetRound(id uint) (r *Round, err error) {
+	r = &Round{}
+	err = db.client.First(r, id).Error
+	if err != nil {
+		return
+	}
+	err = db.loadRoundQuestions(r)
+	return
+}
+
+// loadRoundQuestions fills in the QuestionIDs of the given rounds
+func (db *QuizPGStore) loadRoundQuestions(rr ...*Round) error {
+	if len(rr) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*Round)
+	ids := []uint{}
+	for _, r := range rr {
+		r.QuestionIDs = []uint{}
+		byID[r.ID] = r
+		ids = append(ids, r.ID)
+	}
+	rows := []*QuizQuestion{}
+	err := db.client.Joins("JOIN questions ON questions.id = quiz_questions.question_id AND questions.deleted_at IS NULL").
+		Where("quiz_questions.round_id IN ?", ids).
+		Order("quiz_questions.position, quiz_questions.question_id").
+		Find(&rows).Error
+	if err != nil {
+		return err
+	}
+	for _, row := range rows {
+		r := byID[*row.RoundID]
+		r.QuestionIDs = append(r.QuestionIDs, row.QuestionID)
+	}
+	return nil
+}
+
+func (db *QuizPGStore) CreateRound(r *Round) error {
+	var last int
+	err := db.client.Model(&Round{}).Where("quiz_id = ?", r.QuizID).
+		Select("coalesce(max(position), 0)").Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	r.Position = last + 1
+	return db.client.Create(r).Error
+}
+
+func (db *QuizPGStore) UpdateRound(r *Round) error {
+	return db.client.Save(r).Error
+}
+
+func (db *QuizPGStore) DeleteRound(id uint) error {
+	return db.client.Transaction(func(tx *gorm.DB) error {
+		res := tx.Delete(&Round{}, id)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		err := tx.Exec("update quiz_questions set round_id = null where round_id = ?", id).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("update trashed_questions set round_id = null where round_id = ?", id).Error
+	})
+}
+
+func (db *QuizPGStore) SetRoundPositions(quizID uint, ids []uint) error {
+	return db.client.Transaction(func(tx *gorm.DB) error {
+		for i, id := range ids {
+			err := tx.Model(&Round{}).Where("quiz_id = ? AND id = ?", quizID, id).Update("position", i+1).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
 func (db *QuizPGStore) GetTagByName(name string) (t *Tag, err error) {
 	t = &Tag{}
 	err = db.client.Where("name = ?", name).First(t).Error
 	return
 }
 
+func (db *QuizPGStore) GetTagUsage() (tus []*TagUsage, err error) {
+	all := []*TagUsage{}
+	err = db.client.Model(&Tag{}).Select(`tags.name,
+		(select count(*) from quiz_tags join quizzes on quizzes.id = quiz_tags.quiz_id
+			where quiz_tags.tag_id = tags.id and quizzes.deleted_at is null and quizzes.private = false) as quizzes,
+		(select count(*) from question_tags join questions on questions.id = question_tags.question_id
+			where question_tags.tag_id = tags.id and questions.deleted_at is null and exists (
+				select 1 from quiz_questions join quizzes on quizzes.id = quiz_questions.quiz_id
+				where quiz_questions.question_id = questions.id and quizzes.deleted_at is null and quizzes.private = false)) as questions`).
+		Order("quizzes desc, questions desc, tags.name").
+		Scan(&all).Error
+	tus = make([]*TagUsage, 0)
+	for _, tu := range all {
+		if tu.Quizzes > 0 || tu.Questions > 0 {
+			tus = append(tus, tu)
+		}
+	}
+	return
+}
+
+func (db *QuizPGStore) AddQuizTags(quizID uint, tt []*Tag) error {
+	return db.client.Model(&Quiz{Model: gorm.Model{ID: quizID}}).Association("Tags").Append(tt)
+}
+
+func (db *QuizPGStore) RemoveQuizTags(quizID uint, tt []*Tag) error {
+	return db.client.Model(&Quiz{Model: gorm.Model{ID: quizID}}).Association("Tags").Delete(tt)
+}
+
+func (db *QuizPGStore) AddQuestionTags(questionID uint, tt []*Tag) error {
+	return db.client.Model(&Question{Model: gorm.Model{ID: questionID}}).Association("Tags").Append(tt)
+}
+
+func (db *QuizPGStore) RemoveQuestionTags(questionID uint, tt []*Tag) error {
+	return db.client.Model(&Question{Model: gorm.Model{ID: questionID}}).Association("Tags").Delete(tt)
+}
+
 func (db *QuizPGStore) CreatePlaySession(s *PlaySession) error {
 	return db.client.Create(s).Error
 }
@@ -169,14 +725,276 @@ func (db *QuizPGStore) GetPlaySession(code uint) (s *PlaySession, err error) {
 	return
 }
 
+func (db *QuizPGStore) GetPlaySessionsInProgress() (ss []*PlaySession, err error) {
+	ss = []*PlaySession{}
+	err = db.client.Preload("Quiz").Preload("Users").Preload("Teams").Preload("Teams.Users").Where("state = ?", StateInProgress).Order("id").Find(&ss).Error
+	return
+}
+
 func (db *QuizPGStore) DeletePlaySession(code uint) (err error) {
 	return db.client.Where("code = ?", code).Delete(&PlaySession{}).Error
 }
 
+// UpdatePlaySession saves s if nobody else did since it was read, otherwise it fails with a ConflictError
 func (db *QuizPGStore) UpdatePlaySession(s *PlaySession) error {
-	return db.client.Save(s).Error
+	return db.client.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&PlaySession{}).Where("id = ? AND version = ?", s.ID, s.Version).Update("version", s.Version+1)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &ConflictError{Table: "play_sessions", ID: s.ID, Version: s.Version}
+		}
+		s.Version++
+		err := tx.Save(s).Error
+		if err != nil {
+			s.Version--
+		}
+		return err
+	})
+}
+
+func (db *QuizPGStore) SaveSubmission(sub *Submission) error {
+	return db.client.Transaction(func(tx *gorm.DB) error {
+		if sub.ID == 0 {
+			existing := &Submission{}
+			err := tx.Where("play_session_id = ? AND question_id = ? AND team_id = ?", sub.PlaySessionID, sub.QuestionID, sub.TeamID).Limit(1).Find(existing).Error
+			if err != nil {
+				return err
+			}
+			sub.ID, sub.CreatedAt = existing.ID, existing.CreatedAt
+		}
+		return tx.Omit("User", "Team").Save(sub).Error
+	})
 }
 
+func (db *QuizPGStore) GetSubmissions(sessionID, questionID uint) (subs []*Submission, err error) {
+	subs = []*Submission{}
+	err = db.client.Preload("User").Preload("Team").Where("play_session_id = ? AND question_id = ?", sessionID, questionID).Order("created_at, id").Find(&subs).Error
+	return
+}
+
+func (db *QuizPGStore) CreateBuzz(b *Buzz) error {
+	return db.client.Omit("User", "Team", "Question").Create(b).Error
+}
+
+func (db *QuizPGStore) UpdateBuzz(b *Buzz) error {
+	return db.client.Omit("User", "Team", "Question").Save(b).Error
+}
+
+func (db *QuizPGStore) GetBuzzes(sessionID uint) (bb []*Buzz, err error) {
+	bb = []*Buzz{}
+	err = db.client.Preload("User").Preload("Team").Where("play_session_id = ?", sessionID).Order("ts, id").Find(&bb).Error
+	return
+}
+
+// UpdateTeam saves t if nobody else did since it was read, otherwise it fails with a ConflictError
 func (db *QuizPGStore) UpdateTeam(t *Team) error {
-	return db.client.Save(t).Error
+	return db.client.Transaction(func(tx *gorm.DB) error {
+		res := tx.Model(&Team{}).Where("id = ? AND version = ?", t.ID, t.Version).Update("version", t.Version+1)
+		if res.Error != nil {
+			return res.Error
+		}
+		if res.RowsAffected == 0 {
+			return &ConflictError{Table: "teams", ID: t.ID, Version: t.Version}
+		}
+		t.Version++
+		err := tx.Save(t).Error
+		if err != nil {
+			t.Version--
+		}
+		return err
+	})
+}
+
+func (db *QuizPGStore) CreateQuizRevision(r *QuizRevision) error {
+	var last uint
+	err := db.client.Model(&QuizRevision{}).Where("quiz_id = ?", r.QuizID).
+		Select("coalesce(max(number), 0)").Scan(&last).Error
+	if err != nil {
+		return err
+	}
+	r.Number = last + 1
+	return db.client.Omit("User").Create(r).Error
+}
+
+func (db *QuizPGStore) GetQuizRevisions(quizID uint) (rr []*QuizRevision, err error) {
+	rr = []*QuizRevision{}
+	err = db.client.Preload("User").Omit("snapshot").Where("quiz_id = ?", quizID).Order("number desc").Find(&rr).Error
+	return
+}
+
+func (db *QuizPGStore) GetQuizRevision(quizID, number uint) (r *QuizRevision, err error) {
+	r = &QuizRevision{}
+	err = db.client.Preload("User").Where("quiz_id = ? AND number = ?", quizID, number).First(r).Error
+	return
+}
+
+func (db *QuizPGStore) GetTrashedQuizzes(email string) (qzs []*Quiz, err error) {
+	qzs = make([]*Quiz, 0)
+	err = db.client.Unscoped().Preload("Collaborators").Preload("Tags").
+		Joins("JOIN quiz_collaborators ON quiz_collaborators.quiz_id = quizzes.id").
+		Joins("JOIN users ON users.id = quiz_collaborators.user_id").
+		Where("users.email = ? AND quiz_collaborators.role = ? AND quizzes.deleted_at IS NOT NULL", email, RoleOwner).
+		Order("quizzes.deleted_at DESC").
+		Find(&qzs).Error
+	if err != nil {
+		return
+	}
+	err = db.loadRoles(qzs...)
+	if err != nil {
+		return
+	}
+	err = db.loadForks(qzs...)
+	return
+}
+
+func (db *QuizPGStore) GetTrashedQuiz(id uint) (qz *Quiz, err error) {
+	qz = &Quiz{}
+	err = db.client.Unscoped().Preload("Collaborators").Where("id = ? AND deleted_at IS NOT NULL", id).First(qz).Error
+	if err != nil {
+		return
+	}
+	err = db.loadRoles(qz)
+	if err != nil {
+		return
+	}
+	err = db.loadForks(qz)
+	return
+}
+
+func (db *QuizPGStore) GetTrashedQuestions(email string) (tqs []*TrashedQuestion, err error) {
+	tqs = make([]*TrashedQuestion, 0)
+	err = db.client.Preload("Question").
+		Joins("JOIN quizzes ON quizzes.id = trashed_questions.quiz_id AND quizzes.deleted_at IS NULL").
+		Joins("JOIN quiz_collaborators ON quiz_collaborators.quiz_id = quizzes.id").
+		Joins("JOIN users ON users.id = quiz_collaborators.user_id").
+		Where("users.email = ? AND quiz_collaborators.role IN ?", email, []Role{RoleOwner, RoleEditor}).
+		Order("trashed_questions.trashed_at DESC").
+		Find(&tqs).Error
+	return
+}
+
+func (db *QuizPGStore) RestoreQuiz(id uint) error {
+	res := db.client.Unscoped().Model(&Quiz{}).Where("id = ? AND deleted_at IS NOT NULL", id).Update("deleted_at", nil)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return res.Error
+}
+
+// RestoreQuestion puts the most recently trashed copy of a question back at its old position, in
+// its old round
+func (db *QuizPGStore) RestoreQuestion(id uint, quizID uint) error {
+	return db.client.Transaction(func(tx *gorm.DB) error {
+		tq := &TrashedQuestion{}
+		err := tx.Where("question_id = ? AND quiz_id = ?", id, quizID).Order("trashed_at DESC").First(tq).Error
+		if err != nil {
+			return err
+		}
+		var attached int64
+		err = tx.Model(&QuizQuestion{}).Where("question_id = ? AND quiz_id = ?", id, quizID).Count(&attached).Error
+		if err != nil {
+			return err
+		}
+		if attached == 0 {
+			err = tx.Create(&QuizQuestion{QuizID: quizID, QuestionID: id, Position: tq.Position, RoundID: tq.RoundID}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return tx.Where("question_id = ? AND quiz_id = ?", id, quizID).Delete(&TrashedQuestion{}).Error
+	})
+}
+
+func (db *QuizPGStore) PurgeTrash(before time.Time) error {
+	return db.client.Transaction(func(tx *gorm.DB) error {
+		quizIDs := []uint{}
+		err := tx.Unscoped().Model(&Quiz{}).Where("deleted_at IS NOT NULL AND deleted_at < ?", before).Pluck("id", &quizIDs).Error
+		if err != nil {
+			return err
+		}
+		trashed := []*TrashedQuestion{}
+		err = tx.Where("trashed_at < ? OR quiz_id IN ?", before, quizIDs).Find(&trashed).Error
+		if err != nil {
+			return err
+		}
+
+		// Questions of purged quizzes or trash entries may now belong nowhere
+		candidates := []uint{}
+		err = tx.Model(&QuizQuestion{}).Where("quiz_id IN ?", quizIDs).Pluck("question_id", &candidates).Error
+		if err != nil {
+			return err
+		}
+		trashedIDs := []uint{}
+		for _, tq := range trashed {
+			candidates = append(candidates, tq.QuestionID)
+			trashedIDs = append(trashedIDs, tq.ID)
+		}
+
+		if len(quizIDs) > 0 {
+			sessionIDs := []uint{}
+			err = tx.Unscoped().Model(&PlaySession{}).Where("quiz_id IN ?", quizIDs).Pluck("id", &sessionIDs).Error
+			if err != nil {
+				return err
+			}
+			teamIDs := []uint{}
+			err = tx.Table("session_teams").Where("play_session_id IN ?", sessionIDs).Pluck("team_id", &teamIDs).Error
+			if err != nil {
+				return err
+			}
+			stmts := []struct {
+				sql string
+				ids []uint
+			}{
+				{"delete from submissions where play_session_id in ?", sessionIDs},
+				{"delete from buzzs where play_session_id in ?", sessionIDs},
+				{"delete from session_users where play_session_id in ?", sessionIDs},
+				{"delete from session_teams where play_session_id in ?", sessionIDs},
+				{"delete from user_teams where team_id in ?", teamIDs},
+				{"delete from teams where id in ?", teamIDs},
+				{"delete from play_sessions where id in ?", sessionIDs},
+				{"delete from quiz_collaborators where quiz_id in ?", quizIDs},
+				{"delete from quiz_tags where quiz_id in ?", quizIDs},
+				{"delete from quiz_questions where quiz_id in ?", quizIDs},
+				{"delete from rounds where quiz_id in ?", quizIDs},
+				{"delete from quiz_revisions where quiz_id in ?", quizIDs},
+				{"update quizzes set forked_from_id = null where forked_from_id in ?", quizIDs},
+				{"delete from quizzes where id in ?", quizIDs},
+			}
+			for _, st := range stmts {
+				if len(st.ids) == 0 {
+					continue
+				}
+				err = tx.Exec(st.sql, st.ids).Error
+				if err != nil {
+					return err
+				}
+			}
+		}
+		if len(trashedIDs) > 0 {
+			err = tx.Delete(&TrashedQuestion{}, trashedIDs).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if len(candidates) == 0 {
+			return nil
+		}
+		orphans := []uint{}
+		err = tx.Unscoped().Model(&Question{}).
+			Where("id IN ?", candidates).
+			Where("NOT EXISTS (select 1 from quiz_questions where quiz_questions.question_id = questions.id)").
+			Where("NOT EXISTS (select 1 from trashed_questions where trashed_questions.question_id = questions.id)").
+			Pluck("id", &orphans).Error
+		if err != nil || len(orphans) == 0 {
+			return err
+		}
+		err = tx.Exec("delete from question_tags where question_id in ?", orphans).Error
+		if err != nil {
+			return err
+		}
+		return tx.Exec("delete from questions where id in ?", orphans).Error
+	})
 }
@@ -0,0 +1,347 @@
+package models
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gorm.io/gorm"
+)
+
+// postgresTestDSN names the environment variable pointing the tests at a Postgres database. The
+// tests wipe it, so never point it at one holding data you care about.
+const postgresTestDSN = "LAQZ_TEST_POSTGRES"
+
+// forEachStore runs fn against every QuizStore implementation, each time on an empty store with
+// an up to date schema. Postgres is skipped unless LAQZ_TEST_POSTGRES is set.
+func forEachStore(t *testing.T, fn func(t *testing.T, db QuizStore)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewQuizMemStore())
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := NewQuizSQLiteStore(filepath.Join(t.TempDir(), "laqz.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.Migrate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, db)
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv(postgresTestDSN)
+		if dsn == "" {
+			t.Skipf("%s is not set", postgresTestDSN)
+		}
+		db, err := NewQuizPGStore(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Reverting every migration drops whatever the previous test left behind
+		err = db.MigrateDown(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.Migrate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, db)
+	})
+}
+
+// newTestUser creates a user named after their email
+func newTestUser(t *testing.T, db QuizStore, email string) *User {
+	t.Helper()
+	u := &User{Email: email, Name: email}
+	err := db.CreateUser(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+// newTestQuiz creates a quiz owned by owner with the given questions, in that order
+func newTestQuiz(t *testing.T, db QuizStore, name string, owner *User, qq ...*Question) *Quiz {
+	t.Helper()
+	qz := NewQuiz(name, owner, nil)
+	err := db.CreateQuiz(qz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.SetCollaboratorRole(qz.ID, owner.ID, RoleOwner)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(qq) == 0 {
+		return qz
+	}
+	ids := []uint{}
+	for _, q := range qq {
+		q.QuizID, q.UserID = qz.ID, owner.ID
+		qz.AddQuestion(q)
+	}
+	err = db.UpdateQuiz(qz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range qq {
+		ids = append(ids, q.ID)
+	}
+	err = db.SetQuestionPositions(qz.ID, ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return qz
+}
+
+func questionTexts(qq []*Question) []string {
+	tt := []string{}
+	for _, q := range qq {
+		tt = append(tt, q.Text)
+	}
+	return tt
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// TestQuizStore checks that every store behaves the same for the same calls
+func TestQuizStore(t *testing.T) {
+	cases := []struct {
+		name string
+		run  func(t *testing.T, db QuizStore)
+	}{
+		{"users by email", func(t *testing.T, db QuizStore) {
+			u := newTestUser(t, db, "ann@example.com")
+			got, err := db.GetUserByEmail("ann@example.com")
+			if err != nil || got.ID != u.ID {
+				t.Fatalf("got user %d, %v; want %d", got.ID, err, u.ID)
+			}
+			_, err = db.GetUserByEmail("bob@example.com")
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Fatalf("got %v for a missing user, want ErrRecordNotFound", err)
+			}
+			err = db.CreateUser(&User{Email: "ann@example.com"})
+			if err == nil {
+				t.Fatal("created a second user with the same email")
+			}
+		}},
+		{"quizzes with their owner", func(t *testing.T, db QuizStore) {
+			ann := newTestUser(t, db, "ann@example.com")
+			qz := newTestQuiz(t, db, "Capitals", ann)
+			got, err := db.GetQuiz(qz.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !got.IsOwner(ann.Email) || !got.Private {
+				t.Fatalf("got owner %v and private %v, want the owner of a private quiz", got.IsOwner(ann.Email), got.Private)
+			}
+			byName, err := db.GetQuizByName("Capitals")
+			if err != nil || byName.ID != qz.ID {
+				t.Fatalf("got quiz %d, %v by name; want %d", byName.ID, err, qz.ID)
+			}
+			err = db.CreateQuiz(NewQuiz("Capitals", ann, nil))
+			if err == nil {
+				t.Fatal("created a second quiz with the same name")
+			}
+		}},
+		{"questions in position order", func(t *testing.T, db QuizStore) {
+			ann := newTestUser(t, db, "ann@example.com")
+			a, b, c := NewQuestion(0, "a", "", "", "1", 1, 0), NewQuestion(0, "b", "", "", "2", 1, 0), NewQuestion(0, "c", "", "", "3", 1, 0)
+			qz := newTestQuiz(t, db, "Letters", ann, a, b, c)
+			err := db.SetQuestionPositions(qz.ID, []uint{c.ID, a.ID, b.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			qq, err := db.GetQuestionsByQuiz(qz.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := questionTexts(qq); !equalStrings(got, []string{"c", "a", "b"}) {
+				t.Fatalf("got questions %v, want c a b", got)
+			}
+		}},
+		{"trashed questions come back in place", func(t *testing.T, db QuizStore) {
+			ann := newTestUser(t, db, "ann@example.com")
+			a, b, c := NewQuestion(0, "a", "", "", "1", 1, 0), NewQuestion(0, "b", "", "", "2", 1, 0), NewQuestion(0, "c", "", "", "3", 1, 0)
+			qz := newTestQuiz(t, db, "Letters", ann, a, b, c)
+			err := db.DeleteQuestion(b.ID, qz.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			qq, _ := db.GetQuestionsByQuiz(qz.ID)
+			if got := questionTexts(qq); !equalStrings(got, []string{"a", "c"}) {
+				t.Fatalf("got questions %v after trashing b, want a c", got)
+			}
+			tqs, err := db.GetTrashedQuestions(ann.Email)
+			if err != nil || len(tqs) != 1 || tqs[0].QuestionID != b.ID {
+				t.Fatalf("got %d trashed questions, %v; want b", len(tqs), err)
+			}
+			err = db.RestoreQuestion(b.ID, qz.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			qq, _ = db.GetQuestionsByQuiz(qz.ID)
+			if got := questionTexts(qq); !equalStrings(got, []string{"a", "b", "c"}) {
+				t.Fatalf("got questions %v after restoring b, want a b c", got)
+			}
+		}},
+		{"trashed quizzes", func(t *testing.T, db QuizStore) {
+			ann := newTestUser(t, db, "ann@example.com")
+			qz := newTestQuiz(t, db, "Capitals", ann)
+			err := db.DeleteQuiz(qz.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = db.GetQuiz(qz.ID)
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Fatalf("got %v for a trashed quiz, want ErrRecordNotFound", err)
+			}
+			trashed, err := db.GetTrashedQuizzes(ann.Email)
+			if err != nil || len(trashed) != 1 || trashed[0].ID != qz.ID {
+				t.Fatalf("got %d trashed quizzes, %v; want the deleted one", len(trashed), err)
+			}
+			err = db.RestoreQuiz(qz.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, err = db.GetQuiz(qz.ID)
+			if err != nil {
+				t.Fatalf("restored quiz: %v", err)
+			}
+		}},
+		{"stale play session updates conflict", func(t *testing.T, db QuizStore) {
+			ann := newTestUser(t, db, "ann@example.com")
+			qz := newTestQuiz(t, db, "Capitals", ann, NewQuestion(0, "a", "", "", "1", 1, 0))
+			s := NewPlaySession(ann.Email, qz)
+			err := db.CreatePlaySession(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			first, _ := db.GetPlaySession(s.Code)
+			second, _ := db.GetPlaySession(s.Code)
+			first.SetInProgress()
+			err = db.UpdatePlaySession(first)
+			if err != nil {
+				t.Fatal(err)
+			}
+			second.SetFinished()
+			err = db.UpdatePlaySession(second)
+			if !errors.Is(err, ErrConflict) {
+				t.Fatalf("got %v for a stale update, want ErrConflict", err)
+			}
+			got, _ := db.GetPlaySession(s.Code)
+			if got.State != StateInProgress || got.Version != first.Version {
+				t.Fatalf("got state %s version %d, want %s version %d", got.State, got.Version, StateInProgress, first.Version)
+			}
+		}},
+		{"search results count forks", func(t *testing.T, db QuizStore) {
+			ann := newTestUser(t, db, "ann@example.com")
+			src := newTestQuiz(t, db, "Capitals", ann)
+			fork := newTestQuiz(t, db, "Capitals of Europe", ann)
+			fork.ForkedFromID = &src.ID
+			err := db.UpdateQuiz(fork)
+			if err != nil {
+				t.Fatal(err)
+			}
+			qzs, err := db.SearchQuizzes("capitals", ann.Email, 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			found := false
+			for _, qz := range qzs {
+				if qz.ID == src.ID {
+					found = true
+					if qz.Forks != 1 {
+						t.Fatalf("got %d forks of the original in search results, want 1", qz.Forks)
+					}
+				}
+			}
+			if !found {
+				t.Fatalf("the original isn't among %d search results", len(qzs))
+			}
+		}},
+		{"play sessions in progress", func(t *testing.T, db QuizStore) {
+			ann := newTestUser(t, db, "ann@example.com")
+			qz := newTestQuiz(t, db, "Capitals", ann, NewQuestion(0, "a", "", "", "1", 1, 0))
+			waiting := NewPlaySession(ann.Email, qz)
+			playing := NewPlaySession(ann.Email, qz)
+			playing.Code = waiting.Code + 1
+			playing.SetInProgress()
+			for _, s := range []*PlaySession{waiting, playing} {
+				err := db.CreatePlaySession(s)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			ss, err := db.GetPlaySessionsInProgress()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ss) != 1 || ss[0].Code != playing.Code || ss[0].Quiz == nil {
+				t.Fatalf("got %d sessions in progress, want the one of code %d with its quiz", len(ss), playing.Code)
+			}
+		}},
+		{"submissions replace earlier ones", func(t *testing.T, db QuizStore) {
+			ann := newTestUser(t, db, "ann@example.com")
+			q := NewQuestion(0, "a", "", "", "1", 1, 0)
+			qz := newTestQuiz(t, db, "Capitals", ann, q)
+			s := NewPlaySession(ann.Email, qz)
+			s.AddTeam(NewTeam("Owls"))
+			err := db.CreatePlaySession(s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			team := s.Teams[0]
+			for _, text := range []string{"Paris", "Rome"} {
+				err = db.SaveSubmission(&Submission{PlaySessionID: s.ID, QuestionID: q.ID, TeamID: team.ID, UserID: ann.ID, Text: text})
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			subs, err := db.GetSubmissions(s.ID, q.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(subs) != 1 || subs[0].Text != "Rome" || subs[0].Team == nil || subs[0].Team.Name != "Owls" {
+				t.Fatalf("got %d submissions, want the last answer of Owls", len(subs))
+			}
+		}},
+		{"transactions roll back", func(t *testing.T, db QuizStore) {
+			failed := errors.New("failed")
+			err := db.WithTx(context.Background(), func(tx QuizStore) error {
+				err := tx.CreateUser(&User{Email: "ann@example.com"})
+				if err != nil {
+					return err
+				}
+				return failed
+			})
+			if !errors.Is(err, failed) {
+				t.Fatalf("got %v, want the error of the transaction", err)
+			}
+			_, err = db.GetUserByEmail("ann@example.com")
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				t.Fatalf("got %v, want the user to be rolled back", err)
+			}
+		}},
+	}
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			forEachStore(t, c.run)
+		})
+	}
+}
@@ -0,0 +1,141 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+)
+
+// Choices are the indexes of the options picked for a multiple choice question
+type Choices []int
+
+func (cc Choices) Value() (driver.Value, error) {
+	if cc == nil {
+		return nil, nil
+	}
+	b, err := json.Marshal(cc)
+	return string(b), err
+}
+
+func (cc *Choices) Scan(src interface{}) error {
+	return scanJSON(src, cc)
+}
+
+// MaxAnswerLength is the longest free text answer a team can submit, in characters
+const MaxAnswerLength = 500
+
+// Submission is a team's answer to a question of a play session: Text for free text questions,
+// Choices for multiple choice questions and Number for numeric ones. Teams may change it until
+// answers are locked or the answer is revealed. Multiple choice and numeric answers are graded
+// once on reveal and the points go to the team. Text answers are matched against the accepted
+// answers when they come in and judged by the quizmaster.
+type Submission struct {
+	gorm.Model    `json:"-"`
+	PlaySessionID uint     `gorm:"uniqueIndex:idx_submissions_team" json:"-"`
+	QuestionID    uint     `gorm:"uniqueIndex:idx_submissions_team" json:"question_id"`
+	TeamID        uint     `gorm:"uniqueIndex:idx_submissions_team" json:"team_id"`
+	Team          *Team    `json:"team,omitempty"`
+	UserID        uint     `json:"user_id"`
+	User          *User    `json:"user,omitempty"`
+	Text          string   `json:"text,omitempty"`
+	Choices       Choices  `gorm:"type:text" json:"choices,omitempty"`
+	Number        *float64 `json:"number,omitempty"`
+	Graded        bool     `json:"graded"`
+	Correct       bool     `json:"correct"`
+	Points        int      `json:"points"`
+	// Match pre-marks text answers for the quizmaster, see Question.MatchAnswer. Teams must never
+	// see it, it would let them probe for the answer.
+	Match MatchVerdict `json:"match,omitempty"`
+}
+
+// ForTeam returns a copy of the submission fit to hand back to the team that made it
+func (sub *Submission) ForTeam() *Submission {
+	c := *sub
+	c.Match = ""
+	return &c
+}
+
+// CheckSubmission validates an answer to q before it is accepted, trimming free text answers
+func (q *Question) CheckSubmission(sub *Submission) error {
+	switch q.Type {
+	case QuestionTypeMultipleChoice:
+		if sub.Number != nil || sub.Text != "" {
+			return fmt.Errorf("%w: pick options rather than answering", ErrInvalidSubmission)
+		}
+		return q.CheckChoices(sub.Choices)
+	case QuestionTypeNumeric:
+		if sub.Number == nil || len(sub.Choices) > 0 || sub.Text != "" {
+			return fmt.Errorf("%w: answer with a number", ErrInvalidSubmission)
+		}
+		if math.IsNaN(*sub.Number) || math.IsInf(*sub.Number, 0) {
+			return fmt.Errorf("%w: the number must be finite", ErrInvalidSubmission)
+		}
+		return nil
+	default:
+		sub.Text = strings.TrimSpace(sub.Text)
+		if sub.Text == "" || sub.Number != nil || len(sub.Choices) > 0 {
+			return fmt.Errorf("%w: answer with some text", ErrInvalidSubmission)
+		}
+		if utf8.RuneCountInString(sub.Text) > MaxAnswerLength {
+			return fmt.Errorf("%w: answers can't be longer than %d characters", ErrInvalidSubmission, MaxAnswerLength)
+		}
+		return nil
+	}
+}
+
+// GradeSubmissions grades the answers to q that haven't been graded yet and returns them. Answers
+// graded before still count when finding the closest ones to a numeric question.
+func GradeSubmissions(q *Question, subs []*Submission) (graded []*Submission) {
+	closest := math.Inf(1)
+	if q.IsNumeric() {
+		for _, sub := range subs {
+			if sub.Number != nil {
+				closest = math.Min(closest, math.Abs(*sub.Number-q.Numeric.Target))
+			}
+		}
+	}
+	for _, sub := range subs {
+		if sub.Graded {
+			continue
+		}
+		sub.Graded, sub.Correct, sub.Points = true, false, 0
+		switch {
+		case q.IsMultipleChoice():
+			sub.Correct = q.Grade(sub.Choices)
+			if sub.Correct {
+				sub.Points = q.Worth()
+			}
+		case q.IsNumeric() && sub.Number != nil:
+			sub.Correct, sub.Points = q.Numeric.score(*sub.Number, closest, q.Worth())
+		}
+		graded = append(graded, sub)
+	}
+	return graded
+}
+
+// score grades a number given the distance of the closest answer. Correct means it earned
+// points at all, scaled scoring may award less than worth.
+func (n *NumericAnswer) score(number, closest float64, worth int) (correct bool, points int) {
+	d := math.Abs(number - n.Target)
+	within := d <= n.Tolerance
+	switch n.Scoring {
+	case ScoringClosest:
+		correct = d == closest && (n.Tolerance == 0 || within)
+	case ScoringScaled:
+		if within {
+			points = int(math.Round(float64(worth) * (1 - d/n.Tolerance)))
+		}
+		return points > 0, points
+	default:
+		correct = within
+	}
+	if correct {
+		points = worth
+	}
+	return correct, points
+}
@@ -0,0 +1,158 @@
+package models
+
+import (
+	"errors"
+	"testing"
+)
+
+func multipleChoice(correct ...bool) *Question {
+	q := NewQuestion(0, "Pick", "", "", "", 2, 0)
+	oo := QuestionOptions{}
+	for i, c := range correct {
+		oo = append(oo, QuestionOption{Text: string(rune('A' + i)), Correct: c})
+	}
+	q.SetOptions(oo)
+	return q
+}
+
+func TestCheckChoices(t *testing.T) {
+	single, several := multipleChoice(false, true, false), multipleChoice(true, false, true)
+	cases := []struct {
+		name    string
+		q       *Question
+		choices []int
+		valid   bool
+	}{
+		{"single pick", single, []int{1}, true},
+		{"no pick", single, nil, false},
+		{"two picks of a single answer", single, []int{0, 1}, false},
+		{"several picks", several, []int{0, 2}, true},
+		{"missing option", several, []int{3}, false},
+		{"negative option", several, []int{-1}, false},
+		{"option picked twice", several, []int{0, 0}, false},
+		{"not multiple choice", NewQuestion(0, "Text", "", "", "Paris", 1, 0), []int{0}, false},
+	}
+	for _, c := range cases {
+		err := c.q.CheckChoices(c.choices)
+		if c.valid && err != nil {
+			t.Errorf("%s: got %v, want the pick to be valid", c.name, err)
+		}
+		if !c.valid && !errors.Is(err, ErrInvalidChoices) {
+			t.Errorf("%s: got %v, want ErrInvalidChoices", c.name, err)
+		}
+	}
+}
+
+func TestGradeMultipleChoice(t *testing.T) {
+	q := multipleChoice(true, false, true)
+	subs := []*Submission{
+		{TeamID: 1, Choices: Choices{2, 0}},
+		{TeamID: 2, Choices: Choices{0}},
+		{TeamID: 3, Choices: Choices{0, 1}},
+		{TeamID: 4, Choices: Choices{0, 1, 2}},
+		{TeamID: 5, Choices: Choices{0, 2}, Graded: true, Correct: false},
+	}
+	graded := GradeSubmissions(q, subs)
+	if len(graded) != 4 {
+		t.Fatalf("got %d answers graded, want the 4 ungraded ones", len(graded))
+	}
+	want := map[uint]int{1: 2, 2: 0, 3: 0, 4: 0}
+	for _, sub := range graded {
+		if !sub.Graded || sub.Points != want[sub.TeamID] || sub.Correct != (want[sub.TeamID] > 0) {
+			t.Errorf("team %d: got correct %v and %d points, want %d points", sub.TeamID, sub.Correct, sub.Points, want[sub.TeamID])
+		}
+	}
+	if subs[4].Correct {
+		t.Error("an answer graded before was graded again")
+	}
+}
+
+func TestCheckTextSubmission(t *testing.T) {
+	q := NewQuestion(0, "Capital of France?", "", "", "Paris", 1, 0)
+	sub := &Submission{Text: "  Paris  "}
+	err := q.CheckSubmission(sub)
+	if err != nil || sub.Text != "Paris" {
+		t.Fatalf("got %q, %v; want the trimmed answer", sub.Text, err)
+	}
+	long := make([]rune, MaxAnswerLength+1)
+	for i := range long {
+		long[i] = 'é'
+	}
+	for _, sub := range []*Submission{{Text: "   "}, {Text: string(long)}, {Text: "Paris", Choices: Choices{0}}} {
+		err = q.CheckSubmission(sub)
+		if !errors.Is(err, ErrInvalidSubmission) {
+			t.Errorf("got %v for %.10q, want ErrInvalidSubmission", err, sub.Text)
+		}
+	}
+}
+
+func numeric(n NumericAnswer) *Question {
+	q := NewQuestion(0, "How many?", "", "", "", 10, 0)
+	q.SetNumeric(n)
+	return q
+}
+
+func TestGradeNumeric(t *testing.T) {
+	cases := []struct {
+		name    string
+		answer  NumericAnswer
+		numbers []float64
+		points  []int
+	}{
+		{"exact", NumericAnswer{Target: 42}, []float64{42, 41.9, 43}, []int{10, 0, 0}},
+		{"exact within a tolerance", NumericAnswer{Target: 42, Tolerance: 2}, []float64{40, 44, 44.5}, []int{10, 10, 0}},
+		{"closest", NumericAnswer{Target: 100, Scoring: ScoringClosest}, []float64{90, 120, 110}, []int{10, 0, 10}},
+		{"closest within a tolerance", NumericAnswer{Target: 100, Tolerance: 5, Scoring: ScoringClosest}, []float64{90, 120}, []int{0, 0}},
+		{"scaled", NumericAnswer{Target: 100, Tolerance: 10, Scoring: ScoringScaled}, []float64{100, 95, 104, 110, 80}, []int{10, 5, 6, 0, 0}},
+	}
+	for _, c := range cases {
+		subs := []*Submission{}
+		for i := range c.numbers {
+			subs = append(subs, &Submission{TeamID: uint(i + 1), Number: &c.numbers[i]})
+		}
+		GradeSubmissions(numeric(c.answer), subs)
+		for i, sub := range subs {
+			if sub.Points != c.points[i] || sub.Correct != (c.points[i] > 0) {
+				t.Errorf("%s: %v got correct %v and %d points, want %d points", c.name, c.numbers[i], sub.Correct, sub.Points, c.points[i])
+			}
+		}
+	}
+}
+
+func TestGradeNumericLateAnswers(t *testing.T) {
+	// The closest answer was graded on an earlier reveal, it still beats the late ones
+	q := numeric(NumericAnswer{Target: 100, Scoring: ScoringClosest})
+	early, late := 99.0, 98.0
+	subs := []*Submission{
+		{TeamID: 1, Number: &early, Graded: true, Correct: true, Points: 10},
+		{TeamID: 2, Number: &late},
+	}
+	graded := GradeSubmissions(q, subs)
+	if len(graded) != 1 || graded[0].TeamID != 2 || graded[0].Correct {
+		t.Fatalf("got %d answers graded, want the late one graded wrong", len(graded))
+	}
+}
+
+func TestValidateNumeric(t *testing.T) {
+	cases := []struct {
+		name   string
+		answer NumericAnswer
+		valid  bool
+	}{
+		{"exact", NumericAnswer{Target: 1}, true},
+		{"negative tolerance", NumericAnswer{Target: 1, Tolerance: -1}, false},
+		{"scaled without a tolerance", NumericAnswer{Target: 1, Scoring: ScoringScaled}, false},
+		{"unknown scoring", NumericAnswer{Target: 1, Scoring: "nearest"}, false},
+	}
+	for _, c := range cases {
+		q := numeric(c.answer)
+		q.Answer = "1"
+		err := q.Validate()
+		if c.valid && err != nil {
+			t.Errorf("%s: got %v, want the question to be valid", c.name, err)
+		}
+		if !c.valid && !errors.Is(err, ErrInvalidNumeric) {
+			t.Errorf("%s: got %v, want ErrInvalidNumeric", c.name, err)
+		}
+	}
+}
@@ -1,8 +1,56 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"strings"
+
+	"gorm.io/gorm"
+)
 
 type Tag struct {
 	gorm.Model `json:"-"`
-	Name       string `json:"name,omitempty"`
+	Name       string `gorm:"uniqueIndex" json:"name,omitempty"`
+}
+
+// TagUsage is a tag with the number of public quizzes and questions carrying it
+type TagUsage struct {
+	Name      string `json:"name"`
+	Quizzes   int64  `json:"quizzes"`
+	Questions int64  `json:"questions"`
+}
+
+// TagSynonyms maps normalized tag names onto the name they are stored under
+var TagSynonyms = map[string]string{
+	"sci-fi":      "science fiction",
+	"scifi":       "science fiction",
+	"maths":       "math",
+	"mathematics": "math",
+	"film":        "movies",
+	"films":       "movies",
+	"movie":       "movies",
+	"tv":          "television",
+	"geo":         "geography",
+}
+
+// NormalizeTagName lowercases a tag name, collapses its whitespace and resolves synonyms
+func NormalizeTagName(name string) string {
+	n := strings.Join(strings.Fields(strings.ToLower(name)), " ")
+	if s, ok := TagSynonyms[n]; ok {
+		return s
+	}
+	return n
+}
+
+// NormalizeTagNames normalizes every name, dropping empty ones and duplicates
+func NormalizeTagNames(names []string) []string {
+	nn := []string{}
+	seen := map[string]bool{}
+	for _, name := range names {
+		n := NormalizeTagName(name)
+		if n == "" || seen[n] {
+			continue
+		}
+		seen[n] = true
+		nn = append(nn, n)
+	}
+	return nn
 }
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestTagUsage(t *testing.T) {
+	forEachStore(t, func(t *testing.T, db QuizStore) {
+		ann := newTestUser(t, db, "ann@example.com")
+		capital := NewQuestion(0, "Capital of France?", "", "", "Paris", 1, 0)
+		public := newTestQuiz(t, db, "Capitals", ann, capital)
+		public.TogglePrivacy()
+		err := db.UpdateQuiz(public)
+		if err != nil {
+			t.Fatal(err)
+		}
+		secret := NewQuestion(0, "Ann's middle name?", "", "", "Jane", 1, 0)
+		private := newTestQuiz(t, db, "Family", ann, secret)
+
+		tag := func(name string) []*Tag {
+			tg, err := db.GetTagByName(name)
+			if err != nil {
+				return []*Tag{{Name: name}}
+			}
+			return []*Tag{tg}
+		}
+		for _, err := range []error{
+			db.AddQuizTags(public.ID, tag("geography")),
+			db.AddQuestionTags(capital.ID, tag("cities")),
+			db.AddQuestionTags(capital.ID, tag("geography")),
+			db.AddQuizTags(private.ID, tag("family")),
+			db.AddQuestionTags(secret.ID, tag("names")),
+			db.AddQuestionTags(secret.ID, tag("cities")),
+		} {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		tus, err := db.GetTagUsage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := []TagUsage{{Name: "geography", Quizzes: 1, Questions: 1}, {Name: "cities", Questions: 1}}
+		if len(tus) != len(want) {
+			t.Fatalf("got %d tags in use, want %d", len(tus), len(want))
+		}
+		for i, tu := range tus {
+			if *tu != want[i] {
+				t.Errorf("got tag usage %+v, want %+v", *tu, want[i])
+			}
+		}
+
+		// Trashing the public quiz leaves nothing public
+		err = db.DeleteQuiz(public.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tus, err = db.GetTagUsage()
+		if err != nil || len(tus) != 0 {
+			t.Fatalf("got %d tags in use, %v; want none", len(tus), err)
+		}
+	})
+}
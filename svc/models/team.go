@@ -7,6 +7,10 @@ type Team struct {
 	Name   string  `json:"name"`
 	Users  []*User `gorm:"many2many:user_teams" json:"users"`
 	Points int     `json:"points"`
+	// RoundPoints splits Points by the round they were scored in
+	RoundPoints RoundPoints `gorm:"type:text" json:"round_points,omitempty"`
+	// Version is bumped on every update, stale updates fail with a ConflictError
+	Version uint `gorm:"not null;default:0" json:"version"`
 }
 
 func NewTeam(name string) *Team {
@@ -18,3 +22,16 @@ func NewTeam(name string) *Team {
 func (t *Team) AddPoints(points int) {
 	t.Points += points
 }
+
+// AddRoundPoints adds points scored in round r, nil outside of rounds, to the team's total
+func (t *Team) AddRoundPoints(r *Round, points int) {
+	t.AddPoints(points)
+	if t.RoundPoints == nil {
+		t.RoundPoints = RoundPoints{}
+	}
+	var id uint
+	if r != nil {
+		id = r.ID
+	}
+	t.RoundPoints[id] += points
+}
@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// TrashedQuestion remembers a question that was removed from a quiz, so it can be put back
+// where it was until the trash is purged
+type TrashedQuestion struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	QuizID     uint      `gorm:"index" json:"quiz_id"`
+	QuestionID uint      `json:"question_id"`
+	Question   *Question `json:"question,omitempty"`
+	Position   int       `json:"position"`
+	RoundID    *uint     `json:"round_id,omitempty"`
+	TrashedAt  time.Time `gorm:"index" json:"trashed_at"`
+}
+
+// Trash is everything a user can still restore
+type Trash struct {
+	Quizzes   []*Quiz            `json:"quizzes"`
+	Questions []*TrashedQuestion `json:"questions"`
+}
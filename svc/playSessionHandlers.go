@@ -1,7 +1,9 @@
 package svc
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/gorilla/mux"
 	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
 )
 
 func (s *QServer) UserContextKey() contextKey {
@@ -36,11 +39,11 @@ func (s *QServer) CreatePS() http.HandlerFunc {
 		}
 		ps, err := s.hub.InitNewPS(req.Context(), r.QuizID)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
 		// Create a new wsHub for the playSession
-		s.wsHubs[ps.Code] = newHub()
+		s.hubFor(ps.Code, true)
 		resp := Response{}
 		resp.PlaySession = ps
 		s.respond(w, req, resp, http.StatusOK, nil)
@@ -68,7 +71,7 @@ func (s *QServer) GetPS() http.HandlerFunc {
 		resp := Response{}
 		ps, err := s.hub.GetPS(req.Context(), r.Code)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
 		resp.PlaySession = ps
@@ -76,6 +79,27 @@ func (s *QServer) GetPS() http.HandlerFunc {
 	}
 }
 
+// GetPSScores returns the points of the teams round by round
+func (s *QServer) GetPSScores() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Scoreboard *models.Scoreboard `json:"scoreboard,omitempty"`
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		sb, err := s.hub.GetPSScores(req.Context(), uint(id))
+		if err != nil {
+			s.respondPSErr(w, req, err)
+			return
+		}
+		s.respond(w, req, Response{Scoreboard: sb}, http.StatusOK, nil)
+	}
+}
+
 func (s *QServer) JoinPS() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		type Request struct {
@@ -93,13 +117,10 @@ func (s *QServer) JoinPS() http.HandlerFunc {
 		r.Code = uint(id)
 		err = s.hub.AddUserToPS(req.Context(), r.Code)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
-			s.wsHubs[r.Code] = newHub()
-		}
-		s.wsHubs[r.Code].BroadcastReload()
+		s.hubFor(r.Code, true).BroadcastReload()
 		s.respond(w, req, nil, http.StatusNoContent, nil)
 	}
 }
@@ -129,13 +150,10 @@ func (s *QServer) AddTeam() http.HandlerFunc {
 		team := models.NewTeam(r.TeamName)
 		err = s.hub.AddTeamToPS(req.Context(), r.Code, team)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
-			s.wsHubs[r.Code] = newHub()
-		}
-		s.wsHubs[r.Code].BroadcastReload()
+		s.hubFor(r.Code, true).BroadcastReload()
 		s.respond(w, req, nil, http.StatusNoContent, nil)
 	}
 }
@@ -165,13 +183,10 @@ func (s *QServer) AddUserToTeam() http.HandlerFunc {
 		r.Code = uint(id)
 		err = s.hub.AddUserToTeam(req.Context(), r.Code, r.TeamName, r.Email)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
-			s.wsHubs[r.Code] = newHub()
-		}
-		s.wsHubs[r.Code].BroadcastReload()
+		s.hubFor(r.Code, true).BroadcastReload()
 		s.respond(w, req, nil, http.StatusNoContent, nil)
 	}
 }
@@ -193,13 +208,10 @@ func (s *QServer) StartPS() http.HandlerFunc {
 		r.Code = uint(id)
 		err = s.hub.StartPS(req.Context(), r.Code)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
-			s.wsHubs[r.Code] = newHub()
-		}
-		s.wsHubs[r.Code].BroadcastReload()
+		s.hubFor(r.Code, true).BroadcastReload()
 		s.respond(w, req, nil, http.StatusNoContent, nil)
 	}
 }
@@ -221,23 +233,21 @@ func (s *QServer) EndPS() http.HandlerFunc {
 		r.Code = uint(id)
 		err = s.hub.EndPlaySession(req.Context(), r.Code)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
+		h := s.hubFor(r.Code, false)
+		if h == nil {
 			s.respond(w, req, nil, http.StatusNoContent, nil)
 			return
 		}
-		s.wsHubs[r.Code].BroadcastReload()
+		h.BroadcastReload()
 		// Give it a few seconds
 		time.Sleep(3 * time.Second)
 
 		// CleanUp Connections and delete Hub
-		for c := range s.wsHubs[r.Code].connections {
-			s.logger.Log("msg", "Closing WS Connections", "ps-code", r.Code)
-			s.wsHubs[r.Code].removeConnection(c)
-		}
-		delete(s.wsHubs, r.Code)
+		s.dropHub(r.Code)
+		s.logger.Log("msg", "Closing WS Connections", "ps-code", r.Code, "connections", h.removeConnections())
 		s.logger.Log("msg", "Deleted Websocket Session", "ps-code", r.Code)
 
 		s.respond(w, req, nil, http.StatusNoContent, nil)
@@ -261,13 +271,34 @@ func (s *QServer) IncrementPSQuestion() http.HandlerFunc {
 		r.Code = uint(id)
 		err = s.hub.IncrementPSQuestion(req.Context(), r.Code)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
-			s.wsHubs[r.Code] = newHub()
+		s.hubFor(r.Code, true).BroadcastReload()
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+// NextPSRound skips the rest of the current round
+func (s *QServer) NextPSRound() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Code uint `json:"code,omitempty"`
+		}
+		r := Request{}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
 		}
-		s.wsHubs[r.Code].BroadcastReload()
+		r.Code = uint(id)
+		err = s.hub.NextPSRound(req.Context(), r.Code)
+		if err != nil {
+			s.respondPSErr(w, req, err)
+			return
+		}
+		s.hubFor(r.Code, true).BroadcastReload()
 		s.respond(w, req, nil, http.StatusNoContent, nil)
 	}
 }
@@ -289,13 +320,10 @@ func (s *QServer) DecrementPSQuestion() http.HandlerFunc {
 		r.Code = uint(id)
 		err = s.hub.DecrementPSQuestion(req.Context(), r.Code)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
-			s.wsHubs[r.Code] = newHub()
-		}
-		s.wsHubs[r.Code].BroadcastReload()
+		s.hubFor(r.Code, true).BroadcastReload()
 		s.respond(w, req, nil, http.StatusNoContent, nil)
 	}
 }
@@ -325,13 +353,10 @@ func (s *QServer) AddPSTeamPoints() http.HandlerFunc {
 		r.Code = uint(id)
 		err = s.hub.UpdateTeamPoints(req.Context(), r.Code, r.Points, r.TeamName)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
-			s.wsHubs[r.Code] = newHub()
-		}
-		s.wsHubs[r.Code].BroadcastReload()
+		s.hubFor(r.Code, true).BroadcastReload()
 		s.respond(w, req, nil, http.StatusNoContent, nil)
 	}
 }
@@ -364,10 +389,7 @@ func (s *QServer) BroadcastChatMessage() http.HandlerFunc {
 		if err != nil {
 			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad User Supplied"))
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
-			s.wsHubs[r.Code] = newHub()
-		}
-		s.wsHubs[r.Code].BroadcastChat(u.Name, r.Message)
+		s.hubFor(r.Code, true).BroadcastChat(u.Name, r.Message)
 		s.respond(w, req, nil, http.StatusNoContent, nil)
 	}
 }
@@ -389,13 +411,10 @@ func (s *QServer) RevealPSCurrentAnswer() http.HandlerFunc {
 		r.Code = uint(id)
 		err = s.hub.RevealPSCurrentAnswer(req.Context(), r.Code)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondPSErr(w, req, err)
 			return
 		}
-		if _, ok := s.wsHubs[r.Code]; !ok {
-			s.wsHubs[r.Code] = newHub()
-		}
-		s.wsHubs[r.Code].BroadcastReload()
+		s.hubFor(r.Code, true).BroadcastReload()
 		s.respond(w, req, nil, http.StatusNoContent, nil)
 	}
 }
@@ -414,7 +433,8 @@ func (s *QServer) WebSocketPS() http.HandlerFunc {
 			return
 		}
 		r.Code = uint(id)
-		if _, ok := s.wsHubs[r.Code]; !ok {
+		h := s.hubFor(r.Code, false)
+		if h == nil {
 			s.logger.Log("msg", "Attempted WS Connection to non-existant hub")
 			return
 		}
@@ -423,13 +443,394 @@ func (s *QServer) WebSocketPS() http.HandlerFunc {
 			s.logger.Log("msg", "Failed to upgrade WS", "err", err)
 			return
 		}
-		c := &connection{send: make(chan []byte, 256), h: s.wsHubs[r.Code]}
+		c := &connection{send: make(chan []byte, 256), h: h}
 		c.h.addConnection(c)
 		defer c.h.removeConnection(c)
 		var wg sync.WaitGroup
 		wg.Add(2)
 		go c.writer(&wg, wsConn)
+		go c.reader(&wg, wsConn, func(message []byte) {
+			s.handleWSMessage(req.Context(), c, r.Code, message)
+		})
 		wg.Wait()
 		wsConn.Close()
 	}
 }
+
+// handleWSMessage acts on a message a client sends over the session websocket. Players buzz in
+// with {"action": "buzz"} and answer the current question with {"action": "answer"} carrying the
+// text, choices or number of the answer API. Anything else is ignored.
+func (s *QServer) handleWSMessage(ctx context.Context, c *connection, code uint, message []byte) {
+	m := struct {
+		Action  string   `json:"action"`
+		Text    string   `json:"text,omitempty"`
+		Choices []int    `json:"choices,omitempty"`
+		Number  *float64 `json:"number,omitempty"`
+	}{}
+	if json.Unmarshal(message, &m) != nil {
+		return
+	}
+	replyErr := func(action string, err error) {
+		errBytes, _ := json.Marshal(map[string]string{"action": action, "message": err.Error()})
+		c.reply(errBytes)
+	}
+	switch m.Action {
+	case "buzz":
+		b, first, err := s.hub.BuzzPS(ctx, code)
+		if err != nil {
+			replyErr("buzz_error", err)
+			return
+		}
+		if first {
+			c.h.BroadcastBuzz("buzz", b)
+		}
+	case "answer":
+		sub, err := s.hub.SubmitPSAnswer(ctx, code, &models.Submission{Text: m.Text, Choices: m.Choices, Number: m.Number})
+		if err != nil {
+			replyErr("answer_error", err)
+			return
+		}
+		savedBytes, _ := json.Marshal(map[string]interface{}{"action": "answer_saved", "submission": sub})
+		c.reply(savedBytes)
+		c.h.BroadcastAnswerSubmitted(sub)
+	}
+}
+
+// respondPSErr maps the errors of the play session endpoints onto status codes. A session changed by
+// someone else in the meantime is a conflict worth retrying, one whose question is gone or whose quiz
+// has none is a conflict the quizmaster has to sort out.
+func (s *QServer) respondPSErr(w http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, models.ErrConflict) || errors.Is(err, NoCurrentQuestionError) || errors.Is(err, NoQuestionsError) {
+		s.respond(w, req, nil, http.StatusConflict, err)
+		return
+	}
+	s.respond(w, req, nil, http.StatusInternalServerError, err)
+}
+
+// respondBuzzErr maps the errors of the quizmaster's buzzer endpoints onto status codes
+func (s *QServer) respondBuzzErr(w http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, NotPermittedError):
+		s.respond(w, req, nil, http.StatusForbidden, err)
+	case errors.Is(err, NoBuzzError), errors.Is(err, BuzzersLockedError), errors.Is(err, NoCurrentQuestionError), errors.Is(err, models.ErrConflict):
+		s.respond(w, req, nil, http.StatusConflict, err)
+	default:
+		s.respond(w, req, nil, http.StatusInternalServerError, err)
+	}
+}
+
+// AcceptPSBuzz gives the current question to the first team that buzzed in and locks the buzzers
+func (s *QServer) AcceptPSBuzz() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Buzz *models.Buzz `json:"buzz,omitempty"`
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		code := uint(id)
+		b, err := s.hub.AcceptPSBuzz(req.Context(), code)
+		if err != nil {
+			s.respondBuzzErr(w, req, err)
+			return
+		}
+		s.hubFor(code, true).BroadcastBuzz("buzz_accepted", b)
+		s.respond(w, req, Response{Buzz: b}, http.StatusOK, nil)
+	}
+}
+
+// RejectPSBuzz turns down the first team that buzzed in, the next one in line gets announced
+func (s *QServer) RejectPSBuzz() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Next *models.Buzz `json:"next,omitempty"`
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		code := uint(id)
+		rejected, next, err := s.hub.RejectPSBuzz(req.Context(), code)
+		if err != nil {
+			s.respondBuzzErr(w, req, err)
+			return
+		}
+		h := s.hubFor(code, true)
+		if next != nil {
+			h.BroadcastBuzz("buzz", next)
+		} else {
+			h.BroadcastBuzzersCleared(rejected.QuestionID)
+		}
+		s.respond(w, req, Response{Next: next}, http.StatusOK, nil)
+	}
+}
+
+// GetPSBuzzes returns the buzz history of a play session
+func (s *QServer) GetPSBuzzes() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Buzzes []*models.Buzz `json:"buzzes"`
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		bb, err := s.hub.GetPSBuzzes(req.Context(), uint(id))
+		if err != nil {
+			s.respondBuzzErr(w, req, err)
+			return
+		}
+		s.respond(w, req, Response{Buzzes: bb}, http.StatusOK, nil)
+	}
+}
+
+// respondSubmissionErr maps the errors of answer submissions onto status codes
+func (s *QServer) respondSubmissionErr(w http.ResponseWriter, req *http.Request, err error) {
+	switch {
+	case errors.Is(err, models.ErrInvalidSubmission):
+		s.respond(w, req, nil, http.StatusBadRequest, err)
+	case errors.Is(err, NotInTeamError), errors.Is(err, NotPermittedError):
+		s.respond(w, req, nil, http.StatusForbidden, err)
+	case errors.Is(err, AnswersClosedError), errors.Is(err, TimeUpError), errors.Is(err, NoCurrentQuestionError), errors.Is(err, models.ErrConflict):
+		s.respond(w, req, nil, http.StatusConflict, err)
+	default:
+		s.respond(w, req, nil, http.StatusInternalServerError, err)
+	}
+}
+
+func (s *QServer) SubmitPSChoices() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Choices []int `json:"choices,omitempty"`
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		params := mux.Vars(req)
+		idStr := params["code"]
+		var id int
+		id, err = strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		err = s.hub.SubmitPSChoices(req.Context(), uint(id), r.Choices)
+		if err != nil {
+			s.respondSubmissionErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+func (s *QServer) SubmitPSNumber() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Number *float64 `json:"number,omitempty"`
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		if r.Number == nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("You must supply a number"))
+			return
+		}
+		params := mux.Vars(req)
+		idStr := params["code"]
+		var id int
+		id, err = strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		err = s.hub.SubmitPSNumber(req.Context(), uint(id), *r.Number)
+		if err != nil {
+			s.respondSubmissionErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+// SubmitPSAnswer takes the answer of the user's team to the current question, as text, choices or
+// a number depending on the question. Everyone is told the team answered, not what it answered.
+func (s *QServer) SubmitPSAnswer() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Text    string   `json:"text,omitempty"`
+			Choices []int    `json:"choices,omitempty"`
+			Number  *float64 `json:"number,omitempty"`
+		}
+		type Response struct {
+			Submission *models.Submission `json:"submission,omitempty"`
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		code := uint(id)
+		sub, err := s.hub.SubmitPSAnswer(req.Context(), code, &models.Submission{Text: r.Text, Choices: r.Choices, Number: r.Number})
+		if err != nil {
+			s.respondSubmissionErr(w, req, err)
+			return
+		}
+		s.hubFor(code, true).BroadcastAnswerSubmitted(sub)
+		s.respond(w, req, Response{Submission: sub}, http.StatusOK, nil)
+	}
+}
+
+// LockPSAnswers stops taking answers to the current question
+func (s *QServer) LockPSAnswers() http.HandlerFunc {
+	return s.setPSAnswersLocked(true)
+}
+
+// UnlockPSAnswers takes answers to the current question again
+func (s *QServer) UnlockPSAnswers() http.HandlerFunc {
+	return s.setPSAnswersLocked(false)
+}
+
+func (s *QServer) setPSAnswersLocked(locked bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		code := uint(id)
+		err = s.hub.SetPSAnswersLocked(req.Context(), code, locked)
+		if err != nil {
+			s.respondSubmissionErr(w, req, err)
+			return
+		}
+		s.hubFor(code, true).BroadcastReload()
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+// GetPSSubmissions lists the answers to the current question for the quizmaster
+func (s *QServer) GetPSSubmissions() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Submissions []*models.Submission `json:"submissions"`
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		subs, err := s.hub.GetPSSubmissions(req.Context(), uint(id))
+		if err != nil {
+			s.respondSubmissionErr(w, req, err)
+			return
+		}
+		s.respond(w, req, Response{Submissions: subs}, http.StatusOK, nil)
+	}
+}
+
+// JudgePSSubmission takes the quizmaster's verdict on the text answer of a team
+func (s *QServer) JudgePSSubmission() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			TeamID  uint  `json:"team_id,omitempty"`
+			Correct *bool `json:"correct,omitempty"`
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		if r.TeamID == 0 || r.Correct == nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("You must supply the team and whether its answer is correct"))
+			return
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		code := uint(id)
+		err = s.hub.JudgePSSubmission(req.Context(), code, r.TeamID, *r.Correct)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				s.respond(w, req, nil, http.StatusNotFound, err)
+				return
+			}
+			s.respondSubmissionErr(w, req, err)
+			return
+		}
+		s.hubFor(code, true).BroadcastReload()
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+// SetPSAutoReveal turns revealing answers as soon as the timer of a question runs out on or off
+func (s *QServer) SetPSAutoReveal() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			AutoReveal bool `json:"auto_reveal"`
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["code"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad Code supplied"))
+			return
+		}
+		code := uint(id)
+		err = s.hub.SetPSAutoReveal(req.Context(), code, r.AutoReveal)
+		if err != nil {
+			s.respondSubmissionErr(w, req, err)
+			return
+		}
+		s.hubFor(code, true).BroadcastReload()
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+// broadcastTimer relays the ticks and the expiry of question timers to the players of the session.
+// Sessions nobody is connected to are skipped.
+func (s *QServer) broadcastTimer(e TimerEvent) {
+	h := s.hubFor(e.Code, false)
+	if h == nil {
+		return
+	}
+	h.BroadcastTimer(e)
+	if e.Revealed {
+		h.BroadcastReload()
+	}
+}
@@ -2,10 +2,23 @@ package svc
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
 
 	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
 )
 
+var NotInTeamError = errors.New("User is not in a team of the play session")
+var AnswersClosedError = errors.New("Answers are closed for the current question")
+var BuzzersLockedError = errors.New("Buzzers are locked for the current question")
+var AlreadyBuzzedError = errors.New("The team already buzzed on the current question")
+var NoBuzzError = errors.New("Nobody is waiting to answer the current question")
+var TimeUpError = errors.New("Time is up for the current question")
+var NoCurrentQuestionError = errors.New("The current question of the play session is gone from its quiz")
+
 type PlaySessionSVC interface {
 	InitNewPS(ctx context.Context, quizID uint) (s *models.PlaySession, err error)
 	StartPS(ctx context.Context, code uint) (err error)
@@ -14,22 +27,65 @@ type PlaySessionSVC interface {
 	AddTeamToPS(ctx context.Context, code uint, team *models.Team) (err error)
 	IncrementPSQuestion(ctx context.Context, code uint) (err error)
 	DecrementPSQuestion(ctx context.Context, code uint) (err error)
+	NextPSRound(ctx context.Context, code uint) (err error)
 	RevealPSCurrentAnswer(ctx context.Context, code uint) (err error)
+	SubmitPSChoices(ctx context.Context, code uint, choices []int) (err error)
+	SubmitPSNumber(ctx context.Context, code uint, number float64) (err error)
+	SubmitPSAnswer(ctx context.Context, code uint, answer *models.Submission) (sub *models.Submission, err error)
+	SetPSAnswersLocked(ctx context.Context, code uint, locked bool) (err error)
+	SetPSAutoReveal(ctx context.Context, code uint, on bool) (err error)
+	OnQuestionTimer(notify func(e TimerEvent))
+	RearmPSTimers() (err error)
+	StartPSAutopilot(ctx context.Context, code uint, p models.AutopilotPauses) (err error)
+	PausePSAutopilot(ctx context.Context, code uint) (err error)
+	ResumePSAutopilot(ctx context.Context, code uint) (err error)
+	TakeOverPS(ctx context.Context, code uint) (err error)
+	OnAutopilot(notify func(e AutopilotEvent))
+	GetPSSubmissions(ctx context.Context, code uint) (subs []*models.Submission, err error)
+	JudgePSSubmission(ctx context.Context, code uint, teamID uint, correct bool) (err error)
 	GetPS(ctx context.Context, code uint) (s *models.PlaySession, err error)
+	GetPSScores(ctx context.Context, code uint) (sb *models.Scoreboard, err error)
+	BuzzPS(ctx context.Context, code uint) (b *models.Buzz, first bool, err error)
+	AcceptPSBuzz(ctx context.Context, code uint) (b *models.Buzz, err error)
+	RejectPSBuzz(ctx context.Context, code uint) (rejected, next *models.Buzz, err error)
+	GetPSBuzzes(ctx context.Context, code uint) (bb []*models.Buzz, err error)
 	UpdateTeamPoints(ctx context.Context, code uint, points int, teamName string) (err error)
 	EndPlaySession(ctx context.Context, code uint) (err error)
 }
 
 type PlaySessionSvc struct {
-	db models.QuizStore
+	db     models.QuizStore
+	timers *sessionTimers
+	pilots *sessionTimers
+
+	notifyMx    sync.Mutex
+	onTimer     func(e TimerEvent)
+	onAutopilot func(e AutopilotEvent)
 }
 
 func NewPlaySessionSvc(db models.QuizStore) *PlaySessionSvc {
 	return &PlaySessionSvc{
-		db: db,
+		db:     db,
+		timers: newSessionTimers(),
+		pilots: newSessionTimers(),
 	}
 }
 
+// maxConflictRetries is how often an operation is replayed after losing a race on a version check
+const maxConflictRetries = 5
+
+// withRetry runs fn in a transaction. When fn loses an optimistic locking race the whole
+// read-modify-write is replayed against fresh data, up to maxConflictRetries times.
+func (ps *PlaySessionSvc) withRetry(ctx context.Context, fn func(db models.QuizStore) error) (err error) {
+	for i := 0; i < maxConflictRetries; i++ {
+		err = ps.db.WithTx(ctx, fn)
+		if !errors.Is(err, models.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
 func (ps *PlaySessionSvc) UserContextKey() contextKey {
 	var userContextKey = contextKey("user")
 	return userContextKey
@@ -40,20 +96,22 @@ func (ps *PlaySessionSvc) InitNewPS(ctx context.Context, quizID uint) (s *models
 	if err != nil {
 		return s, err
 	}
-	u, err = ps.db.GetUserByEmail(u.Email)
-	if err != nil {
-		return s, err
-	}
-	// Get Quiz
-	qz, err := ps.db.GetQuiz(quizID)
-	if err != nil {
-		return s, err
-	}
-	if !qz.CanView(u.Email) {
-		return s, NotPermittedError
-	}
-	s = models.NewPlaySession(u.Email, qz)
-	err = ps.db.CreatePlaySession(s)
+	err = ps.db.WithTx(ctx, func(db models.QuizStore) error {
+		u, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
+		// Get Quiz
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanView(u.Email) {
+			return NotPermittedError
+		}
+		s = models.NewPlaySession(u.Email, qz)
+		return db.CreatePlaySession(s)
+	})
 	if err != nil {
 		return s, err
 	}
@@ -65,16 +123,39 @@ func (ps *PlaySessionSvc) StartPS(ctx context.Context, code uint) (err error) {
 	if err != nil {
 		return err
 	}
-	s, err := ps.db.GetPlaySession(code)
+	var s *models.PlaySession
+	err = ps.withRetry(ctx, func(db models.QuizStore) (err error) {
+		s, err = db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		qqs, err := db.GetQuestionsByQuiz(s.Quiz.ID)
+		if err != nil {
+			return err
+		}
+		if len(qqs) == 0 {
+			return NoQuestionsError
+		}
+		s.SetInProgress()
+		index := s.CurrentQuestionIndex
+		if index >= len(qqs) {
+			index = len(qqs) - 1
+		}
+		err = goToQuestion(db, s, qqs, index)
+		if err != nil {
+			return err
+		}
+		return db.UpdatePlaySession(s)
+	})
 	if err != nil {
 		return err
 	}
-	if s.QuizMaster != u.Email {
-		return NotPermittedError
-	}
-	s.SetInProgress()
-
-	return ps.db.UpdatePlaySession(s)
+	ps.syncTimer(s)
+	ps.syncAutopilot(s)
+	return nil
 }
 
 func (ps *PlaySessionSvc) EndPlaySession(ctx context.Context, code uint) (err error) {
@@ -82,16 +163,26 @@ func (ps *PlaySessionSvc) EndPlaySession(ctx context.Context, code uint) (err er
 	if err != nil {
 		return err
 	}
-	s, err := ps.db.GetPlaySession(code)
+	err = ps.withRetry(ctx, func(db models.QuizStore) error {
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		s.SetFinished()
+		s.StopTimer()
+		s.TakeOver()
+
+		return db.UpdatePlaySession(s)
+	})
 	if err != nil {
 		return err
 	}
-	if s.QuizMaster != u.Email {
-		return NotPermittedError
-	}
-	s.SetFinished()
-
-	return ps.db.UpdatePlaySession(s)
+	ps.timers.stop(code)
+	ps.pilots.stop(code)
+	return nil
 }
 
 func (ps *PlaySessionSvc) IncrementPSQuestion(ctx context.Context, code uint) (err error) {
@@ -99,50 +190,170 @@ func (ps *PlaySessionSvc) IncrementPSQuestion(ctx context.Context, code uint) (e
 	if err != nil {
 		return err
 	}
-	s, err := ps.db.GetPlaySession(code)
+	var s *models.PlaySession
+	err = ps.withRetry(ctx, func(db models.QuizStore) (err error) {
+		s, err = db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		// Increment Question
+		qqs, err := db.GetQuestionsByQuiz(s.Quiz.ID)
+		if err != nil {
+			return err
+		}
+
+		index := s.CurrentQuestionIndex
+		if index < len(qqs)-1 {
+			index += 1
+		} else if index >= len(qqs) {
+			// Questions were taken out of the quiz, land on the last one left
+			index = len(qqs) - 1
+		}
+		s.TakeOver()
+		err = goToQuestion(db, s, qqs, index)
+		if err != nil {
+			return err
+		}
+		return db.UpdatePlaySession(s)
+	})
 	if err != nil {
 		return err
 	}
-	if s.QuizMaster != u.Email {
-		return NotPermittedError
-	}
-	// Increment Question
-	qqs, err := ps.db.GetQuestionsByQuiz(s.Quiz.ID)
+	ps.syncTimer(s)
+	ps.syncAutopilot(s)
+	return nil
+}
+
+func (ps *PlaySessionSvc) DecrementPSQuestion(ctx context.Context, code uint) (err error) {
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
-
-	if s.CurrentQuestionIndex < len(qqs)-1 {
-		s.CurrentQuestionIndex += 1
+	var s *models.PlaySession
+	err = ps.withRetry(ctx, func(db models.QuizStore) (err error) {
+		s, err = db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		// Increment Question
+		qqs, err := db.GetQuestionsByQuiz(s.Quiz.ID)
+		if err != nil {
+			return err
+		}
+		index := s.CurrentQuestionIndex
+		if index > len(qqs) {
+			// Questions were taken out of the quiz, land on the last one left
+			index = len(qqs)
+		}
+		if index > 0 {
+			index -= 1
+		}
+		s.TakeOver()
+		err = goToQuestion(db, s, qqs, index)
+		if err != nil {
+			return err
+		}
+		return db.UpdatePlaySession(s)
+	})
+	if err != nil {
+		return err
 	}
-	s.UpdateQuestion(qqs[s.CurrentQuestionIndex])
-	s.ClearCurrentAnswer()
-	return ps.db.UpdatePlaySession(s)
+	ps.syncTimer(s)
+	ps.syncAutopilot(s)
+	return nil
 }
 
-func (ps *PlaySessionSvc) DecrementPSQuestion(ctx context.Context, code uint) (err error) {
+// NextPSRound skips ahead to the first question of the round after the current one. Rounds
+// without questions are passed over, during the last round nothing happens.
+func (ps *PlaySessionSvc) NextPSRound(ctx context.Context, code uint) (err error) {
 	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
-	s, err := ps.db.GetPlaySession(code)
+	var s *models.PlaySession
+	err = ps.withRetry(ctx, func(db models.QuizStore) (err error) {
+		s, err = db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		qqs, err := db.GetQuestionsByQuiz(s.Quiz.ID)
+		if err != nil {
+			return err
+		}
+		rr, err := db.GetRounds(s.Quiz.ID)
+		if err != nil {
+			return err
+		}
+		var current *models.Round
+		if s.CurrentQuestionIndex < len(qqs) {
+			current = models.RoundOf(rr, qqs[s.CurrentQuestionIndex])
+		}
+		// Questions are in play order, the first one in another round starts the next round
+		for i := s.CurrentQuestionIndex + 1; i < len(qqs); i++ {
+			if r := models.RoundOf(rr, qqs[i]); r != nil && r != current {
+				s.TakeOver()
+				err = goToQuestion(db, s, qqs, i)
+				if err != nil {
+					return err
+				}
+				return db.UpdatePlaySession(s)
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
-	if s.QuizMaster != u.Email {
-		return NotPermittedError
+	ps.syncTimer(s)
+	ps.syncAutopilot(s)
+	return nil
+}
+
+// goToQuestion makes the question at index of qqs, the questions of the session's quiz in play
+// order, the current one along with its round and starts its timer
+func goToQuestion(db models.QuizStore, s *models.PlaySession, qqs []*models.Question, index int) error {
+	if index < 0 || index >= len(qqs) {
+		return NoCurrentQuestionError
 	}
-	// Increment Question
-	qqs, err := ps.db.GetQuestionsByQuiz(s.Quiz.ID)
+	rr, err := db.GetRounds(s.Quiz.ID)
 	if err != nil {
 		return err
 	}
-	if s.CurrentQuestionIndex > 0 {
-		s.CurrentQuestionIndex -= 1
-	}
-	s.UpdateQuestion(qqs[s.CurrentQuestionIndex])
+	s.CurrentQuestionIndex = index
+	s.UpdateQuestion(qqs[index])
+	s.UpdateRound(models.RoundOf(rr, qqs[index]))
 	s.ClearCurrentAnswer()
-	return ps.db.UpdatePlaySession(s)
+	s.AnswersLocked = false
+	s.StartTimer(models.RoundOf(rr, qqs[index]).TimerFor(qqs[index]), time.Now())
+	return nil
+}
+
+// currentQuestion returns the current question of a session and the round it is played in, nil
+// outside of rounds. It fails with NoCurrentQuestionError when questions were taken out of the quiz
+// and the session points past its end.
+func currentQuestion(db models.QuizStore, s *models.PlaySession) (q *models.Question, r *models.Round, err error) {
+	qqs, err := db.GetQuestionsByQuiz(s.QuizID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s.CurrentQuestionIndex < 0 || s.CurrentQuestionIndex >= len(qqs) {
+		return nil, nil, NoCurrentQuestionError
+	}
+	rr, err := db.GetRounds(s.QuizID)
+	if err != nil {
+		return nil, nil, err
+	}
+	q = qqs[s.CurrentQuestionIndex]
+	return q, models.RoundOf(rr, q), nil
 }
 
 func (ps *PlaySessionSvc) UpdateTeamPoints(ctx context.Context, code uint, points int, teamName string) (err error) {
@@ -150,102 +361,555 @@ func (ps *PlaySessionSvc) UpdateTeamPoints(ctx context.Context, code uint, point
 	if err != nil {
 		return err
 	}
-	s, err := ps.db.GetPlaySession(code)
+	return ps.withRetry(ctx, func(db models.QuizStore) error {
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		// Award Points, they count for the round being played. Without a current question they
+		// count outside of rounds.
+		t, err := s.GetTeam(teamName)
+		if err != nil {
+			return err
+		}
+		var r *models.Round
+		if s.State == models.StateInProgress {
+			_, r, err = currentQuestion(db, s)
+			if err != nil && !errors.Is(err, NoCurrentQuestionError) {
+				return err
+			}
+		}
+		t.AddRoundPoints(r, r.Scale(points))
+
+		return db.UpdateTeam(t)
+	})
+}
+
+// RevealPSCurrentAnswer shows everyone the answer to the current question and stops its timer.
+// The quizmaster takes over from the autopilot.
+func (ps *PlaySessionSvc) RevealPSCurrentAnswer(ctx context.Context, code uint) (err error) {
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
-	if s.QuizMaster != u.Email {
-		return NotPermittedError
+	err = ps.withRetry(ctx, func(db models.QuizStore) error {
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		// Return Answer
+		q, r, err := currentQuestion(db, s)
+		if err != nil {
+			return err
+		}
+		s.TakeOver()
+		return revealAnswer(db, s, q, r)
+	})
+	if err != nil {
+		return err
 	}
-	// Award Points
-	t, err := s.GetTeam(teamName)
+	ps.timers.stop(code)
+	ps.pilots.stop(code)
+	return nil
+}
+
+// revealAnswer reveals the answer to q, the current question of s played in round r. Multiple
+// choice and numeric questions are graded at the same time, the teams that got it right are
+// awarded points.
+func revealAnswer(db models.QuizStore, s *models.PlaySession, q *models.Question, r *models.Round) error {
+	s.SetCurrentAnswer(q.Answer)
+	s.StopTimer()
+	err := db.UpdatePlaySession(s)
+	if err != nil {
+		return err
+	}
+	if !q.IsGraded() {
+		return nil
+	}
+	return gradeSubmissions(db, s, q, r)
+}
+
+// gradeSubmissions grades the answers to q that haven't been yet and awards their points, scaled by
+// the multiplier of round r the question is played in
+func gradeSubmissions(db models.QuizStore, s *models.PlaySession, q *models.Question, r *models.Round) error {
+	subs, err := db.GetSubmissions(s.ID, q.ID)
 	if err != nil {
 		return err
 	}
-	t.AddPoints(points)
+	for _, sub := range models.GradeSubmissions(q, subs) {
+		sub.Points = r.Scale(sub.Points)
+		for _, t := range s.Teams {
+			if t.ID != sub.TeamID || sub.Points == 0 {
+				continue
+			}
+			t.AddRoundPoints(r, sub.Points)
+			err = db.UpdateTeam(t)
+			if err != nil {
+				return err
+			}
+		}
+		err = db.SaveSubmission(sub)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	return ps.db.UpdateTeam(t)
+// SubmitPSChoices records the options the user's team picks for the current question. The team can
+// change its pick until answers are locked.
+func (ps *PlaySessionSvc) SubmitPSChoices(ctx context.Context, code uint, choices []int) (err error) {
+	_, err = ps.SubmitPSAnswer(ctx, code, &models.Submission{Choices: choices})
+	return err
 }
 
-func (ps *PlaySessionSvc) RevealPSCurrentAnswer(ctx context.Context, code uint) (err error) {
+// SubmitPSNumber records the number the user's team answers the current question with. The team
+// can change it until answers are locked.
+func (ps *PlaySessionSvc) SubmitPSNumber(ctx context.Context, code uint, number float64) (err error) {
+	_, err = ps.SubmitPSAnswer(ctx, code, &models.Submission{Number: &number})
+	return err
+}
+
+// SubmitPSAnswer stores the answer of the user's team to the current question, replacing the one
+// it gave before. Answers are taken until the quizmaster locks them or reveals the answer, or the
+// timer of the question runs out. The answer comes back without the verdict of matching it, that
+// is for the quizmaster only.
+func (ps *PlaySessionSvc) SubmitPSAnswer(ctx context.Context, code uint, answer *models.Submission) (sub *models.Submission, err error) {
+	// Taken before the transaction so a retry doesn't make the answer late
+	received := time.Now()
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
+	if err != nil {
+		return sub, err
+	}
+	err = ps.withRetry(ctx, func(db models.QuizStore) error {
+		u, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if !s.AnswersOpen() {
+			return AnswersClosedError
+		}
+		if s.TimeUp(received) {
+			return TimeUpError
+		}
+		team := s.TeamOf(u.Email)
+		if team == nil {
+			return NotInTeamError
+		}
+		q, _, err := currentQuestion(db, s)
+		if err != nil {
+			return err
+		}
+		err = q.CheckSubmission(answer)
+		if err != nil {
+			return err
+		}
+		subs, err := db.GetSubmissions(s.ID, q.ID)
+		if err != nil {
+			return err
+		}
+		for _, sub := range subs {
+			if sub.TeamID == team.ID && sub.Graded {
+				return AnswersClosedError
+			}
+		}
+		sub = &models.Submission{Text: answer.Text, Choices: answer.Choices, Number: answer.Number}
+		if !q.IsGraded() {
+			sub.Match = q.MatchAnswer(sub.Text).Verdict
+		}
+		sub.PlaySessionID, sub.QuestionID, sub.TeamID, sub.UserID = s.ID, q.ID, team.ID, u.ID
+		// Bumping the session first makes teammates answering at the same time take turns, instead
+		// of both inserting the first answer of the team
+		err = db.UpdatePlaySession(s)
+		if err != nil {
+			return err
+		}
+		err = db.SaveSubmission(sub)
+		if err != nil {
+			return err
+		}
+		sub.User, sub.Team = u, team
+		sub = sub.ForTeam()
+		return nil
+	})
+	return sub, err
+}
+
+// SetPSAnswersLocked stops or resumes taking answers to the current question. Moving on to another
+// question unlocks answers again.
+func (ps *PlaySessionSvc) SetPSAnswersLocked(ctx context.Context, code uint, locked bool) (err error) {
 	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
-	s, err := ps.db.GetPlaySession(code)
+	return ps.withRetry(ctx, func(db models.QuizStore) error {
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		if s.State != models.StateInProgress {
+			return AnswersClosedError
+		}
+		s.AnswersLocked = locked
+		return db.UpdatePlaySession(s)
+	})
+}
+
+// SetPSAutoReveal makes the answer to a question show as soon as its timer runs out, or leaves
+// revealing it to the quizmaster
+func (ps *PlaySessionSvc) SetPSAutoReveal(ctx context.Context, code uint, on bool) (err error) {
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
+	return ps.withRetry(ctx, func(db models.QuizStore) error {
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		s.AutoReveal = on
+		return db.UpdatePlaySession(s)
+	})
+}
+
+// GetPSSubmissions lists the answers teams gave to the current question for the quizmaster, in the
+// order they were first submitted
+func (ps *PlaySessionSvc) GetPSSubmissions(ctx context.Context, code uint) (subs []*models.Submission, err error) {
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
+	if err != nil {
+		return subs, err
+	}
+	s, err := ps.db.GetPlaySession(code)
+	if err != nil {
+		return subs, err
+	}
 	if s.QuizMaster != u.Email {
-		return NotPermittedError
+		return subs, NotPermittedError
 	}
-	// Return Answer
-	qqs, err := ps.db.GetQuestionsByQuiz(s.Quiz.ID)
+	if s.State != models.StateInProgress && s.State != models.StateFinished {
+		return []*models.Submission{}, nil
+	}
+	q, _, err := currentQuestion(ps.db, s)
 	if err != nil {
-		return err
+		return subs, err
 	}
-	s.SetCurrentAnswer(qqs[s.CurrentQuestionIndex].Answer)
-	return ps.db.UpdatePlaySession(s)
+	return ps.db.GetSubmissions(s.ID, q.ID)
 }
 
+// GetPS returns the play session with the rounds of its quiz and its current question. Until the
+// answer is revealed only the quizmaster gets to see it. The question shows the timer it is played
+// with, which may be the default of its round.
 func (ps *PlaySessionSvc) GetPS(ctx context.Context, code uint) (s *models.PlaySession, err error) {
 	s, err = ps.db.GetPlaySession(code)
-	// SetQuestion if needed
+	if err != nil {
+		return s, err
+	}
+	rr, err := ps.db.GetRounds(s.QuizID)
+	if err != nil {
+		return s, err
+	}
+	if s.Quiz != nil {
+		s.Quiz.Rounds = rr
+	}
+	// SetQuestion if needed, the session may point past the end of a quiz that lost questions
 	if s.State == models.StateInProgress || s.State == models.StateFinished {
 		qqs, err := ps.db.GetQuestionsByQuiz(s.Quiz.ID)
 		if err != nil {
 			return s, err
 		}
-		s.UpdateQuestion(qqs[s.CurrentQuestionIndex])
+		if s.CurrentQuestionIndex >= len(qqs) {
+			return s, nil
+		}
+		q := qqs[s.CurrentQuestionIndex]
+		r := models.RoundOf(rr, q)
+		u, err := getUserFromContext(ctx, ps.UserContextKey())
+		if s.CurrentAnswer == "" && (err != nil || u.Email != s.QuizMaster) {
+			q = q.ForPlayers()
+		}
+		q.TimerSeconds = r.TimerFor(q)
+		s.UpdateQuestion(q)
+		s.UpdateRound(r)
 	}
-	return
+	return s, nil
 }
 
-func (ps *PlaySessionSvc) AddUserToPS(ctx context.Context, code uint) (err error) {
+// GetPSScores breaks the points of the teams of a play session down by the rounds of its quiz
+func (ps *PlaySessionSvc) GetPSScores(ctx context.Context, code uint) (sb *models.Scoreboard, err error) {
+	s, err := ps.db.GetPlaySession(code)
+	if err != nil {
+		return sb, err
+	}
+	rr, err := ps.db.GetRounds(s.QuizID)
+	if err != nil {
+		return sb, err
+	}
+	return models.NewScoreboard(rr, s.Teams), nil
+}
+
+// BuzzPS buzzes the user's team in on the current question. Buzzes line up by the time they reach
+// the server, first tells whether the team is now the first one waiting for the quizmaster.
+func (ps *PlaySessionSvc) BuzzPS(ctx context.Context, code uint) (b *models.Buzz, first bool, err error) {
+	// Taken before the transaction so buzzes keep their place when it is retried
+	received := time.Now()
 	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
-		return err
+		return b, false, err
 	}
-	u, err = ps.db.GetUserByEmail(u.Email)
+	err = ps.withRetry(ctx, func(db models.QuizStore) error {
+		u, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.State != models.StateInProgress || s.CurrentAnswer != "" {
+			return BuzzersLockedError
+		}
+		team := s.TeamOf(u.Email)
+		if team == nil {
+			return NotInTeamError
+		}
+		q, _, err := currentQuestion(db, s)
+		if err != nil {
+			return err
+		}
+		bb, err := db.GetBuzzes(s.ID)
+		if err != nil {
+			return err
+		}
+		queue := models.QueueFor(bb, q.ID)
+		if queue.Locked() {
+			return BuzzersLockedError
+		}
+		if queue.Of(team.ID) != nil {
+			return AlreadyBuzzedError
+		}
+		b = models.NewBuzz(s.ID, q.ID, u, team, received)
+		err = db.CreateBuzz(b)
+		if err != nil {
+			return err
+		}
+		// Bumping the session makes concurrent buzzes on it take turns
+		err = db.UpdatePlaySession(s)
+		if err != nil {
+			return err
+		}
+		b.User, b.Team = u, team
+		waiting := queue.First()
+		first = waiting == nil || received.Before(waiting.TS)
+		return nil
+	})
+	return b, first, err
+}
+
+// AcceptPSBuzz gives the current question to the first team waiting to answer it and locks the
+// buzzers until the next question
+func (ps *PlaySessionSvc) AcceptPSBuzz(ctx context.Context, code uint) (b *models.Buzz, err error) {
+	err = ps.judgeBuzz(ctx, code, func(db models.QuizStore, queue models.BuzzQueue) error {
+		b = queue.First()
+		b.Status = models.BuzzAccepted
+		return db.UpdateBuzz(b)
+	})
+	return b, err
+}
+
+// RejectPSBuzz turns down the first team waiting to answer the current question, it can't buzz on
+// the question again. Next is the buzz waiting after it, nil when there is none.
+func (ps *PlaySessionSvc) RejectPSBuzz(ctx context.Context, code uint) (rejected, next *models.Buzz, err error) {
+	err = ps.judgeBuzz(ctx, code, func(db models.QuizStore, queue models.BuzzQueue) error {
+		rejected = queue.First()
+		rejected.Status = models.BuzzRejected
+		next = queue.First()
+		return db.UpdateBuzz(rejected)
+	})
+	return rejected, next, err
+}
+
+// judgeBuzz runs judge on the buzzes on the current question for the quizmaster, as long as a team
+// is waiting and the buzzers aren't locked
+func (ps *PlaySessionSvc) judgeBuzz(ctx context.Context, code uint, judge func(db models.QuizStore, queue models.BuzzQueue) error) error {
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
+	return ps.withRetry(ctx, func(db models.QuizStore) error {
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		if s.State != models.StateInProgress {
+			return NoBuzzError
+		}
+		q, _, err := currentQuestion(db, s)
+		if err != nil {
+			return err
+		}
+		bb, err := db.GetBuzzes(s.ID)
+		if err != nil {
+			return err
+		}
+		queue := models.QueueFor(bb, q.ID)
+		if queue.Locked() {
+			return BuzzersLockedError
+		}
+		if queue.First() == nil {
+			return NoBuzzError
+		}
+		err = judge(db, queue)
+		if err != nil {
+			return err
+		}
+		return db.UpdatePlaySession(s)
+	})
+}
+
+// GetPSBuzzes lists every buzz of a play session in the order they were received. Only the
+// quizmaster and the players who joined the session get to see them.
+func (ps *PlaySessionSvc) GetPSBuzzes(ctx context.Context, code uint) (bb []*models.Buzz, err error) {
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
+	if err != nil {
+		return bb, err
+	}
 	s, err := ps.db.GetPlaySession(code)
 	if err != nil {
-		return err
+		return bb, err
 	}
-	s.AddUser(u)
-	return ps.db.UpdatePlaySession(s)
+	if !s.IsParticipant(u.Email) {
+		return bb, NotPermittedError
+	}
+	return ps.db.GetBuzzes(s.ID)
 }
 
-func (ps *PlaySessionSvc) AddTeamToPS(ctx context.Context, code uint, t *models.Team) (err error) {
+// JudgePSSubmission is the quizmaster's verdict on the text answer of a team to the current question,
+// whatever it was pre-marked as. Correct answers earn the team the points of the question, judging
+// an answer again takes back the points of the earlier verdict.
+func (ps *PlaySessionSvc) JudgePSSubmission(ctx context.Context, code uint, teamID uint, correct bool) (err error) {
 	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
-	s, err := ps.db.GetPlaySession(code)
+	return ps.withRetry(ctx, func(db models.QuizStore) error {
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		if s.State != models.StateInProgress {
+			return AnswersClosedError
+		}
+		q, r, err := currentQuestion(db, s)
+		if err != nil {
+			return err
+		}
+		if q.IsGraded() {
+			return fmt.Errorf("%w: answers to %s questions are graded when the answer is revealed", models.ErrInvalidSubmission, q.Type)
+		}
+		subs, err := db.GetSubmissions(s.ID, q.ID)
+		if err != nil {
+			return err
+		}
+		var sub *models.Submission
+		for _, candidate := range subs {
+			if candidate.TeamID == teamID {
+				sub = candidate
+			}
+		}
+		if sub == nil {
+			return fmt.Errorf("answer of team %d: %w", teamID, gorm.ErrRecordNotFound)
+		}
+		points := 0
+		if correct {
+			points = r.Scale(q.Worth())
+		}
+		for _, t := range s.Teams {
+			if t.ID != teamID || points == sub.Points {
+				continue
+			}
+			t.AddRoundPoints(r, points-sub.Points)
+			err = db.UpdateTeam(t)
+			if err != nil {
+				return err
+			}
+		}
+		sub.Graded, sub.Correct, sub.Points = true, correct, points
+		return db.SaveSubmission(sub)
+	})
+}
+
+func (ps *PlaySessionSvc) AddUserToPS(ctx context.Context, code uint) (err error) {
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
-	if s.QuizMaster != u.Email {
-		return NotPermittedError
-	}
-	s.AddTeam(t)
-	return ps.db.UpdatePlaySession(s)
+	return ps.withRetry(ctx, func(db models.QuizStore) error {
+		u, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		s.AddUser(u)
+		return db.UpdatePlaySession(s)
+	})
 }
 
-func (ps *PlaySessionSvc) AddUserToTeam(ctx context.Context, code uint, teamName string, email string) (err error) {
+func (ps *PlaySessionSvc) AddTeamToPS(ctx context.Context, code uint, t *models.Team) (err error) {
 	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
-	s, err := ps.db.GetPlaySession(code)
+	return ps.withRetry(ctx, func(db models.QuizStore) error {
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if s.QuizMaster != u.Email {
+			return NotPermittedError
+		}
+		s.AddTeam(t)
+		return db.UpdatePlaySession(s)
+	})
+}
+
+func (ps *PlaySessionSvc) AddUserToTeam(ctx context.Context, code uint, teamName string, email string) (err error) {
+	u, err := getUserFromContext(ctx, ps.UserContextKey())
 	if err != nil {
 		return err
 	}
-	if email != u.Email {
-		return NotPermittedError
-	}
-	s.AssignUserToTeam(teamName, email)
-	return ps.db.UpdatePlaySession(s)
+	return ps.withRetry(ctx, func(db models.QuizStore) error {
+		s, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		if email != u.Email {
+			return NotPermittedError
+		}
+		s.AssignUserToTeam(teamName, email)
+		return db.UpdatePlaySession(s)
+	})
 }
@@ -0,0 +1,182 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/tchaudhry91/laqz/svc/models"
+)
+
+// startTestSession creates a quiz of qq for quizmaster and starts playing it with a single team
+// "Owls" of players. It returns the code of the session.
+func startTestSession(t *testing.T, hub *QHub, quizmaster context.Context, players []context.Context, qq ...*models.Question) uint {
+	t.Helper()
+	qz, err := hub.CreateQuiz(quizmaster, "Capitals", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, q := range qq {
+		q.QuizID = qz.ID
+		err = hub.AddQuestion(quizmaster, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s, err := hub.InitNewPS(quizmaster, qz.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = hub.AddTeamToPS(quizmaster, s.Code, models.NewTeam("Owls"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, ctx := range players {
+		u, _ := getUserFromContext(ctx, hub.UserContextKey())
+		err = hub.AddUserToPS(ctx, s.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = hub.AddUserToTeam(ctx, s.Code, "Owls", u.Email)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = hub.StartPS(quizmaster, s.Code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s.Code
+}
+
+func TestSubmitPSAnswerTeammates(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		players := []context.Context{logIn(t, hub, "ann@example.com"), logIn(t, hub, "bob@example.com")}
+		code := startTestSession(t, hub, qm, players, models.NewQuestion(0, "Capital of France?", "", "", "Paris", 1, 0))
+
+		// Teammates answering at the same time both get through, one of the answers stands
+		wg := sync.WaitGroup{}
+		errs := make([]error, len(players))
+		for i, ctx := range players {
+			wg.Add(1)
+			go func(i int, ctx context.Context) {
+				defer wg.Done()
+				_, errs[i] = hub.SubmitPSAnswer(ctx, code, &models.Submission{Text: "Paris"})
+			}(i, ctx)
+		}
+		wg.Wait()
+		for _, err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		subs, err := hub.GetPSSubmissions(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(subs) != 1 || subs[0].Text != "Paris" {
+			t.Fatalf("got %d answers of the team, want one", len(subs))
+		}
+	})
+}
+
+func TestSubmitPSAnswerHidesMatch(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		ann := logIn(t, hub, "ann@example.com")
+		code := startTestSession(t, hub, qm, []context.Context{ann}, models.NewQuestion(0, "Capital of France?", "", "", "Paris", 1, 0))
+
+		sub, err := hub.SubmitPSAnswer(ann, code, &models.Submission{Text: "Pariss"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sub.Match != "" {
+			t.Fatalf("the team got the verdict %q on its answer", sub.Match)
+		}
+		subs, err := hub.GetPSSubmissions(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(subs) != 1 || subs[0].Match != models.MatchNearMiss {
+			t.Fatalf("got %d answers for the quizmaster, want one near miss", len(subs))
+		}
+	})
+}
+
+func TestStartPSEmptyQuiz(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		qz, err := hub.CreateQuiz(qm, "Empty", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := hub.InitNewPS(qm, qz.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = hub.StartPS(qm, s.Code)
+		if !errors.Is(err, NoQuestionsError) {
+			t.Fatalf("got %v starting a quiz without questions, want NoQuestionsError", err)
+		}
+		s, err = hub.GetPS(qm, s.Code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.State == models.StateInProgress {
+			t.Fatal("the session started without questions")
+		}
+	})
+}
+
+func TestUpdateTeamPointsQuestionsTakenOut(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		q := models.NewQuestion(0, "Capital of France?", "", "", "Paris", 1, 0)
+		code := startTestSession(t, hub, qm, nil, q)
+		err := hub.DeleteQuestion(qm, q.ID, q.QuizID)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// With the quiz emptied under the session, points count outside of rounds
+		err = hub.UpdateTeamPoints(qm, code, 3, "Owls")
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err := hub.GetPS(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(s.Teams) != 1 || s.Teams[0].Points != 3 {
+			t.Fatalf("got teams %v, want Owls with 3 points", s.Teams)
+		}
+	})
+}
+
+func TestGetPSBuzzesParticipants(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		ann := logIn(t, hub, "ann@example.com")
+		outsider := logIn(t, hub, "bob@example.com")
+		code := startTestSession(t, hub, qm, []context.Context{ann}, models.NewQuestion(0, "Capital of France?", "", "", "Paris", 1, 0))
+		_, _, err := hub.BuzzPS(ann, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, ctx := range []context.Context{qm, ann} {
+			bb, err := hub.GetPSBuzzes(ctx, code)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(bb) != 1 {
+				t.Fatalf("got %d buzzes, want 1", len(bb))
+			}
+		}
+		_, err = hub.GetPSBuzzes(outsider, code)
+		if !errors.Is(err, NotPermittedError) {
+			t.Fatalf("got %v listing the buzzes of a session bob isn't in, want NotPermittedError", err)
+		}
+	})
+}
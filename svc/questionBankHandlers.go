@@ -0,0 +1,91 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
+)
+
+// GetQuestionBank lists the caller's questions. It takes a search query in q, tag filters in tag,
+// a question type in type and the paging parameters of quiz listings.
+func (s *QServer) GetQuestionBank() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		query := req.URL.Query()
+		opts := models.QuestionBankOptions{
+			Query:  query.Get("q"),
+			Tags:   query["tag"],
+			Type:   models.QuestionType(query.Get("type")),
+			Cursor: query.Get("cursor"),
+		}
+		if limitStr := query.Get("limit"); limitStr != "" {
+			limit, err := strconv.Atoi(limitStr)
+			if err != nil || limit <= 0 || limit > maxListLimit {
+				s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad limit supplied, it must be between 1 and %d", maxListLimit))
+				return
+			}
+			opts.Limit = limit
+		}
+		page, err := s.hub.GetQuestionBank(req.Context(), opts)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidListOptions) {
+				s.respond(w, req, nil, http.StatusBadRequest, err)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, page, http.StatusOK, nil)
+	}
+}
+
+func (s *QServer) AttachQuestions() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			QuizID      uint   `json:"quiz_id,omitempty"`
+			QuestionIDs []uint `json:"question_ids,omitempty"`
+		}
+		type Response struct {
+			Err string `json:"err,omitempty"`
+		}
+
+		r := Request{}
+		defer req.Body.Close()
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		if len(r.QuestionIDs) == 0 {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("You must supply the questions to attach"))
+			return
+		}
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["id"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		r.QuizID = uint(id)
+
+		err = s.hub.AttachQuestions(req.Context(), r.QuizID, r.QuestionIDs)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				s.respond(w, req, Response{Err: err.Error()}, http.StatusNotFound, nil)
+				return
+			}
+			if errors.Is(err, NotPermittedError) {
+				s.respond(w, req, nil, http.StatusForbidden, err)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
@@ -0,0 +1,208 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/tchaudhry91/laqz/svc/models"
+)
+
+// timerTick is how often a running question timer tells how much time is left
+const timerTick = time.Second
+
+// TimerEvent is a tick of the timer of the current question of a play session, or its running out.
+// Revealed is set when running out revealed the answer.
+type TimerEvent struct {
+	Code       uint
+	QuestionID uint
+	Remaining  int
+	Expired    bool
+	Revealed   bool
+}
+
+// sessionTimers keeps track of background timers of play sessions, at most one per session
+type sessionTimers struct {
+	mx      sync.Mutex
+	running map[uint]chan struct{}
+}
+
+func newSessionTimers() *sessionTimers {
+	return &sessionTimers{
+		running: make(map[uint]chan struct{}),
+	}
+}
+
+// replace stops the timer of a session and returns the channel that stops the one taking its place
+func (qt *sessionTimers) replace(code uint) chan struct{} {
+	qt.mx.Lock()
+	defer qt.mx.Unlock()
+	if stop, ok := qt.running[code]; ok {
+		close(stop)
+	}
+	stop := make(chan struct{})
+	qt.running[code] = stop
+	return stop
+}
+
+func (qt *sessionTimers) stop(code uint) {
+	qt.mx.Lock()
+	defer qt.mx.Unlock()
+	if stop, ok := qt.running[code]; ok {
+		close(stop)
+		delete(qt.running, code)
+	}
+}
+
+// finish forgets a timer that ran out, unless another one replaced it in the meantime
+func (qt *sessionTimers) finish(code uint, stop chan struct{}) {
+	qt.mx.Lock()
+	defer qt.mx.Unlock()
+	if qt.running[code] == stop {
+		delete(qt.running, code)
+	}
+}
+
+// OnQuestionTimer sets what is told about the ticks and the expiry of question timers. It is
+// called from the goroutines running the timers.
+func (ps *PlaySessionSvc) OnQuestionTimer(notify func(e TimerEvent)) {
+	ps.notifyMx.Lock()
+	defer ps.notifyMx.Unlock()
+	ps.onTimer = notify
+}
+
+func (ps *PlaySessionSvc) notifyTimer(e TimerEvent) {
+	ps.notifyMx.Lock()
+	notify := ps.onTimer
+	ps.notifyMx.Unlock()
+	if notify != nil {
+		notify(e)
+	}
+}
+
+// syncTimer runs the timer of the current question of s in the background, in place of the one
+// running for the session before. Timers live in memory only, RearmPSTimers picks them up again
+// after a restart.
+func (ps *PlaySessionSvc) syncTimer(s *models.PlaySession) {
+	if s.State != models.StateInProgress || s.QuestionEndsAt == nil || s.TimeUp(time.Now()) || s.CurrentQuestion == nil {
+		ps.timers.stop(s.Code)
+		return
+	}
+	stop := ps.timers.replace(s.Code)
+	go ps.runTimer(s.Code, s.CurrentQuestion.ID, *s.QuestionEndsAt, stop)
+}
+
+// RearmPSTimers picks up the question timers and the autopilots of the sessions in progress, which
+// stop with the server. Questions whose time ran out in the meantime expire right away. Sessions
+// that can't be picked up, like those whose quiz lost its questions, are skipped and listed in the
+// error so they don't hold back the others.
+func (ps *PlaySessionSvc) RearmPSTimers() (err error) {
+	ss, err := ps.db.GetPlaySessionsInProgress()
+	if err != nil {
+		return err
+	}
+	skipped := []string{}
+	for _, s := range ss {
+		err = ps.rearm(s)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%d (%v)", s.Code, err))
+		}
+	}
+	if len(skipped) > 0 {
+		return fmt.Errorf("Skipped rearming play sessions %s", strings.Join(skipped, ", "))
+	}
+	return nil
+}
+
+func (ps *PlaySessionSvc) rearm(s *models.PlaySession) error {
+	q, _, err := currentQuestion(ps.db, s)
+	if err != nil {
+		return err
+	}
+	s.UpdateQuestion(q)
+	if s.CurrentAnswer == "" && s.TimeUp(time.Now()) {
+		expired, _, err := ps.expireQuestion(s.Code, q.ID)
+		if err != nil {
+			return err
+		}
+		if expired != nil {
+			s = expired
+		}
+	}
+	ps.syncTimer(s)
+	ps.syncAutopilot(s)
+	return nil
+}
+
+func (ps *PlaySessionSvc) runTimer(code, questionID uint, endsAt time.Time, stop chan struct{}) {
+	ticker := time.NewTicker(timerTick)
+	defer ticker.Stop()
+	expiry := time.NewTimer(time.Until(endsAt))
+	defer expiry.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			remaining := int(math.Ceil(time.Until(endsAt).Seconds()))
+			if remaining > 0 {
+				ps.notifyTimer(TimerEvent{Code: code, QuestionID: questionID, Remaining: remaining})
+			}
+		case <-expiry.C:
+			ps.timers.finish(code, stop)
+			s, revealed, err := ps.expireQuestion(code, questionID)
+			if err != nil || s == nil {
+				return
+			}
+			ps.notifyTimer(TimerEvent{Code: code, QuestionID: questionID, Expired: true, Revealed: revealed})
+			if s.AutopilotRunning() {
+				ps.syncAutopilot(s)
+				ps.notifyAutopilot(s)
+			}
+			return
+		}
+	}
+}
+
+// expireQuestion reveals the answer to a question whose timer ran out if the session reveals answers
+// automatically or flies on autopilot. The session is nil when it moved on in the meantime, which
+// includes its question being taken out of the quiz.
+func (ps *PlaySessionSvc) expireQuestion(code, questionID uint) (s *models.PlaySession, revealed bool, err error) {
+	err = ps.withRetry(context.Background(), func(db models.QuizStore) error {
+		s, revealed = nil, false
+		current, err := db.GetPlaySession(code)
+		if err != nil {
+			return err
+		}
+		// The deadline went through the database, which may have rounded it a little
+		if current.State != models.StateInProgress || !current.TimeUp(time.Now().Add(time.Millisecond)) {
+			return nil
+		}
+		q, r, err := currentQuestion(db, current)
+		if errors.Is(err, NoCurrentQuestionError) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if q.ID != questionID {
+			return nil
+		}
+		s = current
+		if current.CurrentAnswer != "" {
+			return nil
+		}
+		if current.AutopilotRunning() {
+			current.SetAutopilotStep(models.AutopilotAnswer, time.Now())
+		} else if !current.AutoReveal {
+			return nil
+		}
+		revealed = true
+		return revealAnswer(db, current, q, r)
+	})
+	return s, revealed, err
+}
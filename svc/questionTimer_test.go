@@ -0,0 +1,259 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tchaudhry91/laqz/svc/models"
+)
+
+// startTestAutopilot starts a session of a single question on autopilot, then forgets its timers
+// the way a restart would and lets the question run out soon
+func startTestAutopilot(t *testing.T, hub *QHub, qm context.Context) (code uint) {
+	t.Helper()
+	code = startTestSession(t, hub, qm, nil, models.NewQuestion(0, "Capital of France?", "", "", "Paris", 1, 0))
+	err := hub.StartPSAutopilot(qm, code, models.DefaultAutopilotPauses())
+	if err != nil {
+		t.Fatal(err)
+	}
+	hub.timers.stop(code)
+	hub.pilots.stop(code)
+
+	s, err := hub.db.GetPlaySession(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	endsAt := time.Now().Add(100 * time.Millisecond)
+	s.QuestionEndsAt = &endsAt
+	err = hub.db.UpdatePlaySession(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}
+
+// awaitAnswerStep waits for the autopilot of the session to show the answer
+func awaitAnswerStep(t *testing.T, events chan AutopilotEvent, code uint) {
+	t.Helper()
+	timeout := time.After(3 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e.Code == code && e.Step == models.AutopilotAnswer {
+				return
+			}
+		case <-timeout:
+			t.Fatal("the autopilot never showed the answer")
+		}
+	}
+}
+
+func TestRearmPSTimers(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		code := startTestAutopilot(t, hub, qm)
+
+		restarted := NewQHub(hub.db)
+		events := make(chan AutopilotEvent, 10)
+		restarted.OnAutopilot(func(e AutopilotEvent) { events <- e })
+		err := restarted.RearmPSTimers()
+		if err != nil {
+			t.Fatal(err)
+		}
+		awaitAnswerStep(t, events, code)
+		s, err := restarted.GetPS(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.CurrentAnswer != "Paris" {
+			t.Fatalf("got answer %q once the time ran out, want Paris", s.CurrentAnswer)
+		}
+		restarted.pilots.stop(code)
+	})
+}
+
+func TestRearmPSTimersSkipsStaleSessions(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		code := startTestAutopilot(t, hub, qm)
+
+		// A session on autopilot stuck on a quiz that lost all its questions
+		qz, err := hub.CreateQuiz(qm, "Emptied", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stale := models.NewPlaySession("quizmaster@example.com", qz)
+		stale.Code = code + 1
+		stale.SetInProgress()
+		stale.Autopilot = true
+		stale.AutopilotStep = models.AutopilotQuestion
+		err = hub.db.CreatePlaySession(stale)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		restarted := NewQHub(hub.db)
+		events := make(chan AutopilotEvent, 10)
+		restarted.OnAutopilot(func(e AutopilotEvent) { events <- e })
+		err = restarted.RearmPSTimers()
+		if err == nil || !strings.Contains(err.Error(), fmt.Sprint(stale.Code)) {
+			t.Fatalf("got %v, want the stale session %d skipped", err, stale.Code)
+		}
+		awaitAnswerStep(t, events, code)
+		restarted.pilots.stop(code)
+
+		err = restarted.ResumePSAutopilot(qm, stale.Code)
+		if !errors.Is(err, NoCurrentQuestionError) {
+			t.Fatalf("got %v resuming the stale session, want NoCurrentQuestionError", err)
+		}
+	})
+}
+
+func TestResumePSAutopilotRearms(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		code := startTestAutopilot(t, hub, qm)
+
+		events := make(chan AutopilotEvent, 10)
+		hub.OnAutopilot(func(e AutopilotEvent) { events <- e })
+		err := hub.ResumePSAutopilot(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		awaitAnswerStep(t, events, code)
+		hub.pilots.stop(code)
+	})
+}
+
+// awaitTimerStopped waits for the timer of the session in timers to be over
+func awaitTimerStopped(t *testing.T, timers *sessionTimers, code uint) {
+	t.Helper()
+	deadline := time.Now().Add(3 * time.Second)
+	for time.Now().Before(deadline) {
+		timers.mx.Lock()
+		_, running := timers.running[code]
+		timers.mx.Unlock()
+		if !running {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("the timer never stopped")
+}
+
+func TestQuestionTimerAfterQuestionTakenOut(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		first := models.NewQuestion(0, "Capital of France?", "", "", "Paris", 1, 1)
+		second := models.NewQuestion(0, "Capital of Italy?", "", "", "Rome", 1, 1)
+		code := startTestSession(t, hub, qm, nil, first, second)
+		err := hub.SetPSAutoReveal(qm, code, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = hub.IncrementPSQuestion(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The session now points past the end of the quiz, its timer runs out on nothing
+		err = hub.DeleteQuestion(qm, first.ID, first.QuizID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		awaitTimerStopped(t, hub.timers, code)
+		s, err := hub.GetPS(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.CurrentAnswer != "" {
+			t.Fatalf("got answer %q revealed for a question that is gone", s.CurrentAnswer)
+		}
+		_, err = hub.GetPSSubmissions(qm, code)
+		if !errors.Is(err, NoCurrentQuestionError) {
+			t.Fatalf("got %v for the answers to a question that is gone, want NoCurrentQuestionError", err)
+		}
+
+		// Moving on lands on the last question left
+		err = hub.IncrementPSQuestion(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s, err = hub.GetPS(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.CurrentQuestion == nil || s.CurrentQuestion.ID != second.ID {
+			t.Fatalf("got question %v after moving on, want %d", s.CurrentQuestion, second.ID)
+		}
+		hub.timers.stop(code)
+	})
+}
+
+func TestQuestionTimerAfterReorder(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		first := models.NewQuestion(0, "Capital of France?", "", "", "Paris", 1, 1)
+		second := models.NewQuestion(0, "Capital of Italy?", "", "", "Rome", 1, 1)
+		code := startTestSession(t, hub, qm, nil, first, second)
+		err := hub.SetPSAutoReveal(qm, code, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The timer runs for the first question, which isn't the current one anymore once it expires
+		err = hub.ReorderQuestions(qm, first.QuizID, []uint{second.ID, first.ID})
+		if err != nil {
+			t.Fatal(err)
+		}
+		awaitTimerStopped(t, hub.timers, code)
+		s, err := hub.GetPS(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if s.CurrentAnswer != "" {
+			t.Fatalf("got answer %q revealed for a question moved away", s.CurrentAnswer)
+		}
+	})
+}
+
+func TestAutopilotAfterQuestionsTakenOut(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		qm := logIn(t, hub, "quizmaster@example.com")
+		code := startTestAutopilot(t, hub, qm)
+		s, err := hub.db.GetPlaySession(code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		qq, err := hub.db.GetQuestionsByQuiz(s.QuizID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.UpdateQuestion(qq[0])
+
+		// The autopilot's question goes while its timer runs, the timer runs out on nothing
+		err = hub.DeleteQuestion(qm, qq[0].ID, s.QuizID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		endsAt := time.Now().Add(100 * time.Millisecond)
+		s.QuestionEndsAt = &endsAt
+		hub.syncTimer(s)
+		awaitTimerStopped(t, hub.timers, code)
+		got, err := hub.GetPS(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.CurrentAnswer != "" || got.AutopilotStep != models.AutopilotQuestion {
+			t.Fatalf("got the autopilot at %q with answer %q, want it left at the question", got.AutopilotStep, got.CurrentAnswer)
+		}
+		err = hub.PausePSAutopilot(qm, code)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
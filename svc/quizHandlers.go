@@ -6,12 +6,16 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/tchaudhry91/laqz/svc/models"
 	"gorm.io/gorm"
 )
 
+// maxListLimit is the most quizzes a single search or listing page may ask for
+const maxListLimit = 100
+
 func (s *QServer) CreateQuiz() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		type Request struct {
@@ -78,47 +82,119 @@ func (s *QServer) GetQuiz() http.HandlerFunc {
 	}
 }
 
-func (s *QServer) GetQuizzes() http.HandlerFunc {
+func (s *QServer) ForkQuiz() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		type Response struct {
-			Quizzes []*models.Quiz `json:"quizzes,omitempty"`
+			Quiz *models.Quiz `json:"quiz,omitempty"`
 		}
-		resp := Response{
-			Quizzes: []*models.Quiz{},
+
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
 		}
-		qqz, err := s.hub.GetPublicQuizzes(req.Context())
+		qz, err := s.hub.ForkQuiz(req.Context(), uint(id))
 		if err != nil {
 			if errors.Is(err, gorm.ErrRecordNotFound) {
-				s.respond(w, req, resp, http.StatusOK, nil)
+				s.respond(w, req, nil, http.StatusNotFound, nil)
+				return
+			}
+			if errors.Is(err, NotPermittedError) {
+				s.respond(w, req, nil, http.StatusForbidden, err)
 				return
 			}
 			s.respond(w, req, nil, http.StatusInternalServerError, err)
 			return
 		}
-		resp.Quizzes = qqz
-		s.respond(w, req, resp, http.StatusOK, nil)
+		s.respond(w, req, Response{Quiz: qz}, http.StatusCreated, nil)
 	}
 }
 
-func (s *QServer) GetMyQuizzes() http.HandlerFunc {
+// listOptions reads the paging, sorting and tag filter parameters of a quiz listing
+func listOptions(req *http.Request) (opts models.QuizListOptions, err error) {
+	query := req.URL.Query()
+	opts = models.QuizListOptions{
+		Sort:   models.QuizSort(query.Get("sort")),
+		Tags:   query["tag"],
+		Cursor: query.Get("cursor"),
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		opts.Limit, err = strconv.Atoi(limitStr)
+		if err != nil || opts.Limit <= 0 || opts.Limit > maxListLimit {
+			return opts, fmt.Errorf("Bad limit supplied, it must be between 1 and %d", maxListLimit)
+		}
+	}
+	return opts, nil
+}
+
+func (s *QServer) GetQuizzes() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		opts, err := listOptions(req)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		page, err := s.hub.GetPublicQuizzes(req.Context(), opts)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidListOptions) {
+				s.respond(w, req, nil, http.StatusBadRequest, err)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, page, http.StatusOK, nil)
+	}
+}
+
+func (s *QServer) SearchQuizzes() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		type Response struct {
 			Quizzes []*models.Quiz `json:"quizzes"`
 		}
-		resp := Response{
-			Quizzes: []*models.Quiz{},
+		query := strings.TrimSpace(req.URL.Query().Get("q"))
+		if query == "" {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("You must supply a search query"))
+			return
+		}
+		limit := models.DefaultSearchLimit
+		if limitStr := req.URL.Query().Get("limit"); limitStr != "" {
+			l, err := strconv.Atoi(limitStr)
+			if err != nil || l <= 0 || l > maxListLimit {
+				s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad limit supplied, it must be between 1 and %d", maxListLimit))
+				return
+			}
+			limit = l
 		}
-		qqz, err := s.hub.GetMyQuizzes(req.Context())
+		qqz, err := s.hub.SearchQuizzes(req.Context(), query, limit)
 		if err != nil {
-			if errors.Is(err, gorm.ErrRecordNotFound) {
-				s.respond(w, req, resp, http.StatusOK, nil)
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, Response{Quizzes: qqz}, http.StatusOK, nil)
+	}
+}
+
+func (s *QServer) GetMyQuizzes() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		opts, err := listOptions(req)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		page, err := s.hub.GetMyQuizzes(req.Context(), opts)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidListOptions) {
+				s.respond(w, req, nil, http.StatusBadRequest, err)
 				return
 			}
 			s.respond(w, req, nil, http.StatusInternalServerError, err)
 			return
 		}
-		resp.Quizzes = qqz
-		s.respond(w, req, resp, http.StatusOK, nil)
+		s.respond(w, req, page, http.StatusOK, nil)
 	}
 }
 
@@ -258,6 +334,10 @@ func (s *QServer) GetQuestion() http.HandlerFunc {
 				s.respond(w, req, resp, http.StatusNotFound, nil)
 				return
 			}
+			if errors.Is(err, NotPermittedError) {
+				s.respond(w, req, nil, http.StatusForbidden, err)
+				return
+			}
 			s.logger.Log("err", err)
 			s.respond(w, req, nil, http.StatusInternalServerError, err)
 			return
@@ -271,13 +351,18 @@ func (s *QServer) GetQuestion() http.HandlerFunc {
 func (s *QServer) AddQuestion() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		type Request struct {
-			QuizID       uint   `json:"quiz_id,omitempty"`
-			Text         string `json:"text,omitempty"`
-			ImageLink    string `json:"image_link,omitempty"`
-			AudioLink    string `json:"audio_link,omitempty"`
-			Answer       string `json:"answer,omitempty"`
-			Points       uint   `json:"points,omitempty"`
-			TimerSeconds uint   `json:"timer_seconds,omitempty"`
+			QuizID       uint                   `json:"quiz_id,omitempty"`
+			Type         models.QuestionType    `json:"type,omitempty"`
+			Text         string                 `json:"text,omitempty"`
+			ImageLink    string                 `json:"image_link,omitempty"`
+			AudioLink    string                 `json:"audio_link,omitempty"`
+			Answer       string                 `json:"answer,omitempty"`
+			Alternatives []string               `json:"alternatives,omitempty"`
+			Options      models.QuestionOptions `json:"options,omitempty"`
+			Numeric      *models.NumericAnswer  `json:"numeric,omitempty"`
+			Points       uint                   `json:"points,omitempty"`
+			TimerSeconds uint                   `json:"timer_seconds,omitempty"`
+			RoundID      *uint                  `json:"round_id,omitempty"`
 		}
 
 		type Response struct {
@@ -292,16 +377,23 @@ func (s *QServer) AddQuestion() http.HandlerFunc {
 			return
 		}
 
-		if r.Text == "" || r.Answer == "" {
-			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("You must supply atleast some text and an answer"))
+		q := models.NewQuestion(r.QuizID, r.Text, r.ImageLink, r.AudioLink, r.Answer, r.Points, r.TimerSeconds)
+		setQuestionType(q, r.Type, r.Options, r.Numeric)
+		q.SetAlternatives(r.Alternatives)
+		q.RoundID = r.RoundID
+		err = q.Validate()
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
 			return
 		}
 
-		q := models.NewQuestion(r.QuizID, r.Text, r.ImageLink, r.AudioLink, r.Answer, r.Points, r.TimerSeconds)
-
 		err = s.hub.AddQuestion(req.Context(), q)
 
 		if err != nil {
+			if errors.Is(err, models.ErrInvalidRound) {
+				s.respond(w, req, nil, http.StatusBadRequest, err)
+				return
+			}
 			s.respond(w, req, nil, http.StatusInternalServerError, err)
 			return
 		}
@@ -311,17 +403,133 @@ func (s *QServer) AddQuestion() http.HandlerFunc {
 	}
 }
 
+// setQuestionType applies the type of a question request. Multiple choice and numeric questions
+// take their answer from the options or the numeric answer, anything that doesn't belong to the
+// type is kept so Validate can reject it.
+func setQuestionType(q *models.Question, t models.QuestionType, oo models.QuestionOptions, n *models.NumericAnswer) {
+	switch {
+	case t == models.QuestionTypeMultipleChoice:
+		q.SetOptions(oo)
+		q.Numeric = n
+	case t == models.QuestionTypeNumeric && n != nil:
+		q.SetNumeric(*n)
+		q.Options = oo
+	default:
+		if t != "" {
+			q.Type = t
+		}
+		q.Options = oo
+		q.Numeric = n
+	}
+}
+
+// ImportQuestionsCSV appends the questions of an uploaded CSV file to a quiz, see
+// models.ParseQuestionCSV for the format. Nothing is imported unless every row is valid.
+// With dry_run=true the file is checked and the result reported without importing it.
+func (s *QServer) ImportQuestionsCSV() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			DryRun   bool             `json:"dry_run"`
+			Imported int              `json:"imported"`
+			Errors   []*models.CSVRow `json:"errors,omitempty"`
+		}
+
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		resp := Response{}
+		resp.DryRun, _ = strconv.ParseBool(req.URL.Query().Get("dry_run"))
+
+		err = req.ParseMultipartForm(10 << 20)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		defer req.MultipartForm.RemoveAll()
+		file, _, err := req.FormFile("csv")
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		defer file.Close()
+
+		rows, err := models.ParseQuestionCSV(file)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		qq := []*models.Question{}
+		for _, row := range rows {
+			if len(row.Errors) > 0 {
+				resp.Errors = append(resp.Errors, row)
+				continue
+			}
+			qq = append(qq, row.Question)
+		}
+		if len(resp.Errors) > 0 {
+			s.respond(w, req, resp, http.StatusUnprocessableEntity, nil)
+			return
+		}
+
+		err = s.hub.ImportQuestions(req.Context(), uint(id), qq, resp.DryRun)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				s.respond(w, req, nil, http.StatusNotFound, err)
+				return
+			}
+			if errors.Is(err, NotPermittedError) {
+				s.respond(w, req, nil, http.StatusForbidden, err)
+				return
+			}
+			if errors.Is(err, models.ErrInvalidQuestion) || errors.Is(err, models.ErrInvalidOptions) || errors.Is(err, models.ErrInvalidNumeric) {
+				s.respond(w, req, nil, http.StatusBadRequest, err)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		resp.Imported = len(qq)
+		if resp.DryRun {
+			s.respond(w, req, resp, http.StatusOK, nil)
+			return
+		}
+		s.respond(w, req, resp, http.StatusCreated, nil)
+	}
+}
+
+// respondQuestionErr maps the errors of reading and editing a question of a quiz to a status
+func (s *QServer) respondQuestionErr(w http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.respond(w, req, nil, http.StatusNotFound, nil)
+		return
+	}
+	if errors.Is(err, NotPermittedError) {
+		s.respond(w, req, nil, http.StatusForbidden, err)
+		return
+	}
+	s.respond(w, req, nil, http.StatusInternalServerError, err)
+}
+
 func (s *QServer) UpdateQuestion() http.HandlerFunc {
 	return func(w http.ResponseWriter, req *http.Request) {
 		type Request struct {
-			ID           uint   `json:"id,omitempty"`
-			QuizID       uint   `json:"quiz_id,omitempty"`
-			Text         string `json:"text,omitempty"`
-			ImageLink    string `json:"image_link,omitempty"`
-			AudioLink    string `json:"audio_link,omitempty"`
-			Answer       string `json:"answer,omitempty"`
-			Points       uint   `json:"points,omitempty"`
-			TimerSeconds uint   `json:"timer_seconds,omitempty"`
+			ID           uint                   `json:"id,omitempty"`
+			QuizID       uint                   `json:"quiz_id,omitempty"`
+			Type         models.QuestionType    `json:"type,omitempty"`
+			Text         string                 `json:"text,omitempty"`
+			ImageLink    string                 `json:"image_link,omitempty"`
+			AudioLink    string                 `json:"audio_link,omitempty"`
+			Answer       string                 `json:"answer,omitempty"`
+			Alternatives []string               `json:"alternatives,omitempty"`
+			Options      models.QuestionOptions `json:"options,omitempty"`
+			Numeric      *models.NumericAnswer  `json:"numeric,omitempty"`
+			Points       uint                   `json:"points,omitempty"`
+			TimerSeconds uint                   `json:"timer_seconds,omitempty"`
 		}
 
 		type Response struct {
@@ -336,27 +544,36 @@ func (s *QServer) UpdateQuestion() http.HandlerFunc {
 			return
 		}
 
-		if r.Text == "" || r.Answer == "" {
-			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("You must supply atleast some text and an answer"))
+		edited := models.NewQuestion(r.QuizID, r.Text, r.ImageLink, r.AudioLink, r.Answer, r.Points, r.TimerSeconds)
+		setQuestionType(edited, r.Type, r.Options, r.Numeric)
+		edited.SetAlternatives(r.Alternatives)
+		err = edited.Validate()
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
 			return
 		}
 
 		q, err := s.hub.GetQuestion(req.Context(), r.ID, r.QuizID)
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondQuestionErr(w, req, err)
+			return
 		}
 
+		q.Type = edited.Type
 		q.Text = r.Text
 		q.ImageLink = r.ImageLink
 		q.AudioLink = r.AudioLink
-		q.Answer = r.Answer
+		q.Answer = edited.Answer
+		q.Alternatives = edited.Alternatives
+		q.Options = edited.Options
+		q.Numeric = edited.Numeric
 		q.Points = r.Points
 		q.TimerSeconds = r.TimerSeconds
 
 		err = s.hub.UpdateQuestion(req.Context(), r.ID, r.QuizID, q)
 
 		if err != nil {
-			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			s.respondQuestionErr(w, req, err)
 			return
 		}
 
@@ -400,3 +617,141 @@ func (s *QServer) GetQuizQuestions() http.HandlerFunc {
 		s.respond(w, req, resp, http.StatusOK, nil)
 	}
 }
+
+func (s *QServer) ReorderQuestions() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			QuizID      uint   `json:"quiz_id,omitempty"`
+			QuestionIDs []uint `json:"question_ids,omitempty"`
+		}
+		type Response struct {
+			Err string `json:"err,omitempty"`
+		}
+
+		r := Request{}
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+
+		defer req.Body.Close()
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		r.QuizID = uint(id)
+
+		resp := Response{}
+		err = s.hub.ReorderQuestions(req.Context(), r.QuizID, r.QuestionIDs)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				resp.Err = err.Error()
+				s.respond(w, req, resp, http.StatusNotFound, nil)
+				return
+			}
+			if errors.Is(err, InvalidOrderError) {
+				s.respond(w, req, nil, http.StatusBadRequest, err)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+func (s *QServer) GetTrash() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Trash *models.Trash `json:"trash"`
+		}
+		t, err := s.hub.GetTrash(req.Context())
+		if err != nil {
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, Response{Trash: t}, http.StatusOK, nil)
+	}
+}
+
+func (s *QServer) RestoreQuiz() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			ID uint `json:"id,omitempty"`
+		}
+		type Response struct {
+			Err string `json:"err,omitempty"`
+		}
+
+		r := Request{}
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		resp := Response{}
+		r.ID = uint(id)
+		err = s.hub.RestoreQuiz(req.Context(), r.ID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				resp.Err = err.Error()
+				s.respond(w, req, resp, http.StatusNotFound, nil)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+func (s *QServer) RestoreQuestion() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			ID     uint `json:"id,omitempty"`
+			QuizID uint `json:"quiz_id,omitempty"`
+		}
+		type Response struct {
+			Err string `json:"err,omitempty"`
+		}
+
+		r := Request{}
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		quizIDStr := params["quiz_id"]
+		var quizID int
+		quizID, err = strconv.Atoi(quizIDStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		resp := Response{}
+		r.ID = uint(id)
+		r.QuizID = uint(quizID)
+		err = s.hub.RestoreQuestion(req.Context(), r.ID, r.QuizID)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				resp.Err = err.Error()
+				s.respond(w, req, resp, http.StatusNotFound, nil)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
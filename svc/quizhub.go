@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
+	"time"
 
 	"github.com/tchaudhry91/laqz/svc/models"
 	"gorm.io/gorm"
@@ -11,6 +14,9 @@ import (
 
 var NotPermittedError = errors.New("User is not permitted for the following action")
 var UserNotFound = errors.New("User not found")
+var InvalidOrderError = errors.New("Invalid question order")
+var InvalidRoleError = errors.New("Invalid collaborator role")
+var InvalidBundleError = errors.New("Invalid quiz bundle")
 
 type contextKey string
 
@@ -20,14 +26,42 @@ type QuizHub interface {
 	CreateQuiz(ctx context.Context, name string, tags []string) (qz *models.Quiz, err error)
 	DeleteQuiz(ctx context.Context, id uint) (err error)
 	GetQuiz(ctx context.Context, id uint) (qz *models.Quiz, err error)
-	GetMyQuizzes(ctx context.Context) (qqz []*models.Quiz, err error)
-	GetPublicQuizzes(ctx context.Context) (qqz []*models.Quiz, err error)
+	GetMyQuizzes(ctx context.Context, opts models.QuizListOptions) (page *models.QuizPage, err error)
+	GetPublicQuizzes(ctx context.Context, opts models.QuizListOptions) (page *models.QuizPage, err error)
+	SearchQuizzes(ctx context.Context, query string, limit int) (qqz []*models.Quiz, err error)
 	AddQuestion(ctx context.Context, q *models.Question) (err error)
 	UpdateQuestion(ctx context.Context, id, quizID uint, q *models.Question) (err error)
 	DeleteQuestion(ctx context.Context, id, quizID uint) (err error)
 	GetQuestions(ctx context.Context, quizID uint) (qq []*models.Question, err error)
 	GetQuestion(ctx context.Context, id, quizID uint) (q *models.Question, err error)
+	ReorderQuestions(ctx context.Context, quizID uint, ids []uint) (err error)
+	GetQuestionBank(ctx context.Context, opts models.QuestionBankOptions) (page *models.QuestionPage, err error)
+	AttachQuestions(ctx context.Context, quizID uint, ids []uint) (err error)
+	GetRounds(ctx context.Context, quizID uint) (rr []*models.Round, err error)
+	CreateRound(ctx context.Context, r *models.Round) (err error)
+	UpdateRound(ctx context.Context, id, quizID uint, r *models.Round) (err error)
+	DeleteRound(ctx context.Context, id, quizID uint) (err error)
+	ReorderRounds(ctx context.Context, quizID uint, ids []uint) (err error)
+	SetRoundQuestions(ctx context.Context, id, quizID uint, questionIDs []uint) (err error)
+	GetTrash(ctx context.Context) (t *models.Trash, err error)
+	RestoreQuiz(ctx context.Context, id uint) (err error)
+	RestoreQuestion(ctx context.Context, id, quizID uint) (err error)
 	ToggleQuizPrivacy(ctx context.Context, id uint) (err error)
+	GetCollaborators(ctx context.Context, quizID uint) (cc []*models.Collaborator, err error)
+	AddCollaborator(ctx context.Context, quizID uint, email string, role models.Role) (err error)
+	RemoveCollaborator(ctx context.Context, quizID uint, email string) (err error)
+	TransferOwnership(ctx context.Context, quizID uint, email string) (err error)
+	ExportQuiz(ctx context.Context, id uint) (b *models.QuizBundle, err error)
+	ImportQuiz(ctx context.Context, b *models.QuizBundle) (qz *models.Quiz, err error)
+	ForkQuiz(ctx context.Context, id uint) (qz *models.Quiz, err error)
+	ImportQuestions(ctx context.Context, quizID uint, qq []*models.Question, dryRun bool) (err error)
+	GetTags(ctx context.Context) (tus []*models.TagUsage, err error)
+	UpdateQuizTags(ctx context.Context, quizID uint, add, remove []string) (err error)
+	UpdateQuestionTags(ctx context.Context, id, quizID uint, add, remove []string) (err error)
+	GetQuizRevisions(ctx context.Context, quizID uint) (rr []*models.QuizRevision, err error)
+	GetQuizRevision(ctx context.Context, quizID, number uint) (r *models.QuizRevision, err error)
+	DiffQuizRevisions(ctx context.Context, quizID, from, to uint) (d *models.QuizDiff, err error)
+	RollbackQuiz(ctx context.Context, quizID, number uint) (err error)
 
 	PlaySessionSVC
 }
@@ -59,18 +93,20 @@ func (hub *QHub) UserContextKey() contextKey {
 }
 
 func (hub *QHub) LogIn(ctx context.Context, user *models.User) (err error) {
-	// Check if user exists
-	u, err := hub.db.GetUserByEmail(user.Email)
-	if err != nil {
-		if !errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("Error while fetching User from database: %w", err)
-		} else {
-			// User Not Found
-			return hub.db.CreateUser(user)
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		// Check if user exists
+		u, err := db.GetUserByEmail(user.Email)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("Error while fetching User from database: %w", err)
+			} else {
+				// User Not Found
+				return db.CreateUser(user)
+			}
 		}
-	}
-	// User Found. Update as necessary
-	return hub.db.UpdateUser(u)
+		// User Found. Update as necessary
+		return db.UpdateUser(u)
+	})
 }
 
 func (hub *QHub) CreateQuiz(ctx context.Context, name string, tags []string) (qz *models.Quiz, err error) {
@@ -78,31 +114,77 @@ func (hub *QHub) CreateQuiz(ctx context.Context, name string, tags []string) (qz
 	if err != nil {
 		return qz, err
 	}
-	u, err = hub.db.GetUserByEmail(u.Email)
+	err = hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		u, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
+
+		tt, err := resolveTags(db, tags, true)
+		if err != nil {
+			return err
+		}
+
+		qz = models.NewQuiz(name, u, tt)
+		err = db.CreateQuiz(qz)
+		if err != nil {
+			return err
+		}
+		qz.Roles = map[uint]models.Role{u.ID: models.RoleOwner}
+		err = db.SetCollaboratorRole(qz.ID, u.ID, models.RoleOwner)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, "created the quiz", qz.ID)
+	})
 	if err != nil {
 		return qz, err
 	}
+	return qz, nil
+}
 
-	// Get Existing Tags
-	tt := []*models.Tag{}
-	for _, t := range tags {
-		tag, err := hub.db.GetTagByName(t)
+// resolveTags normalizes names and looks up the matching tags. Unknown names become new tags
+// when create is set and are skipped otherwise.
+func resolveTags(db models.QuizStore, names []string, create bool) (tt []*models.Tag, err error) {
+	tt = []*models.Tag{}
+	for _, name := range models.NormalizeTagNames(names) {
+		tag, err := db.GetTagByName(name)
 		if err != nil {
 			if !errors.Is(err, gorm.ErrRecordNotFound) {
-				return qz, err
+				return tt, err
+			}
+			if create {
+				tt = append(tt, &models.Tag{Name: name})
 			}
-			tt = append(tt, &models.Tag{Name: t})
 			continue
 		}
 		tt = append(tt, tag)
 	}
+	return tt, nil
+}
 
-	qz = models.NewQuiz(name, u, tt)
-	err = hub.db.CreateQuiz(qz)
+// tagSet holds tags resolved up front, so that records sharing a new tag don't each create it
+type tagSet map[string]*models.Tag
+
+func newTagSet(db models.QuizStore, names []string) (tagSet, error) {
+	tt, err := resolveTags(db, names, true)
 	if err != nil {
-		return qz, err
+		return nil, err
 	}
-	return qz, nil
+	ts := tagSet{}
+	for _, t := range tt {
+		ts[t.Name] = t
+	}
+	return ts, nil
+}
+
+// pick returns the tags for names, which must have been passed to newTagSet
+func (ts tagSet) pick(names []string) []*models.Tag {
+	tt := []*models.Tag{}
+	for _, n := range models.NormalizeTagNames(names) {
+		tt = append(tt, ts[n])
+	}
+	return tt
 }
 
 func (hub *QHub) GetQuiz(ctx context.Context, id uint) (qz *models.Quiz, err error) {
@@ -126,64 +208,131 @@ func (hub *QHub) ToggleQuizPrivacy(ctx context.Context, id uint) (err error) {
 	if err != nil {
 		return err
 	}
-	qz, err := hub.db.GetQuiz(id)
-	if !qz.IsCollaborator(u.Email) {
-		return NotPermittedError
-	}
-	qz.TogglePrivacy()
-	return hub.db.UpdateQuiz(qz)
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(id)
+		if err != nil {
+			return err
+		}
+		if !qz.IsOwner(u.Email) {
+			return NotPermittedError
+		}
+		qz.TogglePrivacy()
+		err = db.UpdateQuiz(qz)
+		if err != nil {
+			return err
+		}
+		action := "made the quiz public"
+		if qz.Private {
+			action = "made the quiz private"
+		}
+		return recordRevision(db, u.Email, action, id)
+	})
 }
 
-func (hub *QHub) GetMyQuizzes(ctx context.Context) (qqz []*models.Quiz, err error) {
+func (hub *QHub) GetMyQuizzes(ctx context.Context, opts models.QuizListOptions) (page *models.QuizPage, err error) {
 	u, err := getUserFromContext(ctx, hub.UserContextKey())
 	if err != nil {
-		return qqz, err
+		return page, err
 	}
-	qqz, err = hub.db.GetQuizzesByUser(u.Email)
+	opts.Tags = models.NormalizeTagNames(opts.Tags)
+	page, err = hub.db.GetQuizzesByUser(u.Email, opts)
 	return
 }
 
-func (hub *QHub) GetPublicQuizzes(ctx context.Context) (qqz []*models.Quiz, err error) {
-	qqz, err = hub.db.GetAllPublicQuizzes()
+func (hub *QHub) GetPublicQuizzes(ctx context.Context, opts models.QuizListOptions) (page *models.QuizPage, err error) {
+	opts.Tags = models.NormalizeTagNames(opts.Tags)
+	page, err = hub.db.GetAllPublicQuizzes(opts)
 	return
 }
 
-func (hub *QHub) DeleteQuiz(ctx context.Context, id uint) (err error) {
+// SearchQuizzes finds the quizzes matching query that the user, or a guest, is allowed to see
+func (hub *QHub) SearchQuizzes(ctx context.Context, query string, limit int) (qqz []*models.Quiz, err error) {
 	u, err := getUserFromContext(ctx, hub.UserContextKey())
 	if err != nil {
-		return err
+		if !errors.Is(err, UserNotFound) {
+			return qqz, err
+		}
+		// Use Guest User
+		u = &models.User{}
 	}
-	qz, err := hub.db.GetQuiz(id)
+	found, err := hub.db.SearchQuizzes(query, u.Email, limit)
 	if err != nil {
-		return err
+		return qqz, err
 	}
-	if !qz.IsCollaborator(u.Email) {
-		return NotPermittedError
+	qqz = []*models.Quiz{}
+	for _, qz := range found {
+		if qz.CanView(u.Email) {
+			qqz = append(qqz, qz)
+		}
 	}
-	return hub.db.DeleteQuiz(id)
+	return qqz, nil
 }
 
-func (hub *QHub) AddQuestion(ctx context.Context, q *models.Question) (err error) {
+func (hub *QHub) DeleteQuiz(ctx context.Context, id uint) (err error) {
 	u, err := getUserFromContext(ctx, hub.UserContextKey())
 	if err != nil {
 		return err
 	}
-	u, err = hub.db.GetUserByEmail(u.Email)
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(id)
+		if err != nil {
+			return err
+		}
+		if !qz.IsOwner(u.Email) {
+			return NotPermittedError
+		}
+		return db.DeleteQuiz(id)
+	})
+}
+
+func (hub *QHub) AddQuestion(ctx context.Context, q *models.Question) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
 	if err != nil {
 		return err
 	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		u, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
 
-	q.UserID = u.ID
+		q.UserID = u.ID
 
-	qz, err := hub.db.GetQuiz(q.QuizID)
-	if err != nil {
-		return err
-	}
-	if !qz.IsCollaborator(u.Email) {
-		return NotPermittedError
-	}
-	qz.AddQuestion(q)
-	return hub.db.UpdateQuiz(qz)
+		qz, err := db.GetQuiz(q.QuizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		if q.RoundID != nil {
+			_, err = quizRound(db, *q.RoundID, qz.ID)
+			if err != nil {
+				return err
+			}
+		}
+		qz.AddQuestion(q)
+		err = db.UpdateQuiz(qz)
+		if err != nil {
+			return err
+		}
+		// New questions go to the end of the quiz, or of their round
+		ids := []uint{}
+		for _, existing := range qz.Questions {
+			ids = append(ids, existing.ID)
+		}
+		err = db.SetQuestionPositions(qz.ID, ids)
+		if err != nil {
+			return err
+		}
+		if q.RoundID != nil {
+			err = db.SetQuestionRound(qz.ID, q.ID, q.RoundID)
+			if err != nil {
+				return err
+			}
+		}
+		return recordRevision(db, u.Email, fmt.Sprintf("added question %d", q.ID), qz.ID)
+	})
 }
 
 func (hub *QHub) GetQuestions(ctx context.Context, quizID uint) (qq []*models.Question, err error) {
@@ -214,6 +363,10 @@ func (hub *QHub) GetQuestion(ctx context.Context, id uint, quizID uint) (q *mode
 	if !qz.IsCollaborator(u.Email) {
 		return q, NotPermittedError
 	}
+	// The role is on the quiz, questions of other quizzes stay out of reach
+	if !qz.HasQuestion(id) {
+		return q, gorm.ErrRecordNotFound
+	}
 	return hub.db.GetQuestion(id)
 }
 
@@ -222,27 +375,1172 @@ func (hub *QHub) UpdateQuestion(ctx context.Context, id, quizID uint, q *models.
 	if err != nil {
 		return err
 	}
-	qz, err := hub.db.GetQuiz(quizID)
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		if !qz.HasQuestion(id) {
+			return gorm.ErrRecordNotFound
+		}
+		err = db.UpdateQuestion(id, q)
+		if err != nil {
+			return err
+		}
+		// Questions can be shared, the edit shows up in every quiz holding the question
+		quizIDs, err := db.GetQuestionQuizIDs(id)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, fmt.Sprintf("edited question %d", id), quizIDs...)
+	})
+}
+
+func (hub *QHub) DeleteQuestion(ctx context.Context, id, quizID uint) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
 	if err != nil {
 		return err
 	}
-	if !qz.IsCollaborator(u.Email) {
-		return NotPermittedError
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		err = db.DeleteQuestion(id, quizID)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, fmt.Sprintf("removed question %d", id), quizID)
+	})
+}
+
+// ReorderQuestions moves the given questions to the front of the quiz in the order supplied.
+// The questions left out follow in their current order, so passing every ID reorders the whole quiz.
+func (hub *QHub) ReorderQuestions(ctx context.Context, quizID uint, ids []uint) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		inQuiz := map[uint]bool{}
+		for _, q := range qz.Questions {
+			inQuiz[q.ID] = true
+		}
+		order := []uint{}
+		seen := map[uint]bool{}
+		for _, id := range ids {
+			if !inQuiz[id] {
+				return fmt.Errorf("%w: question %d is not part of this quiz", InvalidOrderError, id)
+			}
+			if seen[id] {
+				return fmt.Errorf("%w: question %d is listed more than once", InvalidOrderError, id)
+			}
+			seen[id] = true
+			order = append(order, id)
+		}
+		for _, q := range qz.Questions {
+			if !seen[q.ID] {
+				order = append(order, q.ID)
+			}
+		}
+		err = db.SetQuestionPositions(quizID, order)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, "reordered the questions", quizID)
+	})
+}
+
+// GetQuestionBank lists the questions the user has written, whichever quizzes they are part of
+func (hub *QHub) GetQuestionBank(ctx context.Context, opts models.QuestionBankOptions) (page *models.QuestionPage, err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return page, err
+	}
+	u, err = hub.db.GetUserByEmail(u.Email)
+	if err != nil {
+		return page, err
 	}
-	return hub.db.UpdateQuestion(id, q)
+	opts.Tags = models.NormalizeTagNames(opts.Tags)
+	return hub.db.GetQuestionBank(u.ID, opts)
 }
 
-func (hub *QHub) DeleteQuestion(ctx context.Context, id, quizID uint) (err error) {
+// AttachQuestions adds questions from the user's bank to the end of a quiz without copying them,
+// so later edits show up in every quiz sharing them. Questions already in the quiz are left where
+// they are.
+func (hub *QHub) AttachQuestions(ctx context.Context, quizID uint, ids []uint) (err error) {
 	u, err := getUserFromContext(ctx, hub.UserContextKey())
 	if err != nil {
 		return err
 	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		u, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		inQuiz := map[uint]bool{}
+		for _, q := range qz.Questions {
+			inQuiz[q.ID] = true
+		}
+		for _, id := range ids {
+			if inQuiz[id] {
+				continue
+			}
+			q, err := db.GetQuestion(id)
+			if err != nil {
+				return err
+			}
+			if q.UserID != u.ID {
+				return fmt.Errorf("%w: question %d is not in your question bank", NotPermittedError, id)
+			}
+			inQuiz[id] = true
+			qz.AddQuestion(q)
+		}
+		err = db.UpdateQuiz(qz)
+		if err != nil {
+			return err
+		}
+		order := []uint{}
+		for _, q := range qz.Questions {
+			order = append(order, q.ID)
+		}
+		err = db.SetQuestionPositions(quizID, order)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, "attached questions from the question bank", quizID)
+	})
+}
+
+// GetRounds lists the rounds of a quiz in play order to the users who can view it
+func (hub *QHub) GetRounds(ctx context.Context, quizID uint) (rr []*models.Round, err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return rr, err
+	}
 	qz, err := hub.db.GetQuiz(quizID)
+	if err != nil {
+		return rr, err
+	}
+	if !qz.CanView(u.Email) {
+		return rr, NotPermittedError
+	}
+	return qz.Rounds, nil
+}
+
+// CreateRound adds a round without questions after the last round of its quiz
+func (hub *QHub) CreateRound(ctx context.Context, r *models.Round) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
 	if err != nil {
 		return err
 	}
-	if !qz.IsCollaborator(u.Email) {
-		return NotPermittedError
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(r.QuizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		err = checkRound(qz, r)
+		if err != nil {
+			return err
+		}
+		err = db.CreateRound(r)
+		if err != nil {
+			return err
+		}
+		r.QuestionIDs = []uint{}
+		return recordRevision(db, u.Email, fmt.Sprintf("added round %q", r.Title), qz.ID)
+	})
+}
+
+// UpdateRound changes the title and the settings of a round, its questions and place stay
+func (hub *QHub) UpdateRound(ctx context.Context, id, quizID uint, r *models.Round) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		existing, err := quizRound(db, id, quizID)
+		if err != nil {
+			return err
+		}
+		r.ID, r.QuizID, r.Position = existing.ID, existing.QuizID, existing.Position
+		err = checkRound(qz, r)
+		if err != nil {
+			return err
+		}
+		err = db.UpdateRound(r)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, fmt.Sprintf("edited round %q", r.Title), quizID)
+	})
+}
+
+// DeleteRound removes a round from a quiz, its questions stay in the quiz outside of any round
+func (hub *QHub) DeleteRound(ctx context.Context, id, quizID uint) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		r, err := quizRound(db, id, quizID)
+		if err != nil {
+			return err
+		}
+		err = db.DeleteRound(id)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, fmt.Sprintf("removed round %q", r.Title), quizID)
+	})
+}
+
+// ReorderRounds moves the given rounds to the front of the quiz in the order supplied, like
+// ReorderQuestions does for questions
+func (hub *QHub) ReorderRounds(ctx context.Context, quizID uint, ids []uint) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		inQuiz := map[uint]bool{}
+		for _, r := range qz.Rounds {
+			inQuiz[r.ID] = true
+		}
+		order := []uint{}
+		seen := map[uint]bool{}
+		for _, id := range ids {
+			if !inQuiz[id] {
+				return fmt.Errorf("%w: round %d is not part of this quiz", InvalidOrderError, id)
+			}
+			if seen[id] {
+				return fmt.Errorf("%w: round %d is listed more than once", InvalidOrderError, id)
+			}
+			seen[id] = true
+			order = append(order, id)
+		}
+		for _, r := range qz.Rounds {
+			if !seen[r.ID] {
+				order = append(order, r.ID)
+			}
+		}
+		err = db.SetRoundPositions(quizID, order)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, "reordered the rounds", quizID)
+	})
+}
+
+// SetRoundQuestions makes the given questions of the quiz the questions of a round, in the order
+// supplied. Questions that were in the round and aren't listed stay in the quiz outside of any round,
+// listed ones move over from the round they were in.
+func (hub *QHub) SetRoundQuestions(ctx context.Context, id, quizID uint, questionIDs []uint) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		r, err := quizRound(db, id, quizID)
+		if err != nil {
+			return err
+		}
+		inQuiz := map[uint]bool{}
+		for _, q := range qz.Questions {
+			inQuiz[q.ID] = true
+		}
+		listed := map[uint]bool{}
+		for _, qid := range questionIDs {
+			if !inQuiz[qid] {
+				return fmt.Errorf("%w: question %d is not part of this quiz", InvalidOrderError, qid)
+			}
+			if listed[qid] {
+				return fmt.Errorf("%w: question %d is listed more than once", InvalidOrderError, qid)
+			}
+			listed[qid] = true
+		}
+		for _, qid := range r.QuestionIDs {
+			if !listed[qid] {
+				err = db.SetQuestionRound(quizID, qid, nil)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		for _, qid := range questionIDs {
+			err = db.SetQuestionRound(quizID, qid, &r.ID)
+			if err != nil {
+				return err
+			}
+		}
+		// Positions only order questions within their round, the others keep their relative order
+		order := append([]uint{}, questionIDs...)
+		for _, q := range qz.Questions {
+			if !listed[q.ID] {
+				order = append(order, q.ID)
+			}
+		}
+		err = db.SetQuestionPositions(quizID, order)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, fmt.Sprintf("changed the questions of round %q", r.Title), quizID)
+	})
+}
+
+// quizRound loads a round of the quiz, rounds of other quizzes are not found
+func quizRound(db models.QuizStore, id, quizID uint) (r *models.Round, err error) {
+	r, err = db.GetRound(id)
+	if err != nil {
+		return r, err
+	}
+	if r.QuizID != quizID {
+		return r, fmt.Errorf("round %d of quiz %d: %w", id, quizID, gorm.ErrRecordNotFound)
+	}
+	return r, nil
+}
+
+// checkRound validates r and makes sure no other round of the quiz has its title, so rounds can
+// be told apart by title in bundles and revisions
+func checkRound(qz *models.Quiz, r *models.Round) error {
+	r.Title = strings.TrimSpace(r.Title)
+	err := r.Validate()
+	if err != nil {
+		return err
+	}
+	for _, other := range qz.Rounds {
+		if other.ID != r.ID && other.Title == r.Title {
+			return fmt.Errorf("%w: the quiz already has a round called %q", models.ErrInvalidRound, r.Title)
+		}
+	}
+	return nil
+}
+
+// GetTrash lists the deleted quizzes and removed questions the user can restore
+func (hub *QHub) GetTrash(ctx context.Context) (t *models.Trash, err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return t, err
+	}
+	t = &models.Trash{}
+	t.Quizzes, err = hub.db.GetTrashedQuizzes(u.Email)
+	if err != nil {
+		return t, err
+	}
+	t.Questions, err = hub.db.GetTrashedQuestions(u.Email)
+	return t, err
+}
+
+func (hub *QHub) RestoreQuiz(ctx context.Context, id uint) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetTrashedQuiz(id)
+		if err != nil {
+			return err
+		}
+		if !qz.IsOwner(u.Email) {
+			return NotPermittedError
+		}
+		return db.RestoreQuiz(id)
+	})
+}
+
+func (hub *QHub) RestoreQuestion(ctx context.Context, id, quizID uint) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		err = db.RestoreQuestion(id, quizID)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, fmt.Sprintf("restored question %d", id), quizID)
+	})
+}
+
+// GetCollaborators lists everyone working on a quiz with their role, to anyone who is one of them
+func (hub *QHub) GetCollaborators(ctx context.Context, quizID uint) (cc []*models.Collaborator, err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return cc, err
+	}
+	qz, err := hub.db.GetQuiz(quizID)
+	if err != nil {
+		return cc, err
+	}
+	if !qz.IsCollaborator(u.Email) {
+		return cc, NotPermittedError
+	}
+	cc = []*models.Collaborator{}
+	for _, c := range qz.Collaborators {
+		cc = append(cc, &models.Collaborator{Email: c.Email, Name: c.Name, AvatarURL: c.AvatarURL, Role: qz.Roles[c.ID]})
+	}
+	return cc, nil
+}
+
+// AddCollaborator invites a registered user as an editor or viewer, or changes the role of an
+// existing collaborator. Only owners may do this, ownership itself moves with TransferOwnership.
+func (hub *QHub) AddCollaborator(ctx context.Context, quizID uint, email string, role models.Role) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	if role != models.RoleEditor && role != models.RoleViewer {
+		return fmt.Errorf("%w: %q, expected %q or %q", InvalidRoleError, role, models.RoleEditor, models.RoleViewer)
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.IsOwner(u.Email) {
+			return NotPermittedError
+		}
+		if qz.IsOwner(email) {
+			return fmt.Errorf("%w: the owner has to transfer ownership first", InvalidRoleError)
+		}
+		invitee, err := db.GetUserByEmail(email)
+		if err != nil {
+			return err
+		}
+		action := fmt.Sprintf("added %s as %s", email, role)
+		if qz.IsCollaborator(email) {
+			action = fmt.Sprintf("changed the role of %s to %s", email, role)
+		}
+		err = db.SetCollaboratorRole(quizID, invitee.ID, role)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, action, quizID)
+	})
+}
+
+// RemoveCollaborator takes a user off a quiz. Owners can remove anyone but themselves,
+// everybody else can only leave.
+func (hub *QHub) RemoveCollaborator(ctx context.Context, quizID uint, email string) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.IsOwner(u.Email) && u.Email != email {
+			return NotPermittedError
+		}
+		if qz.IsOwner(email) {
+			return fmt.Errorf("%w: the owner has to transfer ownership first", InvalidRoleError)
+		}
+		for _, c := range qz.Collaborators {
+			if c.Email != email {
+				continue
+			}
+			err = db.RemoveCollaborator(quizID, c.ID)
+			if err != nil {
+				return err
+			}
+			action := fmt.Sprintf("removed %s", email)
+			if u.Email == email {
+				action = "left the quiz"
+			}
+			return recordRevision(db, u.Email, action, quizID)
+		}
+		return gorm.ErrRecordNotFound
+	})
+}
+
+// TransferOwnership hands the quiz to another registered user, the previous owner stays on as an editor
+func (hub *QHub) TransferOwnership(ctx context.Context, quizID uint, email string) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.IsOwner(u.Email) {
+			return NotPermittedError
+		}
+		if u.Email == email {
+			return nil
+		}
+		newOwner, err := db.GetUserByEmail(email)
+		if err != nil {
+			return err
+		}
+		owner, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
+		err = db.SetCollaboratorRole(quizID, newOwner.ID, models.RoleOwner)
+		if err != nil {
+			return err
+		}
+		err = db.SetCollaboratorRole(quizID, owner.ID, models.RoleEditor)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, fmt.Sprintf("handed ownership to %s", email), quizID)
+	})
+}
+
+// ExportQuiz captures a quiz with its questions for moving it to another instance. Since the
+// answers go with it, only collaborators may export.
+func (hub *QHub) ExportQuiz(ctx context.Context, id uint) (b *models.QuizBundle, err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return b, err
+	}
+	qz, err := hub.db.GetQuiz(id)
+	if err != nil {
+		return b, err
+	}
+	if !qz.IsCollaborator(u.Email) {
+		return b, NotPermittedError
+	}
+	return models.NewQuizBundle(qz, qz.Questions), nil
+}
+
+// ImportQuiz recreates a bundled quiz as a new private quiz owned by the user. If the name is
+// taken the quiz is imported under a numbered variant of it.
+func (hub *QHub) ImportQuiz(ctx context.Context, b *models.QuizBundle) (qz *models.Quiz, err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return qz, err
+	}
+	if b.Version != models.BundleVersion {
+		return qz, fmt.Errorf("%w: unsupported version %d", InvalidBundleError, b.Version)
+	}
+	if strings.TrimSpace(b.Name) == "" {
+		return qz, fmt.Errorf("%w: the quiz has no name", InvalidBundleError)
+	}
+	for i, bq := range b.Questions {
+		err = bq.Question(0).Validate()
+		if err != nil {
+			return qz, fmt.Errorf("%w: question %d: %v", InvalidBundleError, i+1, err)
+		}
+	}
+	err = b.ValidateRounds()
+	if err != nil {
+		return qz, fmt.Errorf("%w: %v", InvalidBundleError, err)
+	}
+	err = hub.db.WithTx(ctx, func(db models.QuizStore) (err error) {
+		qz, err = createBundledQuiz(db, u.Email, b, nil)
+		return err
+	})
+	return qz, err
+}
+
+// ForkQuiz copies a quiz the user can view, with its questions and tags, into a new private quiz
+// they own. The copy remembers the quiz it was forked from.
+func (hub *QHub) ForkQuiz(ctx context.Context, id uint) (qz *models.Quiz, err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return qz, err
+	}
+	err = hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		src, err := db.GetQuiz(id)
+		if err != nil {
+			return err
+		}
+		if !src.CanView(u.Email) {
+			return NotPermittedError
+		}
+		qz, err = createBundledQuiz(db, u.Email, models.NewQuizBundle(src, src.Questions), &src.ID)
+		return err
+	})
+	return qz, err
+}
+
+// createBundledQuiz creates the quiz b describes as a private quiz owned by email, under a numbered
+// variant of its name if that is taken. The questions are expected to be valid.
+func createBundledQuiz(db models.QuizStore, email string, b *models.QuizBundle, forkedFrom *uint) (qz *models.Quiz, err error) {
+	u, err := db.GetUserByEmail(email)
+	if err != nil {
+		return qz, err
+	}
+	name, err := freeQuizName(db, b.Name)
+	if err != nil {
+		return qz, err
+	}
+
+	names := append([]string{}, b.Tags...)
+	for _, bq := range b.Questions {
+		names = append(names, bq.Tags...)
+	}
+	tags, err := newTagSet(db, names)
+	if err != nil {
+		return qz, err
+	}
+
+	qz = models.NewQuiz(name, u, tags.pick(b.Tags))
+	qz.ForkedFromID = forkedFrom
+	err = db.CreateQuiz(qz)
+	if err != nil {
+		return qz, err
+	}
+	qz.Roles = map[uint]models.Role{u.ID: models.RoleOwner}
+	err = db.SetCollaboratorRole(qz.ID, u.ID, models.RoleOwner)
+	if err != nil {
+		return qz, err
+	}
+	rounds := map[string]*uint{}
+	for _, br := range b.Rounds {
+		r := br.Round(qz.ID)
+		err = db.CreateRound(r)
+		if err != nil {
+			return qz, err
+		}
+		id := r.ID
+		rounds[r.Title] = &id
+	}
+	for _, bq := range b.Questions {
+		q := bq.Question(qz.ID)
+		q.UserID = u.ID
+		q.Tags = tags.pick(bq.Tags)
+		qz.AddQuestion(q)
+	}
+	err = db.UpdateQuiz(qz)
+	if err != nil {
+		return qz, err
+	}
+	ids := []uint{}
+	for _, q := range qz.Questions {
+		ids = append(ids, q.ID)
+	}
+	err = db.SetQuestionPositions(qz.ID, ids)
+	if err != nil {
+		return qz, err
+	}
+	for i, bq := range b.Questions {
+		if round := rounds[strings.TrimSpace(bq.Round)]; round != nil {
+			err = db.SetQuestionRound(qz.ID, qz.Questions[i].ID, round)
+			if err != nil {
+				return qz, err
+			}
+		}
+	}
+	action := "imported the quiz"
+	if forkedFrom != nil {
+		action = fmt.Sprintf("forked quiz %d", *forkedFrom)
+	}
+	return qz, recordRevision(db, email, action, qz.ID)
+}
+
+// errDryRun rolls back the transaction of a dry run
+var errDryRun = errors.New("dry run")
+
+// ImportQuestions appends questions to the end of a quiz in one go. Their tags only need names.
+// A dry run does all the work and rolls it back, so it fails exactly when the real import would.
+func (hub *QHub) ImportQuestions(ctx context.Context, quizID uint, qq []*models.Question, dryRun bool) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	for _, q := range qq {
+		err = q.Validate()
+		if err != nil {
+			return err
+		}
+	}
+	err = hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		u, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		names := []string{}
+		for _, q := range qq {
+			for _, t := range q.Tags {
+				names = append(names, t.Name)
+			}
+		}
+		tags, err := newTagSet(db, names)
+		if err != nil {
+			return err
+		}
+		for _, q := range qq {
+			names := []string{}
+			for _, t := range q.Tags {
+				names = append(names, t.Name)
+			}
+			q.Tags = tags.pick(names)
+			q.QuizID = quizID
+			q.UserID = u.ID
+			qz.AddQuestion(q)
+		}
+		err = db.UpdateQuiz(qz)
+		if err != nil {
+			return err
+		}
+		ids := []uint{}
+		for _, q := range qz.Questions {
+			ids = append(ids, q.ID)
+		}
+		err = db.SetQuestionPositions(quizID, ids)
+		if err != nil {
+			return err
+		}
+		err = recordRevision(db, u.Email, fmt.Sprintf("imported %d questions", len(qq)), quizID)
+		if err != nil || !dryRun {
+			return err
+		}
+		return errDryRun
+	})
+	if errors.Is(err, errDryRun) {
+		return nil
+	}
+	return err
+}
+
+// freeQuizName returns name, or the first of "name (2)", "name (3)"... that no quiz uses yet.
+// Names of trashed quizzes aren't free, they may still be restored.
+func freeQuizName(db models.QuizStore, name string) (string, error) {
+	candidate := name
+	for i := 2; ; i++ {
+		taken, err := db.QuizNameTaken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s (%d)", name, i)
+	}
+}
+
+func (hub *QHub) GetTags(ctx context.Context) (tus []*models.TagUsage, err error) {
+	return hub.db.GetTagUsage()
+}
+
+// UpdateQuizTags attaches the tags in add and detaches the ones in remove
+func (hub *QHub) UpdateQuizTags(ctx context.Context, quizID uint, add, remove []string) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		err = updateTags(db, add, remove,
+			func(tt []*models.Tag) error { return db.AddQuizTags(quizID, tt) },
+			func(tt []*models.Tag) error { return db.RemoveQuizTags(quizID, tt) })
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, "changed the tags", quizID)
+	})
+}
+
+// UpdateQuestionTags attaches the tags in add to a question of the quiz and detaches the ones in remove
+func (hub *QHub) UpdateQuestionTags(ctx context.Context, id, quizID uint, add, remove []string) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		if !qz.HasQuestion(id) {
+			return gorm.ErrRecordNotFound
+		}
+		err = updateTags(db, add, remove,
+			func(tt []*models.Tag) error { return db.AddQuestionTags(id, tt) },
+			func(tt []*models.Tag) error { return db.RemoveQuestionTags(id, tt) })
+		if err != nil {
+			return err
+		}
+		quizIDs, err := db.GetQuestionQuizIDs(id)
+		if err != nil {
+			return err
+		}
+		return recordRevision(db, u.Email, fmt.Sprintf("changed the tags of question %d", id), quizIDs...)
+	})
+}
+
+func updateTags(db models.QuizStore, add, remove []string, attach, detach func([]*models.Tag) error) error {
+	tt, err := resolveTags(db, remove, false)
+	if err != nil {
+		return err
+	}
+	if len(tt) > 0 {
+		err = detach(tt)
+		if err != nil {
+			return err
+		}
+	}
+	tt, err = resolveTags(db, add, true)
+	if err != nil || len(tt) == 0 {
+		return err
+	}
+	return attach(tt)
+}
+
+// PurgeTrash permanently removes everything that has been in the trash for longer than retention
+func (hub *QHub) PurgeTrash(ctx context.Context, retention time.Duration) (err error) {
+	return hub.db.PurgeTrash(time.Now().Add(-retention))
+}
+
+// recordRevision snapshots quizzes after a change made by the user with email
+func recordRevision(db models.QuizStore, email, action string, quizIDs ...uint) error {
+	u, err := db.GetUserByEmail(email)
+	if err != nil {
+		return err
+	}
+	for _, id := range quizIDs {
+		qz, err := db.GetQuiz(id)
+		if err != nil {
+			return err
+		}
+		err = db.CreateQuizRevision(&models.QuizRevision{
+			QuizID:   id,
+			UserID:   u.ID,
+			Action:   action,
+			Snapshot: models.NewQuizSnapshot(qz),
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// GetQuizRevisions lists the revisions of a quiz, newest first, to its collaborators
+func (hub *QHub) GetQuizRevisions(ctx context.Context, quizID uint) (rr []*models.QuizRevision, err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return rr, err
+	}
+	qz, err := hub.db.GetQuiz(quizID)
+	if err != nil {
+		return rr, err
+	}
+	if !qz.IsCollaborator(u.Email) {
+		return rr, NotPermittedError
+	}
+	return hub.db.GetQuizRevisions(quizID)
+}
+
+// GetQuizRevision returns a revision of a quiz with the content it recorded
+func (hub *QHub) GetQuizRevision(ctx context.Context, quizID, number uint) (r *models.QuizRevision, err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return r, err
+	}
+	qz, err := hub.db.GetQuiz(quizID)
+	if err != nil {
+		return r, err
+	}
+	if !qz.IsCollaborator(u.Email) {
+		return r, NotPermittedError
+	}
+	return hub.db.GetQuizRevision(quizID, number)
+}
+
+// DiffQuizRevisions compares two revisions of a quiz, from may come after to
+func (hub *QHub) DiffQuizRevisions(ctx context.Context, quizID, from, to uint) (d *models.QuizDiff, err error) {
+	a, err := hub.GetQuizRevision(ctx, quizID, from)
+	if err != nil {
+		return d, err
+	}
+	b, err := hub.db.GetQuizRevision(quizID, to)
+	if err != nil {
+		return d, err
+	}
+	return models.DiffRevisions(a, b), nil
+}
+
+// RollbackQuiz gives a quiz back the tags, rounds and questions it had at a revision, which is recorded as a
+// new revision. Questions added since go to the trash, removed ones are put back and ones purged from
+// the trash since come back as new questions. Questions shared with other quizzes change there too.
+func (hub *QHub) RollbackQuiz(ctx context.Context, quizID, number uint) (err error) {
+	u, err := getUserFromContext(ctx, hub.UserContextKey())
+	if err != nil {
+		return err
+	}
+	return hub.db.WithTx(ctx, func(db models.QuizStore) error {
+		author, err := db.GetUserByEmail(u.Email)
+		if err != nil {
+			return err
+		}
+		qz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		if !qz.CanEdit(u.Email) {
+			return NotPermittedError
+		}
+		r, err := db.GetQuizRevision(quizID, number)
+		if err != nil {
+			return err
+		}
+		if r.Snapshot == nil {
+			return fmt.Errorf("Revision %d of quiz %d has no content to roll back to", number, quizID)
+		}
+
+		inQuiz := map[uint]bool{}
+		for _, q := range qz.Questions {
+			inQuiz[q.ID] = true
+		}
+		affected := map[uint]bool{quizID: true}
+		restored := []*models.Question{}
+		attach := []*models.Question{}
+		for _, rq := range r.Snapshot.Questions {
+			q, err := db.GetQuestion(rq.ID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				q = rq.Question(quizID)
+				q.UserID = author.ID
+				q.Tags, err = resolveTags(db, rq.Tags, true)
+				if err != nil {
+					return err
+				}
+				restored = append(restored, q)
+				attach = append(attach, q)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			restored = append(restored, q)
+			changed := false
+			if rq.Restore(q) {
+				err = db.UpdateQuestion(q.ID, q)
+				if err != nil {
+					return err
+				}
+				changed = true
+			}
+			current := []string{}
+			for _, t := range q.Tags {
+				current = append(current, t.Name)
+			}
+			add, remove := missingNames(current, rq.Tags), missingNames(rq.Tags, current)
+			if len(add) > 0 || len(remove) > 0 {
+				err = updateTags(db, add, remove,
+					func(tt []*models.Tag) error { return db.AddQuestionTags(q.ID, tt) },
+					func(tt []*models.Tag) error { return db.RemoveQuestionTags(q.ID, tt) })
+				if err != nil {
+					return err
+				}
+				changed = true
+			}
+			if changed {
+				quizIDs, err := db.GetQuestionQuizIDs(q.ID)
+				if err != nil {
+					return err
+				}
+				for _, id := range quizIDs {
+					affected[id] = true
+				}
+			}
+			if inQuiz[q.ID] {
+				delete(inQuiz, q.ID)
+				continue
+			}
+			// Removed since, take it out of the trash if it is still there
+			err = db.RestoreQuestion(q.ID, quizID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				attach = append(attach, q)
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+		// What is left was added after the revision
+		for id := range inQuiz {
+			err = db.DeleteQuestion(id, quizID)
+			if err != nil {
+				return err
+			}
+		}
+		if len(attach) > 0 {
+			qz.Tags, qz.Questions = nil, attach
+			err = db.UpdateQuiz(qz)
+			if err != nil {
+				return err
+			}
+		}
+		order := []uint{}
+		for _, q := range restored {
+			order = append(order, q.ID)
+		}
+		err = db.SetQuestionPositions(quizID, order)
+		if err != nil {
+			return err
+		}
+		rounds, err := restoreRounds(db, quizID, r.Snapshot.Rounds)
+		if err != nil {
+			return err
+		}
+		for i, q := range restored {
+			err = db.SetQuestionRound(quizID, q.ID, rounds[r.Snapshot.Questions[i].Round])
+			if err != nil {
+				return err
+			}
+		}
+
+		current := []string{}
+		quiz, err := db.GetQuiz(quizID)
+		if err != nil {
+			return err
+		}
+		for _, t := range quiz.Tags {
+			current = append(current, t.Name)
+		}
+		err = updateTags(db, missingNames(current, r.Snapshot.Tags), missingNames(r.Snapshot.Tags, current),
+			func(tt []*models.Tag) error { return db.AddQuizTags(quizID, tt) },
+			func(tt []*models.Tag) error { return db.RemoveQuizTags(quizID, tt) })
+		if err != nil {
+			return err
+		}
+
+		ids := []uint{}
+		for id := range affected {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+		return recordRevision(db, u.Email, fmt.Sprintf("rolled back quiz %d to revision %d", quizID, number), ids...)
+	})
+}
+
+// restoreRounds gives a quiz the rounds of a snapshot, matching the rounds it has by title. It
+// returns the IDs of the rounds by title.
+func restoreRounds(db models.QuizStore, quizID uint, brs []*models.BundleRound) (ids map[string]*uint, err error) {
+	current, err := db.GetRounds(quizID)
+	if err != nil {
+		return ids, err
+	}
+	byTitle := map[string]*models.Round{}
+	for _, r := range current {
+		byTitle[r.Title] = r
+	}
+	ids = map[string]*uint{}
+	order := []uint{}
+	for _, br := range brs {
+		want := br.Round(quizID)
+		r, ok := byTitle[want.Title]
+		switch {
+		case !ok:
+			r = want
+			err = db.CreateRound(r)
+		case r.PointsMultiplier != want.PointsMultiplier || r.TimerSeconds != want.TimerSeconds:
+			r.PointsMultiplier, r.TimerSeconds = want.PointsMultiplier, want.TimerSeconds
+			err = db.UpdateRound(r)
+		}
+		if err != nil {
+			return ids, err
+		}
+		delete(byTitle, r.Title)
+		id := r.ID
+		ids[r.Title] = &id
+		order = append(order, id)
+	}
+	// What is left was added after the snapshot
+	for _, r := range current {
+		if _, ok := byTitle[r.Title]; ok {
+			err = db.DeleteRound(r.ID)
+			if err != nil {
+				return ids, err
+			}
+		}
+	}
+	return ids, db.SetRoundPositions(quizID, order)
+}
+
+// missingNames returns the names in want that are not in have
+func missingNames(have, want []string) []string {
+	in := map[string]bool{}
+	for _, n := range have {
+		in[n] = true
+	}
+	missing := []string{}
+	for _, n := range want {
+		if !in[n] {
+			missing = append(missing, n)
+		}
 	}
-	return hub.db.DeleteQuestion(id, quizID)
+	return missing
 }
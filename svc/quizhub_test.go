@@ -0,0 +1,245 @@
+package svc
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
+)
+
+// postgresTestDSN names the environment variable pointing the tests at a Postgres database
+const postgresTestDSN = "LAQZ_TEST_POSTGRES"
+
+// forEachHub runs fn against a hub on every store, each time empty. Postgres is skipped unless
+// LAQZ_TEST_POSTGRES is set, the tests wipe the database it points at.
+func forEachHub(t *testing.T, fn func(t *testing.T, hub *QHub)) {
+	t.Run("memory", func(t *testing.T) {
+		fn(t, NewQHub(models.NewQuizMemStore()))
+	})
+	t.Run("sqlite", func(t *testing.T) {
+		db, err := models.NewQuizSQLiteStore(filepath.Join(t.TempDir(), "laqz.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.Migrate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, NewQHub(db))
+	})
+	t.Run("postgres", func(t *testing.T) {
+		dsn := os.Getenv(postgresTestDSN)
+		if dsn == "" {
+			t.Skipf("%s is not set", postgresTestDSN)
+		}
+		db, err := models.NewQuizPGStore(dsn)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.MigrateDown(0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = db.Migrate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		fn(t, NewQHub(db))
+	})
+}
+
+// logIn signs a user in and returns the context of their requests
+func logIn(t *testing.T, hub *QHub, email string) context.Context {
+	t.Helper()
+	u := &models.User{Email: email, Name: email}
+	err := hub.LogIn(context.Background(), u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	u, err = hub.db.GetUserByEmail(email)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return context.WithValue(context.Background(), hub.UserContextKey(), u)
+}
+
+func TestImportQuizName(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		ctx := logIn(t, hub, "ann@example.com")
+		qz, err := hub.CreateQuiz(ctx, "Capitals", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		trashed, err := hub.CreateQuiz(ctx, "Capitals (2)", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = hub.DeleteQuiz(ctx, trashed.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := hub.ExportQuiz(ctx, qz.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The trashed quiz still holds its name, it may be restored
+		imported, err := hub.ImportQuiz(ctx, b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if imported.Name != "Capitals (3)" {
+			t.Fatalf("got the import named %q, want %q", imported.Name, "Capitals (3)")
+		}
+		err = hub.RestoreQuiz(ctx, trashed.ID)
+		if err != nil {
+			t.Fatalf("restoring the trashed quiz: %v", err)
+		}
+	})
+}
+
+func TestForkQuiz(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		ann := logIn(t, hub, "ann@example.com")
+		bob := logIn(t, hub, "bob@example.com")
+		src, err := hub.CreateQuiz(ann, "Capitals", []string{"geography"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = hub.AddQuestion(ann, &models.Question{QuizID: src.ID, Text: "Capital of France?", Answer: "Paris"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = hub.ForkQuiz(bob, src.ID)
+		if !errors.Is(err, NotPermittedError) {
+			t.Fatalf("got %v forking a private quiz of someone else, want NotPermittedError", err)
+		}
+		err = hub.ToggleQuizPrivacy(ann, src.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// A trashed fork keeps its name, the next fork takes the one after it
+		first, err := hub.ForkQuiz(bob, src.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = hub.DeleteQuiz(bob, first.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		fork, err := hub.ForkQuiz(bob, src.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if fork.Name != "Capitals (3)" || first.Name != "Capitals (2)" {
+			t.Fatalf("got forks named %q and %q, want %q and %q", first.Name, fork.Name, "Capitals (2)", "Capitals (3)")
+		}
+		got, err := hub.GetQuiz(bob, fork.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !got.Private || !got.IsOwner("bob@example.com") || got.ForkedFromID == nil || *got.ForkedFromID != src.ID {
+			t.Fatalf("got a fork private %v owned by bob %v, want a private copy of %d owned by bob", got.Private, got.IsOwner("bob@example.com"), src.ID)
+		}
+		if len(got.Questions) != 1 || got.Questions[0].Answer != "Paris" || len(got.Tags) != 1 {
+			t.Fatalf("got %d questions and %d tags, want the question and tag of the original", len(got.Questions), len(got.Tags))
+		}
+	})
+}
+
+func TestRevisionsOfSharing(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		ann := logIn(t, hub, "ann@example.com")
+		bob := logIn(t, hub, "bob@example.com")
+		logIn(t, hub, "cat@example.com")
+		qz, err := hub.CreateQuiz(ann, "Capitals", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		steps := []func() error{
+			func() error { return hub.ToggleQuizPrivacy(ann, qz.ID) },
+			func() error { return hub.AddCollaborator(ann, qz.ID, "bob@example.com", models.RoleViewer) },
+			func() error { return hub.AddCollaborator(ann, qz.ID, "bob@example.com", models.RoleEditor) },
+			func() error { return hub.AddCollaborator(ann, qz.ID, "cat@example.com", models.RoleViewer) },
+			func() error { return hub.RemoveCollaborator(ann, qz.ID, "cat@example.com") },
+			func() error { return hub.TransferOwnership(ann, qz.ID, "bob@example.com") },
+			func() error { return hub.RemoveCollaborator(ann, qz.ID, "ann@example.com") },
+		}
+		for _, step := range steps {
+			err = step()
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		rr, err := hub.GetQuizRevisions(bob, qz.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []string{}
+		for _, r := range rr {
+			got = append(got, r.Action)
+		}
+		want := []string{
+			"left the quiz",
+			"handed ownership to bob@example.com",
+			"removed cat@example.com",
+			"added cat@example.com as viewer",
+			"changed the role of bob@example.com to editor",
+			"added bob@example.com as viewer",
+			"made the quiz public",
+			"created the quiz",
+		}
+		if len(got) != len(want) {
+			t.Fatalf("got revisions %q, want %q", got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("got revisions %q, want %q", got, want)
+			}
+		}
+	})
+}
+
+func TestQuestionsOutsideTheQuiz(t *testing.T) {
+	forEachHub(t, func(t *testing.T, hub *QHub) {
+		ann := logIn(t, hub, "ann@example.com")
+		bob := logIn(t, hub, "bob@example.com")
+		private, err := hub.CreateQuiz(ann, "Capitals", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		q := &models.Question{QuizID: private.ID, Text: "Capital of France?", Answer: "Paris"}
+		err = hub.AddQuestion(ann, q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		own, err := hub.CreateQuiz(bob, "Rivers", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Bob's role on his own quiz doesn't reach the questions of Ann's
+		_, err = hub.GetQuestion(bob, q.ID, own.ID)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("got %v reading a question of another quiz, want ErrRecordNotFound", err)
+		}
+		err = hub.UpdateQuestion(bob, q.ID, own.ID, &models.Question{Text: "Capital of Spain?", Answer: "Madrid"})
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Fatalf("got %v editing a question of another quiz, want ErrRecordNotFound", err)
+		}
+		_, err = hub.GetQuestion(bob, q.ID, private.ID)
+		if !errors.Is(err, NotPermittedError) {
+			t.Fatalf("got %v reading a question of a private quiz, want NotPermittedError", err)
+		}
+		got, err := hub.GetQuestion(ann, q.ID, private.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Answer != "Paris" {
+			t.Fatalf("got answer %q, want the question left alone", got.Answer)
+		}
+	})
+}
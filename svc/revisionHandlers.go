@@ -0,0 +1,135 @@
+package svc
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
+)
+
+// respondRevisionErr maps the errors of the revision endpoints to a status
+func (s *QServer) respondRevisionErr(w http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.respond(w, req, nil, http.StatusNotFound, nil)
+		return
+	}
+	if errors.Is(err, NotPermittedError) {
+		s.respond(w, req, nil, http.StatusForbidden, err)
+		return
+	}
+	s.respond(w, req, nil, http.StatusInternalServerError, err)
+}
+
+// revisionParam reads a revision number from the route or the query
+func revisionParam(value string) (uint, error) {
+	n, err := strconv.Atoi(value)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("Bad revision supplied: %q", value)
+	}
+	return uint(n), nil
+}
+
+func (s *QServer) GetQuizRevisions() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Revisions []*models.QuizRevision `json:"revisions"`
+		}
+
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["id"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		rr, err := s.hub.GetQuizRevisions(req.Context(), uint(id))
+		if err != nil {
+			s.respondRevisionErr(w, req, err)
+			return
+		}
+		s.respond(w, req, Response{Revisions: rr}, http.StatusOK, nil)
+	}
+}
+
+func (s *QServer) GetQuizRevision() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Revision *models.QuizRevision `json:"revision"`
+		}
+
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["id"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		number, err := revisionParam(params["number"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		r, err := s.hub.GetQuizRevision(req.Context(), uint(id), number)
+		if err != nil {
+			s.respondRevisionErr(w, req, err)
+			return
+		}
+		s.respond(w, req, Response{Revision: r}, http.StatusOK, nil)
+	}
+}
+
+// DiffQuizRevisions compares the revisions given as from and to in the query
+func (s *QServer) DiffQuizRevisions() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Diff *models.QuizDiff `json:"diff"`
+		}
+
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["id"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		from, err := revisionParam(req.URL.Query().Get("from"))
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		to, err := revisionParam(req.URL.Query().Get("to"))
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		d, err := s.hub.DiffQuizRevisions(req.Context(), uint(id), from, to)
+		if err != nil {
+			s.respondRevisionErr(w, req, err)
+			return
+		}
+		s.respond(w, req, Response{Diff: d}, http.StatusOK, nil)
+	}
+}
+
+func (s *QServer) RollbackQuiz() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["id"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		number, err := revisionParam(params["number"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		err = s.hub.RollbackQuiz(req.Context(), uint(id), number)
+		if err != nil {
+			s.respondRevisionErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
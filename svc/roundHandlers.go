@@ -0,0 +1,211 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
+)
+
+// respondRoundErr maps the errors of the round endpoints to a status
+func (s *QServer) respondRoundErr(w http.ResponseWriter, req *http.Request, err error) {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		s.respond(w, req, nil, http.StatusNotFound, nil)
+		return
+	}
+	if errors.Is(err, NotPermittedError) {
+		s.respond(w, req, nil, http.StatusForbidden, err)
+		return
+	}
+	if errors.Is(err, models.ErrInvalidRound) || errors.Is(err, InvalidOrderError) {
+		s.respond(w, req, nil, http.StatusBadRequest, err)
+		return
+	}
+	s.respond(w, req, nil, http.StatusInternalServerError, err)
+}
+
+// roundParams reads the quiz and round IDs of the routes under /{quiz_id}/rounds/{id}
+func roundParams(req *http.Request) (id, quizID uint, err error) {
+	params := mux.Vars(req)
+	rid, err := strconv.Atoi(params["id"])
+	if err != nil {
+		return 0, 0, fmt.Errorf("Bad ID supplied")
+	}
+	qid, err := strconv.Atoi(params["quiz_id"])
+	if err != nil {
+		return 0, 0, fmt.Errorf("Bad Quiz ID supplied")
+	}
+	return uint(rid), uint(qid), nil
+}
+
+func (s *QServer) GetRounds() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Rounds []*models.Round `json:"rounds"`
+		}
+
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["id"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		rr, err := s.hub.GetRounds(req.Context(), uint(id))
+		if err != nil {
+			s.respondRoundErr(w, req, err)
+			return
+		}
+		s.respond(w, req, Response{Rounds: rr}, http.StatusOK, nil)
+	}
+}
+
+func (s *QServer) CreateRound() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Title            string   `json:"title,omitempty"`
+			PointsMultiplier *float64 `json:"points_multiplier,omitempty"`
+			TimerSeconds     uint     `json:"timer_seconds,omitempty"`
+		}
+		type Response struct {
+			Round *models.Round `json:"round"`
+		}
+
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["id"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		// Rounds count points as they are unless told otherwise
+		multiplier := 1.0
+		if r.PointsMultiplier != nil {
+			multiplier = *r.PointsMultiplier
+		}
+		round := models.NewRound(uint(id), r.Title, multiplier, r.TimerSeconds)
+		err = s.hub.CreateRound(req.Context(), round)
+		if err != nil {
+			s.respondRoundErr(w, req, err)
+			return
+		}
+		s.respond(w, req, Response{Round: round}, http.StatusCreated, nil)
+	}
+}
+
+// UpdateRound replaces the title and the settings of a round
+func (s *QServer) UpdateRound() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Title            string   `json:"title,omitempty"`
+			PointsMultiplier *float64 `json:"points_multiplier,omitempty"`
+			TimerSeconds     uint     `json:"timer_seconds,omitempty"`
+		}
+
+		id, quizID, err := roundParams(req)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		multiplier := 1.0
+		if r.PointsMultiplier != nil {
+			multiplier = *r.PointsMultiplier
+		}
+		err = s.hub.UpdateRound(req.Context(), id, quizID, models.NewRound(quizID, r.Title, multiplier, r.TimerSeconds))
+		if err != nil {
+			s.respondRoundErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+func (s *QServer) DeleteRound() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		id, quizID, err := roundParams(req)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		err = s.hub.DeleteRound(req.Context(), id, quizID)
+		if err != nil {
+			s.respondRoundErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+func (s *QServer) ReorderRounds() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			RoundIDs []uint `json:"round_ids,omitempty"`
+		}
+
+		params := mux.Vars(req)
+		id, err := strconv.Atoi(params["id"])
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		err = s.hub.ReorderRounds(req.Context(), uint(id), r.RoundIDs)
+		if err != nil {
+			s.respondRoundErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+// SetRoundQuestions makes the listed questions the questions of a round, in that order. An empty
+// list empties the round.
+func (s *QServer) SetRoundQuestions() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			QuestionIDs []uint `json:"question_ids"`
+		}
+
+		id, quizID, err := roundParams(req)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		r := Request{}
+		defer req.Body.Close()
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		err = s.hub.SetRoundQuestions(req.Context(), id, quizID, r.QuestionIDs)
+		if err != nil {
+			s.respondRoundErr(w, req, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
@@ -19,12 +19,47 @@ func (s *QServer) routes() {
 	quizRoutes.Handle("/{id}/toggleVisibility", s.AuthMW(s.ToggleQuizPrivacy())).Methods("PATCH")
 	quizRoutes.Handle("/{id}/viewQuestions", s.AuthMW(s.GetQuizQuestions())).Methods("GET")
 	quizRoutes.Handle("/{id}/addQuestion", s.AuthMW(s.AddQuestion())).Methods("POST")
+	quizRoutes.Handle("/{id}/importQuestions", s.AuthMW(s.ImportQuestionsCSV())).Methods("POST")
 	quizRoutes.Handle("/{quiz_id}/question/{id}/", s.AuthMW(s.GetQuestion())).Methods("GET")
 	quizRoutes.Handle("/{id}/editQuestion", s.AuthMW(s.UpdateQuestion())).Methods("PATCH")
 	quizRoutes.Handle("/{quiz_id}/deleteQuestion/{id}/", s.AuthMW(s.DeleteQuestion())).Methods("DELETE")
+	quizRoutes.Handle("/{id}/reorderQuestions", s.AuthMW(s.ReorderQuestions())).Methods("PATCH")
+	quizRoutes.Handle("/{id}/collaborators", s.AuthMW(s.GetCollaborators())).Methods("GET")
+	quizRoutes.Handle("/{id}/addCollaborator", s.AuthMW(s.AddCollaborator())).Methods("POST")
+	quizRoutes.Handle("/{id}/removeCollaborator/{email}", s.AuthMW(s.RemoveCollaborator())).Methods("DELETE")
+	quizRoutes.Handle("/{id}/transferOwnership", s.AuthMW(s.TransferOwnership())).Methods("POST")
+	quizRoutes.Handle("/{id}/tags", s.AuthMW(s.UpdateQuizTags())).Methods("PATCH")
+	quizRoutes.Handle("/{quiz_id}/question/{id}/tags", s.AuthMW(s.UpdateQuestionTags())).Methods("PATCH")
 	quizRoutes.Handle("/list/user/", s.AuthMW(s.GetMyQuizzes())).Methods("GET")
+	quizRoutes.Handle("/trash", s.AuthMW(s.GetTrash())).Methods("GET")
+	quizRoutes.Handle("/{id}/restore", s.AuthMW(s.RestoreQuiz())).Methods("POST")
+	quizRoutes.Handle("/{quiz_id}/restoreQuestion/{id}/", s.AuthMW(s.RestoreQuestion())).Methods("POST")
 	quizRoutes.Handle("/list", s.GetQuizzes()).Methods("GET")
+	quizRoutes.Handle("/search", s.OptionalAuthMW(s.SearchQuizzes())).Methods("GET")
 	quizRoutes.Handle("/upload", s.AuthMW(s.UploadFile()))
+	quizRoutes.Handle("/{id}/export", s.AuthMW(s.ExportQuiz())).Methods("GET")
+	quizRoutes.Handle("/import", s.AuthMW(s.ImportQuiz())).Methods("POST")
+	quizRoutes.Handle("/{id}/fork", s.AuthMW(s.ForkQuiz())).Methods("POST")
+	quizRoutes.Handle("/{id}/attachQuestions", s.AuthMW(s.AttachQuestions())).Methods("POST")
+	quizRoutes.Handle("/{id}/revisions", s.AuthMW(s.GetQuizRevisions())).Methods("GET")
+	quizRoutes.Handle("/{id}/revisions/diff", s.AuthMW(s.DiffQuizRevisions())).Methods("GET")
+	quizRoutes.Handle("/{id}/revisions/{number}", s.AuthMW(s.GetQuizRevision())).Methods("GET")
+	quizRoutes.Handle("/{id}/revisions/{number}/rollback", s.AuthMW(s.RollbackQuiz())).Methods("POST")
+	quizRoutes.Handle("/{id}/rounds", s.AuthMW(s.GetRounds())).Methods("GET")
+	quizRoutes.Handle("/{id}/rounds", s.AuthMW(s.CreateRound())).Methods("POST")
+	quizRoutes.Handle("/{id}/reorderRounds", s.AuthMW(s.ReorderRounds())).Methods("PATCH")
+	quizRoutes.Handle("/{quiz_id}/rounds/{id}", s.AuthMW(s.UpdateRound())).Methods("PATCH")
+	quizRoutes.Handle("/{quiz_id}/rounds/{id}", s.AuthMW(s.DeleteRound())).Methods("DELETE")
+	quizRoutes.Handle("/{quiz_id}/rounds/{id}/questions", s.AuthMW(s.SetRoundQuestions())).Methods("PUT")
+
+	// Question Routes
+	questionRoutes := s.router.PathPrefix("/question").Subrouter()
+	questionRoutes.Handle("/bank", s.AuthMW(s.GetQuestionBank())).Methods("GET")
+
+	// Tag Routes
+	tagRoutes := s.router.PathPrefix("/tag").Subrouter()
+	tagRoutes.Handle("/list", s.GetTags()).Methods("GET")
+	tagRoutes.Handle("/{name}/quizzes", s.GetQuizzesByTag()).Methods("GET")
 
 	// PlaySessionRoutes
 	psRoutes := s.router.PathPrefix("/ps").Subrouter()
@@ -36,11 +71,28 @@ func (s *QServer) routes() {
 	psRoutes.Handle("/{code}/end", s.AuthMW(s.EndPS())).Methods("POST")
 	psRoutes.Handle("/{code}/next", s.AuthMW(s.IncrementPSQuestion())).Methods("POST")
 	psRoutes.Handle("/{code}/prev", s.AuthMW(s.DecrementPSQuestion())).Methods("POST")
+	psRoutes.Handle("/{code}/nextRound", s.AuthMW(s.NextPSRound())).Methods("POST")
+	psRoutes.Handle("/{code}/scores", s.AuthMW(s.GetPSScores())).Methods("GET")
+	psRoutes.Handle("/{code}/buzzes", s.AuthMW(s.GetPSBuzzes())).Methods("GET")
+	psRoutes.Handle("/{code}/acceptBuzz", s.AuthMW(s.AcceptPSBuzz())).Methods("POST")
+	psRoutes.Handle("/{code}/rejectBuzz", s.AuthMW(s.RejectPSBuzz())).Methods("POST")
 	psRoutes.Handle("/{code}/reveal", s.AuthMW(s.RevealPSCurrentAnswer())).Methods("POST")
+	psRoutes.Handle("/{code}/submitChoices", s.AuthMW(s.SubmitPSChoices())).Methods("POST")
+	psRoutes.Handle("/{code}/submitNumber", s.AuthMW(s.SubmitPSNumber())).Methods("POST")
+	psRoutes.Handle("/{code}/answer", s.AuthMW(s.SubmitPSAnswer())).Methods("POST")
+	psRoutes.Handle("/{code}/lockAnswers", s.AuthMW(s.LockPSAnswers())).Methods("POST")
+	psRoutes.Handle("/{code}/unlockAnswers", s.AuthMW(s.UnlockPSAnswers())).Methods("POST")
+	psRoutes.Handle("/{code}/autoReveal", s.AuthMW(s.SetPSAutoReveal())).Methods("POST")
+	psRoutes.Handle("/{code}/autopilot", s.AuthMW(s.StartPSAutopilot())).Methods("POST")
+	psRoutes.Handle("/{code}/pauseAutopilot", s.AuthMW(s.PausePSAutopilot())).Methods("POST")
+	psRoutes.Handle("/{code}/resumeAutopilot", s.AuthMW(s.ResumePSAutopilot())).Methods("POST")
+	psRoutes.Handle("/{code}/takeOver", s.AuthMW(s.TakeOverPS())).Methods("POST")
+	psRoutes.Handle("/{code}/submissions", s.AuthMW(s.GetPSSubmissions())).Methods("GET")
+	psRoutes.Handle("/{code}/judge", s.AuthMW(s.JudgePSSubmission())).Methods("POST")
 	psRoutes.Handle("/{code}/addPoints", s.AuthMW(s.AddPSTeamPoints())).Methods("POST")
 	psRoutes.Handle("/{code}/assignTeamToUser", s.AuthMW(s.AddUserToTeam())).Methods("POST")
 	psRoutes.Handle("/{code}/chatMessage", s.AuthMW(s.AddUserToTeam())).Methods("POST")
-	psRoutes.Handle("/ws/{code}", s.WebSocketPS())
+	psRoutes.Handle("/ws/{code}", s.WSAuthMW(s.WebSocketPS()))
 }
 
 // CorsMW is a middleware to add CORS header to the response
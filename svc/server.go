@@ -4,6 +4,7 @@ import (
 	"context"
 	"encoding/json"
 	"net/http"
+	"sync"
 
 	"firebase.google.com/go/auth"
 	"github.com/go-kit/kit/log"
@@ -20,7 +21,9 @@ type QServer struct {
 	wsUpgrader          websocket.Upgrader
 	externalURL         string
 	fileUploadDirectory string
-	wsHubs              map[uint]*wsHub
+	// wsHubsMx guards wsHubs, which timers and autopilots reach from their own goroutines
+	wsHubsMx sync.RWMutex
+	wsHubs   map[uint]*wsHub
 }
 
 func NewQServer(hub QuizHub, listenAddr string, logger log.Logger, authClient *auth.Client, fileUploadDirectory string, externalURL string) *QServer {
@@ -40,6 +43,8 @@ func NewQServer(hub QuizHub, listenAddr string, logger log.Logger, authClient *a
 	}
 	s.server = &http.Server{Addr: listenAddr, Handler: s.CorsMW()}
 	s.routes()
+	hub.OnQuestionTimer(s.broadcastTimer)
+	hub.OnAutopilot(s.broadcastAutopilot)
 	return s
 }
 
@@ -51,14 +56,40 @@ func (s *QServer) Start() error {
 // Shutdown gracefully terminates the server
 func (s *QServer) Shutdown(ctx context.Context) error {
 	// Drop all websockets
-	for i := range s.wsHubs {
-		for c := range s.wsHubs[i].connections {
-			s.wsHubs[i].removeConnection(c)
-		}
+	s.wsHubsMx.RLock()
+	for _, h := range s.wsHubs {
+		h.removeConnections()
 	}
+	s.wsHubsMx.RUnlock()
 	return s.server.Shutdown(ctx)
 }
 
+// hubFor returns the websocket hub of a play session. A missing hub is created when create is
+// set, otherwise nil is returned for it.
+func (s *QServer) hubFor(code uint, create bool) *wsHub {
+	s.wsHubsMx.RLock()
+	h, ok := s.wsHubs[code]
+	s.wsHubsMx.RUnlock()
+	if ok || !create {
+		return h
+	}
+	s.wsHubsMx.Lock()
+	defer s.wsHubsMx.Unlock()
+	if h, ok := s.wsHubs[code]; ok {
+		return h
+	}
+	h = newHub()
+	s.wsHubs[code] = h
+	return h
+}
+
+// dropHub forgets the websocket hub of a play session
+func (s *QServer) dropHub(code uint) {
+	s.wsHubsMx.Lock()
+	defer s.wsHubsMx.Unlock()
+	delete(s.wsHubs, code)
+}
+
 // respond is a internal utility to set proper HTTP responses
 func (s *QServer) respond(w http.ResponseWriter, req *http.Request, data interface{}, statusCode int, err error) {
 	w.WriteHeader(statusCode)
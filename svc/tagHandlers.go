@@ -0,0 +1,140 @@
+package svc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+	"github.com/tchaudhry91/laqz/svc/models"
+	"gorm.io/gorm"
+)
+
+func (s *QServer) GetTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Response struct {
+			Tags []*models.TagUsage `json:"tags"`
+		}
+		tus, err := s.hub.GetTags(req.Context())
+		if err != nil {
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, Response{Tags: tus}, http.StatusOK, nil)
+	}
+}
+
+// GetQuizzesByTag lists the public quizzes carrying a tag, with the same paging as /quiz/list
+func (s *QServer) GetQuizzesByTag() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		opts, err := listOptions(req)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+		opts.Tags = append(opts.Tags, mux.Vars(req)["name"])
+		page, err := s.hub.GetPublicQuizzes(req.Context(), opts)
+		if err != nil {
+			if errors.Is(err, models.ErrInvalidListOptions) {
+				s.respond(w, req, nil, http.StatusBadRequest, err)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, page, http.StatusOK, nil)
+	}
+}
+
+func (s *QServer) UpdateQuizTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Add    []string `json:"add,omitempty"`
+			Remove []string `json:"remove,omitempty"`
+		}
+		type Response struct {
+			Err string `json:"err,omitempty"`
+		}
+
+		r := Request{}
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+
+		defer req.Body.Close()
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+
+		resp := Response{}
+		err = s.hub.UpdateQuizTags(req.Context(), uint(id), r.Add, r.Remove)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				resp.Err = err.Error()
+				s.respond(w, req, resp, http.StatusNotFound, nil)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
+
+func (s *QServer) UpdateQuestionTags() http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		type Request struct {
+			Add    []string `json:"add,omitempty"`
+			Remove []string `json:"remove,omitempty"`
+		}
+		type Response struct {
+			Err string `json:"err,omitempty"`
+		}
+
+		r := Request{}
+		params := mux.Vars(req)
+		idStr := params["id"]
+		var id int
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+		quizIDStr := params["quiz_id"]
+		var quizID int
+		quizID, err = strconv.Atoi(quizIDStr)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, fmt.Errorf("Bad ID supplied"))
+			return
+		}
+
+		defer req.Body.Close()
+		err = json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			s.respond(w, req, nil, http.StatusBadRequest, err)
+			return
+		}
+
+		resp := Response{}
+		err = s.hub.UpdateQuestionTags(req.Context(), uint(id), uint(quizID), r.Add, r.Remove)
+		if err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				resp.Err = err.Error()
+				s.respond(w, req, resp, http.StatusNotFound, nil)
+				return
+			}
+			s.respond(w, req, nil, http.StatusInternalServerError, err)
+			return
+		}
+		s.respond(w, req, nil, http.StatusNoContent, nil)
+	}
+}
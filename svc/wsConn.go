@@ -13,14 +13,30 @@ type connection struct {
 	h *wsHub
 }
 
-func (c *connection) reader(wg *sync.WaitGroup, wsConn *websocket.Conn) {
+// reader hands the messages of the client to handle until it goes away, then drops the
+// connection so the writer stops as well
+func (c *connection) reader(wg *sync.WaitGroup, wsConn *websocket.Conn, handle func(message []byte)) {
 	defer wg.Done()
+	defer c.h.removeConnection(c)
 	for {
 		_, message, err := wsConn.ReadMessage()
 		if err != nil {
 			break
 		}
-		c.h.broadcast <- message
+		handle(message)
+	}
+}
+
+// reply sends a message to this connection only, it is dropped if the client can't keep up
+func (c *connection) reply(message []byte) {
+	c.h.connectionsMx.RLock()
+	defer c.h.connectionsMx.RUnlock()
+	if _, ok := c.h.connections[c]; !ok {
+		return
+	}
+	select {
+	case c.send <- message:
+	default:
 	}
 }
 
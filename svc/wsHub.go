@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"sync"
 	"time"
+
+	"github.com/tchaudhry91/laqz/svc/models"
 )
 
 type wsHub struct {
@@ -13,23 +15,27 @@ type wsHub struct {
 	// Registered connections.
 	connections map[*connection]struct{}
 
-	// Inbound messages from the connections.
+	// Inbound messages from the connections, as well as from timers and autopilots.
 	broadcast chan []byte
 
 	logMx sync.RWMutex
 	log   [][]byte
 }
 
+// broadcastBuffer is how many messages may wait for the hub to hand them out
+const broadcastBuffer = 64
+
 func newHub() *wsHub {
 	h := &wsHub{
 		connectionsMx: sync.RWMutex{},
-		broadcast:     make(chan []byte),
+		broadcast:     make(chan []byte, broadcastBuffer),
 		connections:   make(map[*connection]struct{}),
 	}
 
 	go func() {
 		for {
 			msg := <-h.broadcast
+			dead := []*connection{}
 			h.connectionsMx.RLock()
 			for c := range h.connections {
 				select {
@@ -37,10 +43,14 @@ func newHub() *wsHub {
 				// stop trying to send to this connection after trying for 1 second.
 				// if we have to stop, it means that a reader died so remove the connection also.
 				case <-time.After(1 * time.Second):
-					h.removeConnection(c)
+					dead = append(dead, c)
 				}
 			}
 			h.connectionsMx.RUnlock()
+			// Removing takes the write lock, which can't be had while reading the connections
+			for _, c := range dead {
+				h.removeConnection(c)
+			}
 		}
 	}()
 	return h
@@ -64,12 +74,110 @@ func (h *wsHub) BroadcastChat(name, message string) {
 	h.broadcast <- chatBytes
 }
 
+// BroadcastBuzz tells everyone about a buzz on a question. The action is "buzz" for the team now
+// first in line and "buzz_accepted" once the quizmaster gave it the question.
+func (h *wsHub) BroadcastBuzz(action string, b *models.Buzz) {
+	buzzMessage := map[string]interface{}{
+		"action":      action,
+		"question_id": b.QuestionID,
+		"ts":          b.TS,
+	}
+	if b.User != nil {
+		buzzMessage["name"] = b.User.Name
+	}
+	if b.Team != nil {
+		buzzMessage["team"] = b.Team.Name
+	}
+	buzzBytes, _ := json.Marshal(buzzMessage)
+	h.broadcast <- buzzBytes
+}
+
+// BroadcastBuzzersCleared tells everyone nobody is waiting to answer the question anymore
+func (h *wsHub) BroadcastBuzzersCleared(questionID uint) {
+	clearedMessage := map[string]interface{}{
+		"action":      "buzz_cleared",
+		"question_id": questionID,
+	}
+	clearedBytes, _ := json.Marshal(clearedMessage)
+	h.broadcast <- clearedBytes
+}
+
+// BroadcastAnswerSubmitted tells everyone a team answered a question, leaving out the answer
+func (h *wsHub) BroadcastAnswerSubmitted(sub *models.Submission) {
+	answerMessage := map[string]interface{}{
+		"action":      "answer_submitted",
+		"question_id": sub.QuestionID,
+	}
+	if sub.Team != nil {
+		answerMessage["team"] = sub.Team.Name
+	}
+	answerBytes, _ := json.Marshal(answerMessage)
+	h.broadcast <- answerBytes
+}
+
+// BroadcastTimer tells everyone how much time is left to answer a question with a "timer_tick"
+// every second, then "timer_expired" once it runs out. Ticks are dropped rather than holding up
+// the timer when the hub is backed up, the next one tells the time just as well.
+func (h *wsHub) BroadcastTimer(e TimerEvent) {
+	timerMessage := map[string]interface{}{
+		"action":      "timer_tick",
+		"question_id": e.QuestionID,
+		"remaining":   e.Remaining,
+	}
+	if e.Expired {
+		timerMessage = map[string]interface{}{
+			"action":      "timer_expired",
+			"question_id": e.QuestionID,
+			"revealed":    e.Revealed,
+		}
+	}
+	timerBytes, _ := json.Marshal(timerMessage)
+	if !e.Expired {
+		select {
+		case h.broadcast <- timerBytes:
+		default:
+		}
+		return
+	}
+	h.broadcast <- timerBytes
+}
+
+// BroadcastAutopilot tells everyone what the autopilot of the session is up to
+func (h *wsHub) BroadcastAutopilot(e AutopilotEvent) {
+	autopilotMessage := map[string]interface{}{
+		"action":   "autopilot",
+		"engaged":  e.Engaged,
+		"paused":   e.Paused,
+		"finished": e.Finished,
+	}
+	if e.Step != "" {
+		autopilotMessage["step"] = e.Step
+	}
+	if e.NextAt != nil {
+		autopilotMessage["next_at"] = e.NextAt
+	}
+	autopilotBytes, _ := json.Marshal(autopilotMessage)
+	h.broadcast <- autopilotBytes
+}
+
 func (h *wsHub) addConnection(conn *connection) {
 	h.connectionsMx.Lock()
 	defer h.connectionsMx.Unlock()
 	h.connections[conn] = struct{}{}
 }
 
+// removeConnections drops every connection of the hub and returns how many there were
+func (h *wsHub) removeConnections() int {
+	h.connectionsMx.Lock()
+	defer h.connectionsMx.Unlock()
+	n := len(h.connections)
+	for c := range h.connections {
+		delete(h.connections, c)
+		close(c.send)
+	}
+	return n
+}
+
 func (h *wsHub) removeConnection(conn *connection) {
 	h.connectionsMx.Lock()
 	defer h.connectionsMx.Unlock()
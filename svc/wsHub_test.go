@@ -0,0 +1,58 @@
+package svc
+
+import (
+	"testing"
+	"time"
+)
+
+func TestHubDropsStuckConnections(t *testing.T) {
+	h := newHub()
+	stuck := &connection{send: make(chan []byte), h: h}
+	h.addConnection(stuck)
+
+	// Nobody reads from the stuck connection, the hub gives up on it and carries on
+	h.BroadcastReload()
+	deadline := time.Now().Add(3 * time.Second)
+	for {
+		h.connectionsMx.RLock()
+		n := len(h.connections)
+		h.connectionsMx.RUnlock()
+		if n == 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("the hub never dropped the stuck connection")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	healthy := &connection{send: make(chan []byte, 1), h: h}
+	h.addConnection(healthy)
+	h.BroadcastReload()
+	select {
+	case <-healthy.send:
+	case <-time.After(3 * time.Second):
+		t.Fatal("the hub stopped handing out messages")
+	}
+}
+
+func TestHubTimerTicksDontBlock(t *testing.T) {
+	h := newHub()
+	stuck := &connection{send: make(chan []byte), h: h}
+	h.addConnection(stuck)
+
+	// The hub is held up by the stuck connection, ticks beyond its buffer are dropped
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 2*broadcastBuffer; i++ {
+			h.BroadcastTimer(TimerEvent{Code: 1, QuestionID: 1, Remaining: i})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("timer ticks blocked on a backed up hub")
+	}
+	h.removeConnections()
+}